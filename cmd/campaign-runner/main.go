@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/campaigns"
+	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/ingest"
+	"github.com/example/notification-service/internal/recipients"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := common.LoadConfig("campaign-runner")
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	logger := common.NewLogger(cfg.ServiceName)
+	shutdown, err := common.SetupOTel(ctx, cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialise telemetry")
+	}
+	defer common.ShutdownTelemetry(context.Background(), shutdown)
+
+	metricsSrv := common.StartMetricsServer(cfg.MetricsPort)
+	defer metricsSrv.Shutdown(context.Background())
+
+	if cfg.DatabaseURL == "" {
+		logger.Fatal().Msg("DATABASE_URL must be provided")
+	}
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("connect postgres")
+	}
+	defer pool.Close()
+
+	// Campaign messages take the same path as /v1/notify, so idempotency,
+	// preferences, throttling and routing all apply downstream.
+	producer := &kafka.Writer{
+		Addr:     kafka.TCP(cfg.KafkaBrokers...),
+		Topic:    cfg.NotificationTopic,
+		Balancer: &kafka.Hash{},
+	}
+	defer producer.Close()
+
+	runner := campaigns.Runner{
+		Store:      campaigns.NewPostgresRepository(pool),
+		Recipients: recipients.NewPostgresRepository(pool),
+		Notifier:   ingest.NewService(ingest.NewPostgresRepository(pool), producer),
+		Logger:     logger,
+	}
+
+	logger.Info().Msg("campaign runner started")
+	if err := runner.Run(ctx); err != nil && ctx.Err() == nil {
+		logger.Error().Err(err).Msg("campaign runner stopped")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/digest"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := common.LoadConfig("digester")
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	logger := common.NewLogger(cfg.ServiceName)
+	shutdown, err := common.SetupOTel(ctx, cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialise telemetry")
+	}
+	defer common.ShutdownTelemetry(context.Background(), shutdown)
+
+	metricsSrv := common.StartMetricsServer(cfg.MetricsPort)
+	defer metricsSrv.Shutdown(context.Background())
+
+	if cfg.DatabaseURL == "" {
+		logger.Fatal().Msg("DATABASE_URL must be provided")
+	}
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("connect postgres")
+	}
+	defer pool.Close()
+
+	// The consumer and the flush loop share writers.
+	var writerMu sync.Mutex
+	writerCache := map[string]*kafka.Writer{}
+	writerFactory := func(topic string) *kafka.Writer {
+		writerMu.Lock()
+		defer writerMu.Unlock()
+		if w, ok := writerCache[topic]; ok {
+			return w
+		}
+		writer := &kafka.Writer{
+			Addr:     kafka.TCP(cfg.KafkaBrokers...),
+			Topic:    topic,
+			Balancer: &kafka.Hash{},
+		}
+		writerCache[topic] = writer
+		return writer
+	}
+
+	stage := digest.Stage{
+		ReaderFactory: func() *kafka.Reader {
+			return kafka.NewReader(kafka.ReaderConfig{
+				Brokers: cfg.KafkaBrokers,
+				GroupID: cfg.ServiceName,
+				Topic:   cfg.DigestTopic,
+			})
+		},
+		WriterFactory: writerFactory,
+		Store:         digest.NewPostgresRepository(pool),
+		EventsTopic:   cfg.ProviderEventsTopic,
+		Logger:        logger,
+	}
+
+	logger.Info().Msg("digest stage started")
+	if err := stage.Run(ctx); err != nil && ctx.Err() == nil {
+		logger.Error().Err(err).Msg("digest stage stopped")
+	}
+	writerMu.Lock()
+	for _, writer := range writerCache {
+		_ = writer.Close()
+	}
+	writerMu.Unlock()
+}
@@ -4,12 +4,21 @@ import (
 	"context"
 	"log"
 	"os/signal"
+	"sync"
 	"syscall"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/segmentio/kafka-go"
 
 	"github.com/example/notification-service/internal/common"
 	"github.com/example/notification-service/internal/dispatcher"
+	"github.com/example/notification-service/internal/fallback"
+	"github.com/example/notification-service/internal/preferences"
+	"github.com/example/notification-service/internal/quiethours"
+	"github.com/example/notification-service/internal/recipients"
+	"github.com/example/notification-service/internal/routing"
+	"github.com/example/notification-service/internal/scheduler"
+	"github.com/example/notification-service/internal/throttle"
 )
 
 func main() {
@@ -39,8 +48,12 @@ func main() {
 		})
 	}
 
+	// The dispatcher loop and the deferred-message poller share writers.
+	var writerMu sync.Mutex
 	writerCache := map[string]*kafka.Writer{}
 	writerFactory := func(topic string) *kafka.Writer {
+		writerMu.Lock()
+		defer writerMu.Unlock()
 		if w, ok := writerCache[topic]; ok {
 			return w
 		}
@@ -56,9 +69,79 @@ func main() {
 	d := dispatcher.Dispatcher{
 		ReaderFactory: readerFactory,
 		WriterFactory: writerFactory,
+		DigestTopic:   cfg.DigestTopic,
+		EventsTopic:   cfg.ProviderEventsTopic,
 		Logger:        logger,
 	}
 
+	routes := &routing.Engine{Logger: logger}
+	if cfg.RoutingRulesFile != "" {
+		routes.Sources = append(routes.Sources, routing.FileSource{Path: cfg.RoutingRulesFile})
+	}
+	d.Router = routes
+
+	if cfg.DatabaseURL != "" {
+		pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("connect postgres")
+		}
+		defer pool.Close()
+		d.Recipients = recipients.NewPostgresRepository(pool)
+		d.Preferences = &preferences.Checker{Repo: preferences.NewPostgresRepository(pool)}
+		d.QuietHours = &quiethours.Policy{Repo: quiethours.NewPostgresRepository(pool)}
+		routes.Sources = append(routes.Sources, routing.NewPostgresRepository(pool))
+
+		throttler := &throttle.Throttler{Repo: throttle.NewPostgresRepository(pool), Replicas: cfg.DispatcherReplicas, Logger: logger}
+		if err := throttler.Refresh(ctx); err != nil {
+			logger.Fatal().Err(err).Msg("load rate limits")
+		}
+		d.Throttle = throttler
+		go func() {
+			_ = throttler.Run(ctx)
+		}()
+
+		fallbacks := fallback.NewPostgresRepository(pool)
+		d.Fallbacks = fallbacks
+		watcher := fallback.Watcher{
+			ReaderFactory: func() *kafka.Reader {
+				return kafka.NewReader(kafka.ReaderConfig{
+					Brokers: cfg.KafkaBrokers,
+					GroupID: cfg.ServiceName + "-fallback",
+					Topic:   cfg.ProviderEventsTopic,
+				})
+			},
+			WriterFactory: writerFactory,
+			Store:         fallbacks,
+			EventsTopic:   cfg.ProviderEventsTopic,
+			Logger:        logger,
+		}
+		go func() {
+			if err := watcher.Run(ctx); err != nil && ctx.Err() == nil {
+				logger.Fatal().Err(err).Msg("fallback watcher stopped")
+			}
+		}()
+
+		deferred := scheduler.NewPostgresRepository(pool)
+		d.Scheduler = deferred
+		poller := scheduler.Poller{
+			Store:         deferred,
+			WriterFactory: writerFactory,
+			Logger:        logger,
+		}
+		go func() {
+			if err := poller.Run(ctx); err != nil && ctx.Err() == nil {
+				logger.Fatal().Err(err).Msg("deferred message poller stopped")
+			}
+		}()
+	}
+
+	if err := routes.Refresh(ctx); err != nil {
+		logger.Fatal().Err(err).Msg("load routing rules")
+	}
+	go func() {
+		_ = routes.Run(ctx)
+	}()
+
 	go func() {
 		logger.Info().Msg("dispatcher service started")
 		if err := d.Run(ctx); err != nil {
@@ -7,10 +7,15 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/segmentio/kafka-go"
 
 	"github.com/example/notification-service/internal/common"
 	"github.com/example/notification-service/internal/email"
+	"github.com/example/notification-service/internal/preferences"
+	"github.com/example/notification-service/internal/suppression"
+	"github.com/example/notification-service/internal/templates"
+	"github.com/example/notification-service/internal/tracking"
 )
 
 func main() {
@@ -32,11 +37,11 @@ func main() {
 	metricsSrv := common.StartMetricsServer(cfg.MetricsPort)
 	defer metricsSrv.Shutdown(context.Background())
 
-	readerFactory := func() *kafka.Reader {
+	readerFor := func(priority string) *kafka.Reader {
 		return kafka.NewReader(kafka.ReaderConfig{
 			Brokers: cfg.KafkaBrokers,
 			GroupID: cfg.ServiceName,
-			Topic:   cfg.EmailTopic,
+			Topic:   common.PriorityTopic(cfg.EmailTopic, priority),
 		})
 	}
 
@@ -64,11 +69,32 @@ func main() {
 	}
 
 	worker := email.Worker{
-		ReaderFactory: readerFactory,
-		DLQWriter:     dlqWriter,
-		EventWriter:   eventWriter,
-		Providers:     []email.Provider{ses, sendgrid},
-		Logger:        logger,
+		Lanes:       email.DefaultLanes(readerFor),
+		DLQWriter:   dlqWriter,
+		EventWriter: eventWriter,
+		Providers:   []email.Provider{ses, sendgrid},
+		Logger:      logger,
+	}
+
+	// Without a database the worker falls back to provider-hosted templates
+	// and skips suppression checks.
+	if cfg.DatabaseURL != "" {
+		pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("connect postgres")
+		}
+		defer pool.Close()
+		worker.Templates = templates.NewService(templates.NewPostgresRepository(pool))
+		worker.Suppressions = suppression.NewPostgresRepository(pool)
+		if cfg.TrackingSecret != "" {
+			worker.Tracking = &tracking.Instrumenter{
+				Signer:   &tracking.Signer{Secret: []byte(cfg.TrackingSecret), BaseURL: cfg.TrackingBaseURL},
+				Settings: tracking.NewPostgresRepository(pool),
+			}
+		}
+	}
+	if cfg.UnsubscribeSecret != "" {
+		worker.Unsubscribe = &preferences.Signer{Secret: []byte(cfg.UnsubscribeSecret), BaseURL: cfg.PublicBaseURL}
 	}
 
 	logger.Info().Msg("email worker started")
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/experiments"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := common.LoadConfig("experiment-tracker")
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	logger := common.NewLogger(cfg.ServiceName)
+	shutdown, err := common.SetupOTel(ctx, cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialise telemetry")
+	}
+	defer common.ShutdownTelemetry(context.Background(), shutdown)
+
+	metricsSrv := common.StartMetricsServer(cfg.MetricsPort)
+	defer metricsSrv.Shutdown(context.Background())
+
+	if cfg.DatabaseURL == "" {
+		logger.Fatal().Msg("DATABASE_URL must be provided")
+	}
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("connect postgres")
+	}
+	defer pool.Close()
+
+	readerFactory := func() *kafka.Reader {
+		return kafka.NewReader(kafka.ReaderConfig{
+			Brokers: cfg.KafkaBrokers,
+			GroupID: cfg.ServiceName,
+			Topic:   cfg.ProviderEventsTopic,
+		})
+	}
+
+	tracker := experiments.Tracker{
+		ReaderFactory: readerFactory,
+		Store:         experiments.NewPostgresRepository(pool),
+		Logger:        logger,
+	}
+
+	logger.Info().Msg("experiment tracker started")
+	if err := tracker.Run(ctx); err != nil {
+		logger.Fatal().Err(err).Msg("experiment tracker stopped")
+	}
+}
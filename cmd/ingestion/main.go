@@ -12,8 +12,19 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/segmentio/kafka-go"
 
+	"github.com/example/notification-service/internal/campaigns"
 	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/experiments"
 	"github.com/example/notification-service/internal/ingest"
+	"github.com/example/notification-service/internal/journeys"
+	"github.com/example/notification-service/internal/outbound"
+	"github.com/example/notification-service/internal/preferences"
+	"github.com/example/notification-service/internal/quiethours"
+	"github.com/example/notification-service/internal/recipients"
+	"github.com/example/notification-service/internal/routing"
+	"github.com/example/notification-service/internal/suppression"
+	"github.com/example/notification-service/internal/templates"
+	"github.com/example/notification-service/internal/tracking"
 )
 
 func main() {
@@ -54,10 +65,84 @@ func main() {
 	defer producer.Close()
 
 	h := ingest.NewHandler(repo, producer, cfg, logger)
+	emailProducer := &kafka.Writer{
+		Addr:     kafka.TCP(cfg.KafkaBrokers...),
+		Topic:    cfg.EmailTopic,
+		Balancer: &kafka.Hash{},
+	}
+	defer emailProducer.Close()
+
+	templateService := templates.NewService(templates.NewPostgresRepository(pool))
+	templateRouter := templates.NewHandler(templateService, emailProducer, cfg.TestSendAllowList, logger).Router()
+
+	mux := http.NewServeMux()
+	notifyRouter := h.Router()
+	mux.Handle("/v1/notify", notifyRouter)
+	mux.Handle("/v1/messages/", notifyRouter)
+	mux.Handle("/v1/templates", templateRouter)
+	mux.Handle("/v1/templates/", templateRouter)
+	mux.Handle("/v1/experiments/", experiments.NewHandler(experiments.NewPostgresRepository(pool), logger).Router())
+
+	unsubscribeSigner := &preferences.Signer{Secret: []byte(cfg.UnsubscribeSecret), BaseURL: cfg.PublicBaseURL}
+	preferenceRouter := preferences.NewHandler(preferences.NewPostgresRepository(pool), unsubscribeSigner, logger).Router()
+	mux.Handle("/v1/preferences/", preferenceRouter)
+	mux.Handle("/v1/unsubscribe", preferenceRouter)
+
+	suppressionRouter := suppression.NewHandler(suppression.NewPostgresRepository(pool), logger).Router()
+	mux.Handle("/v1/suppressions", suppressionRouter)
+	mux.Handle("/v1/suppressions/", suppressionRouter)
+	recipientRouter := recipients.NewHandler(recipients.NewPostgresRepository(pool), logger).Router()
+	mux.Handle("/v1/recipients", recipientRouter)
+	mux.Handle("/v1/recipients/", recipientRouter)
+	campaignRouter := campaigns.NewHandler(campaigns.NewPostgresRepository(pool), logger).Router()
+	mux.Handle("/v1/segments", campaignRouter)
+	mux.Handle("/v1/segments/", campaignRouter)
+	mux.Handle("/v1/campaigns", campaignRouter)
+	mux.Handle("/v1/campaigns/", campaignRouter)
+	journeyRouter := journeys.NewHandler(journeys.NewPostgresRepository(pool), logger).Router()
+	mux.Handle("/v1/journeys", journeyRouter)
+	mux.Handle("/v1/journeys/", journeyRouter)
+	webhookRouter := outbound.NewHandler(outbound.NewPostgresRepository(pool), outbound.NewSender(), logger).Router()
+	mux.Handle("/v1/webhooks", webhookRouter)
+	mux.Handle("/v1/webhooks/", webhookRouter)
+	mux.Handle("/v1/tracking", tracking.NewHandler(tracking.NewPostgresRepository(pool), logger).Router())
+	mux.Handle("/v1/quiet-hours", quiethours.NewHandler(quiethours.NewPostgresRepository(pool), logger).Router())
+
+	// The ingestion copy of the engine only serves explain; it loads the
+	// same sources as the dispatcher.
+	routingRepo := routing.NewPostgresRepository(pool)
+	routes := &routing.Engine{Sources: []routing.Source{routingRepo}, Logger: logger}
+	if cfg.RoutingRulesFile != "" {
+		routes.Sources = append([]routing.Source{routing.FileSource{Path: cfg.RoutingRulesFile}}, routes.Sources...)
+	}
+	if err := routes.Refresh(ctx); err != nil {
+		logger.Error().Err(err).Msg("load routing rules")
+	}
+	go func() {
+		_ = routes.Run(ctx)
+	}()
+	mux.Handle("/v1/routing/", routing.NewHandler(routingRepo, routes, logger).Router())
+
+	statusUpdater := ingest.StatusUpdater{
+		ReaderFactory: func() *kafka.Reader {
+			return kafka.NewReader(kafka.ReaderConfig{
+				Brokers: cfg.KafkaBrokers,
+				GroupID: cfg.ServiceName + "-status",
+				Topic:   cfg.ProviderEventsTopic,
+			})
+		},
+		Repo:   repo,
+		Logger: logger,
+	}
+	go func() {
+		if err := statusUpdater.Run(ctx); err != nil && ctx.Err() == nil {
+			logger.Error().Err(err).Msg("message status updater stopped")
+		}
+	}()
 
 	srv := &http.Server{
 		Addr:    formatAddr(cfg.HTTPPort),
-		Handler: h.Router(),
+		Handler: mux,
 	}
 
 	go func() {
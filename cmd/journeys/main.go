@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/ingest"
+	"github.com/example/notification-service/internal/journeys"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := common.LoadConfig("journeys")
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	logger := common.NewLogger(cfg.ServiceName)
+	shutdown, err := common.SetupOTel(ctx, cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialise telemetry")
+	}
+	defer common.ShutdownTelemetry(context.Background(), shutdown)
+
+	metricsSrv := common.StartMetricsServer(cfg.MetricsPort)
+	defer metricsSrv.Shutdown(context.Background())
+
+	if cfg.DatabaseURL == "" {
+		logger.Fatal().Msg("DATABASE_URL must be provided")
+	}
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("connect postgres")
+	}
+	defer pool.Close()
+
+	// Journey sends take the same path as /v1/notify.
+	producer := &kafka.Writer{
+		Addr:     kafka.TCP(cfg.KafkaBrokers...),
+		Topic:    cfg.NotificationTopic,
+		Balancer: &kafka.Hash{},
+	}
+	defer producer.Close()
+
+	store := journeys.NewPostgresRepository(pool)
+	messages := ingest.NewPostgresRepository(pool)
+
+	listener := journeys.Listener{
+		ReaderFactory: func() *kafka.Reader {
+			return kafka.NewReader(kafka.ReaderConfig{
+				Brokers: cfg.KafkaBrokers,
+				GroupID: cfg.ServiceName,
+				Topic:   cfg.ProviderEventsTopic,
+			})
+		},
+		Store:    store,
+		Messages: messages,
+		Logger:   logger,
+	}
+	go func() {
+		if err := listener.Run(ctx); err != nil && ctx.Err() == nil {
+			logger.Fatal().Err(err).Msg("journey listener stopped")
+		}
+	}()
+
+	runner := journeys.Runner{
+		Store:    store,
+		Notifier: ingest.NewService(messages, producer),
+		Logger:   logger,
+	}
+
+	logger.Info().Msg("journey runner started")
+	if err := runner.Run(ctx); err != nil && ctx.Err() == nil {
+		logger.Error().Err(err).Msg("journey runner stopped")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/outbound"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := common.LoadConfig("webhook-delivery")
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	logger := common.NewLogger(cfg.ServiceName)
+	shutdown, err := common.SetupOTel(ctx, cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialise telemetry")
+	}
+	defer common.ShutdownTelemetry(context.Background(), shutdown)
+
+	metricsSrv := common.StartMetricsServer(cfg.MetricsPort)
+	defer metricsSrv.Shutdown(context.Background())
+
+	if cfg.DatabaseURL == "" {
+		logger.Fatal().Msg("DATABASE_URL must be provided")
+	}
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("connect postgres")
+	}
+	defer pool.Close()
+
+	store := outbound.NewPostgresRepository(pool)
+
+	fanout := outbound.Fanout{
+		ReaderFactory: func() *kafka.Reader {
+			return kafka.NewReader(kafka.ReaderConfig{
+				Brokers: cfg.KafkaBrokers,
+				GroupID: cfg.ServiceName,
+				Topic:   cfg.ProviderEventsTopic,
+			})
+		},
+		Store:  store,
+		Logger: logger,
+	}
+	go func() {
+		if err := fanout.Run(ctx); err != nil && ctx.Err() == nil {
+			logger.Fatal().Err(err).Msg("webhook fanout stopped")
+		}
+	}()
+
+	deliverer := outbound.Deliverer{
+		Store:  store,
+		Sender: outbound.NewSender(),
+		Logger: logger,
+	}
+
+	logger.Info().Msg("webhook deliverer started")
+	if err := deliverer.Run(ctx); err != nil && ctx.Err() == nil {
+		logger.Error().Err(err).Msg("webhook deliverer stopped")
+	}
+}
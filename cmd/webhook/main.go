@@ -9,9 +9,13 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
 	"github.com/segmentio/kafka-go"
 
 	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/preferences"
+	"github.com/example/notification-service/internal/suppression"
 	"github.com/example/notification-service/internal/webhook"
 )
 
@@ -40,10 +44,39 @@ func main() {
 		Balancer: &kafka.Hash{},
 	}
 	defer producer.Close()
+	inboundProducer := &kafka.Writer{
+		Addr:     kafka.TCP(cfg.KafkaBrokers...),
+		Topic:    cfg.InboundTopic,
+		Balancer: &kafka.Hash{},
+	}
+	defer inboundProducer.Close()
+
+	server := &webhook.Server{
+		Producer:         producer,
+		Normalizers:      normalizers(cfg, logger),
+		Dedup:            &webhook.MemoryDedup{},
+		Inbound:          inboundParsers(cfg, logger),
+		InboundProducer:  inboundProducer,
+		AllowedTopicARNs: cfg.SNSAllowedTopicARNs,
+		Logger:           logger,
+	}
+	if cfg.DatabaseURL != "" {
+		pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("connect postgres")
+		}
+		defer pool.Close()
+		server.Suppressions = &suppression.Recorder{
+			Repo:                suppression.NewPostgresRepository(pool),
+			SoftBounceThreshold: cfg.SoftBounceThreshold,
+		}
+		server.Archive = webhook.NewPostgresArchive(pool)
+		server.Preferences = preferences.NewPostgresRepository(pool)
+	}
 
 	srv := &http.Server{
 		Addr:    ":" + strconv.Itoa(cfg.HTTPPort),
-		Handler: webhook.Server{Producer: producer, Logger: logger}.Router(),
+		Handler: server.Router(),
 	}
 
 	go func() {
@@ -61,3 +94,93 @@ func main() {
 		logger.Error().Err(err).Msg("graceful shutdown failed")
 	}
 }
+
+// normalizers registers every supported provider with the verifier its
+// credentials allow. SES always verifies: SNS signs every message, and only
+// the allowed topics are accepted. Other providers without credentials are
+// not registered, so their callbacks get 404, unless WEBHOOK_ALLOW_UNVERIFIED
+// is set.
+func normalizers(cfg *common.Config, logger zerolog.Logger) *webhook.Registry {
+	ses := &webhook.SES{Verifier: &webhook.SNSVerifier{AllowedTopicARNs: cfg.SNSAllowedTopicARNs}}
+	if len(cfg.SNSAllowedTopicARNs) == 0 {
+		logger.Warn().Str("provider", "ses").Msg("SNS_ALLOWED_TOPIC_ARNS is not set; SES callbacks are rejected")
+	}
+	sendGrid := &webhook.SendGrid{}
+	twilio := &webhook.Twilio{}
+	mailgun := &webhook.Mailgun{}
+	postmark := &webhook.Postmark{}
+	fcm := &webhook.FCM{}
+	whatsApp := &webhook.WhatsApp{}
+
+	if cfg.SendGridWebhookPublicKey != "" {
+		key, err := webhook.ParseSendGridPublicKey(cfg.SendGridWebhookPublicKey)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("invalid SENDGRID_WEBHOOK_PUBLIC_KEY")
+		}
+		sendGrid.Verifier = &webhook.SendGridVerifier{PublicKey: key}
+	}
+	if cfg.TwilioAuthToken != "" {
+		twilio.Verifier = &webhook.TwilioVerifier{AuthToken: cfg.TwilioAuthToken, BaseURL: cfg.PublicBaseURL}
+	}
+	if cfg.MailgunWebhookSigningKey != "" {
+		mailgun.Verifier = &webhook.MailgunVerifier{SigningKey: cfg.MailgunWebhookSigningKey}
+	}
+	if cfg.PostmarkWebhookUser != "" {
+		postmark.Verifier = &webhook.BasicAuthVerifier{Username: cfg.PostmarkWebhookUser, Password: cfg.PostmarkWebhookPassword}
+	}
+	if cfg.PushReceiptSecret != "" {
+		fcm.Verifier = &webhook.HMACVerifier{Header: "X-HSNP-Receipt-Signature", Secret: []byte(cfg.PushReceiptSecret)}
+	}
+	if cfg.WhatsAppAppSecret != "" {
+		whatsApp.Verifier = &webhook.HMACVerifier{Header: "X-Hub-Signature-256", Secret: []byte(cfg.WhatsAppAppSecret)}
+	}
+
+	registry := webhook.NewRegistry(ses)
+	for _, p := range []struct {
+		normalizer webhook.Normalizer
+		verifier   *webhook.Verifier
+	}{
+		{sendGrid, &sendGrid.Verifier},
+		{twilio, &twilio.Verifier},
+		{mailgun, &mailgun.Verifier},
+		{postmark, &postmark.Verifier},
+		{fcm, &fcm.Verifier},
+		{whatsApp, &whatsApp.Verifier},
+	} {
+		if *p.verifier == nil {
+			if !cfg.WebhookAllowUnverified {
+				logger.Warn().Str("provider", p.normalizer.Provider()).Msg("no webhook credentials configured; callbacks are disabled")
+				continue
+			}
+			logger.Warn().Str("provider", p.normalizer.Provider()).Msg("WEBHOOK_ALLOW_UNVERIFIED is set; callbacks are not authenticated")
+			*p.verifier = webhook.Unverified{}
+		}
+		registry.Register(p.normalizer)
+	}
+	return registry
+}
+
+// inboundParsers registers the inbound message providers, authenticated
+// with the same credentials as their other callbacks. Each is only
+// registered with a verifier: the tenant comes from the query string, and
+// Twilio inbound SMS applies STOP/START opt-outs.
+func inboundParsers(cfg *common.Config, logger zerolog.Logger) map[string]webhook.InboundParser {
+	parsers := map[string]webhook.InboundParser{}
+	if cfg.TwilioAuthToken != "" {
+		twilio := &webhook.TwilioInbound{
+			Verifier: &webhook.TwilioVerifier{AuthToken: cfg.TwilioAuthToken, BaseURL: cfg.PublicBaseURL},
+		}
+		parsers[twilio.Provider()] = twilio
+	} else {
+		logger.Warn().Str("provider", "twilio").Msg("TWILIO_AUTH_TOKEN is not set; inbound SMS is disabled")
+	}
+	if cfg.SendGridInboundUser != "" {
+		sendGrid := &webhook.SendGridInbound{
+			Verifier: &webhook.BasicAuthVerifier{Username: cfg.SendGridInboundUser, Password: cfg.SendGridInboundPassword},
+		}
+		parsers[sendGrid.Provider()] = sendGrid
+	} else {
+		logger.Warn().Str("provider", "sendgrid").Msg("SENDGRID_INBOUND_USER is not set; inbound email is disabled")
+	}
+	return parsers
+}
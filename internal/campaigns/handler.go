@@ -0,0 +1,236 @@
+package campaigns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/ingest"
+)
+
+type Handler struct {
+	repo   Repository
+	logger zerolog.Logger
+}
+
+func NewHandler(repo Repository, logger zerolog.Logger) *Handler {
+	return &Handler{repo: repo, logger: logger}
+}
+
+func (h *Handler) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/v1/segments", h.listSegments)
+	r.Post("/v1/segments", h.createSegment)
+	r.Get("/v1/segments/{id}", h.getSegment)
+	r.Get("/v1/campaigns", h.listCampaigns)
+	r.Post("/v1/campaigns", h.createCampaign)
+	r.Get("/v1/campaigns/{id}", h.getCampaign)
+	r.Post("/v1/campaigns/{id}/pause", h.transition("pause"))
+	r.Post("/v1/campaigns/{id}/resume", h.transition("resume"))
+	r.Post("/v1/campaigns/{id}/cancel", h.transition("cancel"))
+	return r
+}
+
+func (h *Handler) listSegments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	segments, err := h.repo.ListSegments(ctx, tenantID)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"segments": segments})
+}
+
+func (h *Handler) createSegment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var s Segment
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	s.TenantID = tenantID
+	if err := s.Validate(); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	saved, err := h.repo.CreateSegment(ctx, s)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(saved)
+}
+
+func (h *Handler) getSegment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	s, err := h.repo.GetSegment(ctx, tenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s)
+}
+
+func (h *Handler) listCampaigns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	campaigns, err := h.repo.ListCampaigns(ctx, tenantID)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"campaigns": campaigns})
+}
+
+func (h *Handler) createCampaign(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var c Campaign
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	c.TenantID = tenantID
+	if c.ScheduledAt.IsZero() {
+		c.ScheduledAt = time.Now().UTC()
+	}
+	if c.RatePerMinute == 0 {
+		c.RatePerMinute = DefaultRatePerMinute
+	}
+	if c.Priority == "" {
+		c.Priority = ingest.PriorityBulk
+	}
+	if err := validateCampaign(c); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := h.repo.GetSegment(ctx, tenantID, c.SegmentID); err != nil {
+		status := statusForErr(err)
+		if status == http.StatusNotFound {
+			status = http.StatusBadRequest
+			err = fmt.Errorf("segment %s not found", c.SegmentID)
+		}
+		h.respondErr(ctx, w, status, err)
+		return
+	}
+	saved, err := h.repo.CreateCampaign(ctx, c)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(saved)
+}
+
+func (h *Handler) getCampaign(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	c, err := h.repo.GetCampaign(ctx, tenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+func (h *Handler) transition(action string) http.HandlerFunc {
+	t := transitions[action]
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		tenantID := r.Header.Get("x-tenant-id")
+		if tenantID == "" {
+			h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+			return
+		}
+		c, err := h.repo.Transition(ctx, tenantID, chi.URLParam(r, "id"), t.from, t.to)
+		if err != nil {
+			h.respondErr(ctx, w, statusForErr(err), err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c)
+	}
+}
+
+func validateCampaign(c Campaign) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if c.SegmentID == "" {
+		return errors.New("segment_id is required")
+	}
+	if c.TemplateID == "" {
+		return errors.New("template_id is required")
+	}
+	switch ingest.Channel(c.Channel) {
+	case ingest.ChannelEmail, ingest.ChannelSMS, ingest.ChannelPush, ingest.ChannelWhatsApp, ingest.ChannelInApp:
+	default:
+		return fmt.Errorf("unsupported channel %q", c.Channel)
+	}
+	switch c.Priority {
+	case ingest.PriorityHigh, ingest.PriorityNormal, ingest.PriorityBulk:
+	default:
+		return fmt.Errorf("campaign priority must be high, normal or bulk")
+	}
+	if c.RatePerMinute < 0 {
+		return errors.New("rate_per_minute must be positive")
+	}
+	return nil
+}
+
+func statusForErr(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidTransition):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
+	logger := common.WithContext(ctx, h.logger)
+	logger.Error().Err(err).Int("status", status).Msg("campaigns handler failed")
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,81 @@
+package campaigns
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrInvalidTransition = errors.New("campaign cannot change to that status")
+)
+
+// Campaign statuses. A scheduled campaign starts running once ScheduledAt
+// passes; paused campaigns keep their cursor and resume where they stopped.
+const (
+	StatusScheduled = "scheduled"
+	StatusRunning   = "running"
+	StatusPaused    = "paused"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+const DefaultRatePerMinute = 600
+
+// Campaign sends one template to every profile in a segment.
+type Campaign struct {
+	ID            string         `json:"id"`
+	TenantID      string         `json:"tenant_id"`
+	Name          string         `json:"name"`
+	SegmentID     string         `json:"segment_id"`
+	TemplateID    string         `json:"template_id"`
+	Channel       string         `json:"channel"`
+	Category      string         `json:"category,omitempty"`
+	Priority      string         `json:"priority,omitempty"`
+	Data          map[string]any `json:"data,omitempty"`
+	ScheduledAt   time.Time      `json:"scheduled_at"`
+	RatePerMinute int            `json:"rate_per_minute"`
+	Status        string         `json:"status"`
+	Progress      Progress       `json:"progress"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	CompletedAt   *time.Time     `json:"completed_at,omitempty"`
+}
+
+// Progress tracks the runner's walk through the tenant's profiles in user
+// id order. Scanned counts every profile visited, matched or not.
+type Progress struct {
+	Cursor     string `json:"cursor,omitempty"`
+	Scanned    int    `json:"scanned"`
+	Sent       int    `json:"sent"`
+	Duplicates int    `json:"duplicates"`
+	Failed     int    `json:"failed"`
+}
+
+type Repository interface {
+	CreateSegment(ctx context.Context, s Segment) (Segment, error)
+	GetSegment(ctx context.Context, tenantID, id string) (Segment, error)
+	ListSegments(ctx context.Context, tenantID string) ([]Segment, error)
+
+	CreateCampaign(ctx context.Context, c Campaign) (Campaign, error)
+	GetCampaign(ctx context.Context, tenantID, id string) (Campaign, error)
+	ListCampaigns(ctx context.Context, tenantID string) ([]Campaign, error)
+	// Transition moves a campaign to status if it is currently in one of
+	// from, returning ErrInvalidTransition otherwise.
+	Transition(ctx context.Context, tenantID, id string, from []string, status string) (Campaign, error)
+	// Claim locks one runnable campaign not advanced since before — running,
+	// or scheduled and due — and saves the campaign step returns, all in one
+	// transaction. It reports false when nothing is runnable.
+	Claim(ctx context.Context, now, before time.Time, step func(context.Context, Campaign) (Campaign, error)) (bool, error)
+}
+
+// transitions lists the statuses each API action may move a campaign from.
+var transitions = map[string]struct {
+	from []string
+	to   string
+}{
+	"pause":  {from: []string{StatusScheduled, StatusRunning}, to: StatusPaused},
+	"resume": {from: []string{StatusPaused}, to: StatusScheduled},
+	"cancel": {from: []string{StatusScheduled, StatusRunning, StatusPaused}, to: StatusCancelled},
+}
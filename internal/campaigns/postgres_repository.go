@@ -0,0 +1,281 @@
+package campaigns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const segmentColumns = `id, tenant_id, name, conditions_json, created_at`
+
+const insertSegment = `
+INSERT INTO segments (id, tenant_id, name, conditions_json, created_at)
+VALUES ($1,$2,$3,$4, now())
+RETURNING ` + segmentColumns
+
+const selectSegment = `
+SELECT ` + segmentColumns + `
+FROM segments
+WHERE tenant_id = $1 AND id = $2
+`
+
+const selectSegments = `
+SELECT ` + segmentColumns + `
+FROM segments
+WHERE tenant_id = $1
+ORDER BY created_at
+`
+
+const campaignColumns = `id, tenant_id, name, segment_id, template_id, channel, category, priority, data_json,
+scheduled_at, rate_per_minute, status, cursor, scanned, sent, duplicates, failed, created_at, updated_at, completed_at`
+
+const insertCampaign = `
+INSERT INTO campaigns (id, tenant_id, name, segment_id, template_id, channel, category, priority, data_json,
+scheduled_at, rate_per_minute, status, cursor, scanned, sent, duplicates, failed, created_at, updated_at)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,'',0,0,0,0, now(), now())
+RETURNING ` + campaignColumns
+
+const selectCampaign = `
+SELECT ` + campaignColumns + `
+FROM campaigns
+WHERE tenant_id = $1 AND id = $2
+`
+
+const selectCampaigns = `
+SELECT ` + campaignColumns + `
+FROM campaigns
+WHERE tenant_id = $1
+ORDER BY created_at DESC
+`
+
+const transitionCampaign = `
+UPDATE campaigns SET status = $4, updated_at = now()
+WHERE tenant_id = $1 AND id = $2 AND status = ANY($3)
+RETURNING ` + campaignColumns
+
+const claimCampaign = `
+SELECT ` + campaignColumns + `
+FROM campaigns
+WHERE (status = 'running' OR (status = 'scheduled' AND scheduled_at <= $1))
+AND updated_at <= $2
+ORDER BY updated_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED
+`
+
+const saveProgress = `
+UPDATE campaigns SET status = $2, cursor = $3, scanned = $4, sent = $5, duplicates = $6, failed = $7,
+completed_at = $8, updated_at = now()
+WHERE id = $1
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) CreateSegment(ctx context.Context, s Segment) (Segment, error) {
+	conditions, err := json.Marshal(s.Conditions)
+	if err != nil {
+		return Segment{}, fmt.Errorf("marshal segment conditions: %w", err)
+	}
+	saved, err := scanSegment(r.pool.QueryRow(ctx, insertSegment, uuid.NewString(), s.TenantID, s.Name, conditions))
+	if err != nil {
+		return Segment{}, fmt.Errorf("insert segment: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresRepository) GetSegment(ctx context.Context, tenantID, id string) (Segment, error) {
+	s, err := scanSegment(r.pool.QueryRow(ctx, selectSegment, tenantID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Segment{}, ErrNotFound
+	}
+	if err != nil {
+		return Segment{}, fmt.Errorf("get segment: %w", err)
+	}
+	return s, nil
+}
+
+func (r *PostgresRepository) ListSegments(ctx context.Context, tenantID string) ([]Segment, error) {
+	rows, err := r.pool.Query(ctx, selectSegments, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list segments: %w", err)
+	}
+	defer rows.Close()
+
+	var segments []Segment
+	for rows.Next() {
+		s, err := scanSegment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan segment: %w", err)
+		}
+		segments = append(segments, s)
+	}
+	return segments, rows.Err()
+}
+
+func (r *PostgresRepository) CreateCampaign(ctx context.Context, c Campaign) (Campaign, error) {
+	data, err := json.Marshal(c.Data)
+	if err != nil {
+		return Campaign{}, fmt.Errorf("marshal campaign data: %w", err)
+	}
+	saved, err := scanCampaign(r.pool.QueryRow(ctx, insertCampaign,
+		uuid.NewString(),
+		c.TenantID,
+		c.Name,
+		c.SegmentID,
+		c.TemplateID,
+		c.Channel,
+		c.Category,
+		c.Priority,
+		data,
+		c.ScheduledAt,
+		c.RatePerMinute,
+		StatusScheduled,
+	))
+	if err != nil {
+		return Campaign{}, fmt.Errorf("insert campaign: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresRepository) GetCampaign(ctx context.Context, tenantID, id string) (Campaign, error) {
+	c, err := scanCampaign(r.pool.QueryRow(ctx, selectCampaign, tenantID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Campaign{}, ErrNotFound
+	}
+	if err != nil {
+		return Campaign{}, fmt.Errorf("get campaign: %w", err)
+	}
+	return c, nil
+}
+
+func (r *PostgresRepository) ListCampaigns(ctx context.Context, tenantID string) ([]Campaign, error) {
+	rows, err := r.pool.Query(ctx, selectCampaigns, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var campaigns []Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan campaign: %w", err)
+		}
+		campaigns = append(campaigns, c)
+	}
+	return campaigns, rows.Err()
+}
+
+func (r *PostgresRepository) Transition(ctx context.Context, tenantID, id string, from []string, status string) (Campaign, error) {
+	c, err := scanCampaign(r.pool.QueryRow(ctx, transitionCampaign, tenantID, id, from, status))
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Distinguish a missing campaign from one in the wrong status.
+		if _, err := r.GetCampaign(ctx, tenantID, id); err != nil {
+			return Campaign{}, err
+		}
+		return Campaign{}, ErrInvalidTransition
+	}
+	if err != nil {
+		return Campaign{}, fmt.Errorf("update campaign status: %w", err)
+	}
+	return c, nil
+}
+
+func (r *PostgresRepository) Claim(ctx context.Context, now, before time.Time, step func(context.Context, Campaign) (Campaign, error)) (bool, error) {
+	claimed := false
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		c, err := scanCampaign(tx.QueryRow(ctx, claimCampaign, now, before))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("claim campaign: %w", err)
+		}
+		claimed = true
+		c, err = step(ctx, c)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, saveProgress,
+			c.ID,
+			c.Status,
+			c.Progress.Cursor,
+			c.Progress.Scanned,
+			c.Progress.Sent,
+			c.Progress.Duplicates,
+			c.Progress.Failed,
+			c.CompletedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("save campaign progress: %w", err)
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+func scanSegment(row pgx.Row) (Segment, error) {
+	var (
+		s          Segment
+		conditions []byte
+	)
+	if err := row.Scan(&s.ID, &s.TenantID, &s.Name, &conditions, &s.CreatedAt); err != nil {
+		return Segment{}, err
+	}
+	if len(conditions) > 0 {
+		if err := json.Unmarshal(conditions, &s.Conditions); err != nil {
+			return Segment{}, err
+		}
+	}
+	return s, nil
+}
+
+func scanCampaign(row pgx.Row) (Campaign, error) {
+	var (
+		c    Campaign
+		data []byte
+	)
+	err := row.Scan(
+		&c.ID,
+		&c.TenantID,
+		&c.Name,
+		&c.SegmentID,
+		&c.TemplateID,
+		&c.Channel,
+		&c.Category,
+		&c.Priority,
+		&data,
+		&c.ScheduledAt,
+		&c.RatePerMinute,
+		&c.Status,
+		&c.Progress.Cursor,
+		&c.Progress.Scanned,
+		&c.Progress.Sent,
+		&c.Progress.Duplicates,
+		&c.Progress.Failed,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.CompletedAt,
+	)
+	if err != nil {
+		return Campaign{}, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &c.Data); err != nil {
+			return Campaign{}, err
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,164 @@
+package campaigns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+
+	"github.com/example/notification-service/internal/ingest"
+	"github.com/example/notification-service/internal/recipients"
+)
+
+const (
+	defaultInterval = 5 * time.Second
+	pageSize        = 500
+	// maxScanPerStep bounds how long one step holds a campaign when few
+	// profiles match the segment.
+	maxScanPerStep = 5000
+)
+
+var campaignMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "campaign_messages_total",
+	Help: "Messages submitted by campaigns",
+}, []string{"result"})
+
+type ProfileLister interface {
+	List(ctx context.Context, tenantID, after string, limit int) ([]recipients.Profile, error)
+}
+
+// Notifier is the ingestion path campaigns submit through.
+type Notifier interface {
+	Notify(ctx context.Context, tenantID, idempotencyKey string, req ingest.NotifyRequest) (ingest.NotifyResult, error)
+}
+
+// Runner expands campaigns into individual messages. Each tick it advances
+// every runnable campaign by at most RatePerMinute's share of the interval,
+// so a campaign's send rate holds no matter how many replicas run. Messages
+// use the idempotency key campaign:<id>:<user_id>, so a step that is retried
+// after a crash never sends twice.
+type Runner struct {
+	Store      Repository
+	Recipients ProfileLister
+	Notifier   Notifier
+	Interval   time.Duration
+	Logger     zerolog.Logger
+}
+
+func (r *Runner) Run(ctx context.Context) error {
+	if r.Store == nil || r.Recipients == nil || r.Notifier == nil {
+		return errors.New("campaign runner requires a store, recipients and notifier")
+	}
+	interval := r.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		for {
+			now := time.Now().UTC()
+			claimed, err := r.Store.Claim(ctx, now, now.Add(-interval), func(ctx context.Context, c Campaign) (Campaign, error) {
+				return r.step(ctx, c, interval)
+			})
+			if err != nil {
+				r.Logger.Error().Err(err).Msg("campaign step failed")
+				break
+			}
+			if !claimed {
+				break
+			}
+		}
+	}
+}
+
+func (r *Runner) step(ctx context.Context, c Campaign, interval time.Duration) (Campaign, error) {
+	segment, err := r.Store.GetSegment(ctx, c.TenantID, c.SegmentID)
+	if err != nil {
+		return c, fmt.Errorf("load segment: %w", err)
+	}
+	c.Status = StatusRunning
+
+	rate := c.RatePerMinute
+	if rate <= 0 {
+		rate = DefaultRatePerMinute
+	}
+	budget := int(math.Ceil(float64(rate) * interval.Minutes()))
+	submitted, scanned := 0, 0
+
+	for submitted < budget && scanned < maxScanPerStep {
+		limit := pageSize
+		if remaining := maxScanPerStep - scanned; remaining < limit {
+			limit = remaining
+		}
+		profiles, err := r.Recipients.List(ctx, c.TenantID, c.Progress.Cursor, limit)
+		if err != nil {
+			return c, fmt.Errorf("list recipients: %w", err)
+		}
+		for _, p := range profiles {
+			if submitted >= budget {
+				return c, nil
+			}
+			c.Progress.Cursor = p.UserID
+			c.Progress.Scanned++
+			scanned++
+			if !segment.Match(p) {
+				continue
+			}
+			if err := r.send(ctx, &c, p); err != nil {
+				return c, err
+			}
+			submitted++
+		}
+		if len(profiles) < limit {
+			completed := time.Now().UTC()
+			c.Status = StatusCompleted
+			c.CompletedAt = &completed
+			r.Logger.Info().Str("campaign_id", c.ID).Int("sent", c.Progress.Sent).Msg("campaign completed")
+			return c, nil
+		}
+	}
+	return c, nil
+}
+
+func (r *Runner) send(ctx context.Context, c *Campaign, p recipients.Profile) error {
+	priority := c.Priority
+	if priority == "" {
+		priority = ingest.PriorityBulk
+	}
+	result, err := r.Notifier.Notify(ctx, c.TenantID, "campaign:"+c.ID+":"+p.UserID, ingest.NotifyRequest{
+		Channel:    ingest.Channel(c.Channel),
+		To:         map[string]any{"user_id": p.UserID},
+		TemplateID: c.TemplateID,
+		Data:       c.Data,
+		Category:   c.Category,
+		Priority:   priority,
+		Options:    map[string]any{"campaign_id": c.ID},
+	})
+	var invalid *ingest.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		c.Progress.Failed++
+		campaignMessages.WithLabelValues("failed").Inc()
+		r.Logger.Warn().Err(err).Str("campaign_id", c.ID).Str("user_id", p.UserID).Msg("campaign message rejected")
+	case err != nil:
+		return fmt.Errorf("submit campaign message: %w", err)
+	case result.Duplicate:
+		c.Progress.Duplicates++
+		campaignMessages.WithLabelValues("duplicate").Inc()
+	default:
+		c.Progress.Sent++
+		campaignMessages.WithLabelValues("sent").Inc()
+	}
+	return nil
+}
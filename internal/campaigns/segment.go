@@ -0,0 +1,178 @@
+package campaigns
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/example/notification-service/internal/recipients"
+)
+
+// Segment operators.
+const (
+	OpEq       = "eq"
+	OpNeq      = "neq"
+	OpIn       = "in"
+	OpNotIn    = "not_in"
+	OpExists   = "exists"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpContains = "contains"
+)
+
+// Condition tests one profile attribute. Attribute is a dotted path into
+// the profile's attributes ("plan", "address.country"); the profile fields
+// locale and timezone are also addressable by name.
+type Condition struct {
+	Attribute string `json:"attribute"`
+	Op        string `json:"op"`
+	Value     any    `json:"value,omitempty"`
+}
+
+// Segment is an audience: the profiles matching every condition. A segment
+// with no conditions matches the whole tenant.
+type Segment struct {
+	ID         string      `json:"id"`
+	TenantID   string      `json:"tenant_id"`
+	Name       string      `json:"name"`
+	Conditions []Condition `json:"conditions"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+func (s Segment) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for i, c := range s.Conditions {
+		if c.Attribute == "" {
+			return fmt.Errorf("conditions[%d]: attribute is required", i)
+		}
+		switch c.Op {
+		case OpEq, OpNeq, OpExists, OpContains:
+		case OpIn, OpNotIn:
+			if _, ok := c.Value.([]any); !ok {
+				return fmt.Errorf("conditions[%d]: %s needs a list value", i, c.Op)
+			}
+		case OpGt, OpGte, OpLt, OpLte:
+			if _, ok := number(c.Value); !ok {
+				return fmt.Errorf("conditions[%d]: %s needs a numeric value", i, c.Op)
+			}
+		default:
+			return fmt.Errorf("conditions[%d]: unknown op %q", i, c.Op)
+		}
+	}
+	return nil
+}
+
+func (s Segment) Match(p recipients.Profile) bool {
+	for _, c := range s.Conditions {
+		if !c.match(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Condition) match(p recipients.Profile) bool {
+	v, ok := lookup(p, c.Attribute)
+	switch c.Op {
+	case OpExists:
+		want, isBool := c.Value.(bool)
+		if !isBool {
+			want = true
+		}
+		return ok == want
+	case OpNeq:
+		return !ok || !equal(v, c.Value)
+	case OpNotIn:
+		return !ok || !inList(v, c.Value)
+	}
+	if !ok {
+		return false
+	}
+	switch c.Op {
+	case OpEq:
+		return equal(v, c.Value)
+	case OpIn:
+		return inList(v, c.Value)
+	case OpContains:
+		if list, isList := v.([]any); isList {
+			return inList(c.Value, list)
+		}
+		s, isString := v.(string)
+		sub, subString := c.Value.(string)
+		return isString && subString && strings.Contains(s, sub)
+	case OpGt, OpGte, OpLt, OpLte:
+		a, aok := number(v)
+		b, bok := number(c.Value)
+		if !aok || !bok {
+			return false
+		}
+		switch c.Op {
+		case OpGt:
+			return a > b
+		case OpGte:
+			return a >= b
+		case OpLt:
+			return a < b
+		}
+		return a <= b
+	}
+	return false
+}
+
+func lookup(p recipients.Profile, path string) (any, bool) {
+	switch path {
+	case "locale":
+		return p.Locale, p.Locale != ""
+	case "timezone":
+		return p.Timezone, p.Timezone != ""
+	}
+	var current any = p.Attributes
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return x == y
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func inList(v, list any) bool {
+	items, ok := list.([]any)
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if equal(v, item) {
+			return true
+		}
+	}
+	return false
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,68 @@
+package campaigns
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/example/notification-service/internal/recipients"
+)
+
+func TestSegmentMatch(t *testing.T) {
+	var attributes map[string]any
+	if err := json.Unmarshal([]byte(`{"plan":"pro","seats":12,"tags":["beta","eu"],"address":{"country":"DE"}}`), &attributes); err != nil {
+		t.Fatal(err)
+	}
+	profile := recipients.Profile{UserID: "u1", Locale: "de-DE", Attributes: attributes}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		want      bool
+	}{
+		{"eq", Condition{"plan", OpEq, "pro"}, true},
+		{"eq mismatch", Condition{"plan", OpEq, "free"}, false},
+		{"neq missing attribute", Condition{"tier", OpNeq, "gold"}, true},
+		{"in", Condition{"plan", OpIn, []any{"free", "pro"}}, true},
+		{"not_in", Condition{"plan", OpNotIn, []any{"free"}}, true},
+		{"exists", Condition{"seats", OpExists, nil}, true},
+		{"not exists", Condition{"tier", OpExists, false}, true},
+		{"gt", Condition{"seats", OpGt, float64(10)}, true},
+		{"lte", Condition{"seats", OpLte, float64(11)}, false},
+		{"numeric compare on string", Condition{"plan", OpGt, float64(1)}, false},
+		{"contains list", Condition{"tags", OpContains, "beta"}, true},
+		{"contains string", Condition{"plan", OpContains, "pr"}, true},
+		{"dotted path", Condition{"address.country", OpEq, "DE"}, true},
+		{"profile field", Condition{"locale", OpEq, "de-DE"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segment := Segment{Name: "s", Conditions: []Condition{tt.condition}}
+			if err := segment.Validate(); err != nil {
+				t.Fatalf("validate: %v", err)
+			}
+			if got := segment.Match(profile); got != tt.want {
+				t.Fatalf("Match=%v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !(Segment{Name: "all"}).Match(profile) {
+		t.Fatal("a segment without conditions should match everyone")
+	}
+}
+
+func TestSegmentValidate(t *testing.T) {
+	invalid := []Segment{
+		{Conditions: []Condition{{"plan", OpEq, "pro"}}},
+		{Name: "s", Conditions: []Condition{{"", OpEq, "pro"}}},
+		{Name: "s", Conditions: []Condition{{"plan", "like", "pro"}}},
+		{Name: "s", Conditions: []Condition{{"plan", OpIn, "pro"}}},
+		{Name: "s", Conditions: []Condition{{"seats", OpGt, "10"}}},
+	}
+	for i, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Fatalf("segment %d: expected a validation error", i)
+		}
+	}
+}
@@ -16,8 +16,51 @@ type Config struct {
 	EmailTopic          string
 	DLQTopic            string
 	ProviderEventsTopic string
+	DigestTopic         string
+	InboundTopic        string
 	OTLPEndpoint        string
 	ServiceName         string
+	// TestSendAllowList holds the addresses template test sends may target:
+	// exact addresses, or "@domain" entries matching that exact domain only
+	// (subdomains need their own entry).
+	TestSendAllowList []string
+	// UnsubscribeSecret signs one-click unsubscribe links served from
+	// PublicBaseURL.
+	UnsubscribeSecret string
+	PublicBaseURL     string
+	// SoftBounceThreshold is how many soft bounces suppress an address.
+	SoftBounceThreshold int
+	// DispatcherReplicas is how many dispatcher replicas share each tenant
+	// rate limit; each enforces its share.
+	DispatcherReplicas int
+	// RoutingRulesFile optionally points at a JSON routing rules file that
+	// is reloaded while the dispatcher runs.
+	RoutingRulesFile string
+	// SendGridWebhookPublicKey is the base64 verification key of SendGrid's
+	// signed Event Webhook; signed events are required once it is set.
+	SendGridWebhookPublicKey string
+	// SNSAllowedTopicARNs lists the SNS topics SES events may come from;
+	// SES callbacks from any other topic are rejected.
+	SNSAllowedTopicARNs []string
+	// Provider webhook credentials; a provider's callbacks are rejected
+	// until its credential is set.
+	TwilioAuthToken          string
+	MailgunWebhookSigningKey string
+	PostmarkWebhookUser      string
+	PostmarkWebhookPassword  string
+	PushReceiptSecret        string
+	WhatsAppAppSecret        string
+	// WebhookAllowUnverified accepts callbacks from providers without
+	// credentials instead of rejecting them. Never set it in production.
+	WebhookAllowUnverified bool
+	// TrackingSecret signs open and click tracking links served from
+	// TrackingBaseURL; email tracking is off until it is set.
+	TrackingSecret  string
+	TrackingBaseURL string
+	// SendGrid Inbound Parse basic auth credentials, embedded in the parse
+	// webhook URL.
+	SendGridInboundUser     string
+	SendGridInboundPassword string
 }
 
 func LoadConfig(service string) (*Config, error) {
@@ -49,10 +92,60 @@ func LoadConfig(service string) (*Config, error) {
 	cfg.EmailTopic = getEnv("EMAIL_TOPIC", "dispatch.email")
 	cfg.DLQTopic = getEnv("DLQ_TOPIC", "dlq.dispatch.email")
 	cfg.ProviderEventsTopic = getEnv("PROVIDER_EVENTS_TOPIC", "provider.events")
+	cfg.DigestTopic = getEnv("DIGEST_TOPIC", "digest.pending")
+	cfg.InboundTopic = getEnv("INBOUND_TOPIC", "inbound.messages")
+
+	softBounceThreshold, err := getEnvInt("SOFT_BOUNCE_THRESHOLD", 3)
+	if err != nil {
+		return nil, err
+	}
+	cfg.SoftBounceThreshold = softBounceThreshold
+
+	dispatcherReplicas, err := getEnvInt("DISPATCHER_REPLICAS", 1)
+	if err != nil {
+		return nil, err
+	}
+	cfg.DispatcherReplicas = dispatcherReplicas
+
+	cfg.RoutingRulesFile = os.Getenv("ROUTING_RULES_FILE")
+	cfg.SendGridWebhookPublicKey = os.Getenv("SENDGRID_WEBHOOK_PUBLIC_KEY")
+	cfg.TwilioAuthToken = os.Getenv("TWILIO_AUTH_TOKEN")
+	cfg.MailgunWebhookSigningKey = os.Getenv("MAILGUN_WEBHOOK_SIGNING_KEY")
+	cfg.PostmarkWebhookUser = os.Getenv("POSTMARK_WEBHOOK_USER")
+	cfg.PostmarkWebhookPassword = os.Getenv("POSTMARK_WEBHOOK_PASSWORD")
+	cfg.PushReceiptSecret = os.Getenv("PUSH_RECEIPT_SECRET")
+	cfg.WhatsAppAppSecret = os.Getenv("WHATSAPP_APP_SECRET")
+	cfg.WebhookAllowUnverified = os.Getenv("WEBHOOK_ALLOW_UNVERIFIED") == "true"
+	cfg.SendGridInboundUser = os.Getenv("SENDGRID_INBOUND_USER")
+	cfg.SendGridInboundPassword = os.Getenv("SENDGRID_INBOUND_PASSWORD")
+	cfg.UnsubscribeSecret = os.Getenv("UNSUBSCRIBE_SECRET")
+	cfg.PublicBaseURL = getEnv("PUBLIC_BASE_URL", "http://localhost:8080")
+	cfg.TrackingSecret = os.Getenv("TRACKING_SECRET")
+	cfg.TrackingBaseURL = getEnv("TRACKING_BASE_URL", cfg.PublicBaseURL)
+
+	if topics := os.Getenv("SNS_ALLOWED_TOPIC_ARNS"); topics != "" {
+		cfg.SNSAllowedTopicARNs = strings.Split(topics, ",")
+	}
+	if allowList := os.Getenv("TEST_SEND_ALLOWLIST"); allowList != "" {
+		cfg.TestSendAllowList = strings.Split(allowList, ",")
+	}
 
 	return cfg, nil
 }
 
+// Priorities lists message priorities from most to least urgent.
+var Priorities = []string{"critical", "high", "normal", "bulk"}
+
+// PriorityTopic returns the lane topic for priority on top of a channel's
+// base topic. Normal (or unset) priority keeps the base topic so existing
+// consumers are unaffected: dispatch.email, dispatch.email.critical, ...
+func PriorityTopic(base, priority string) string {
+	if priority == "" || priority == "normal" {
+		return base
+	}
+	return base + "." + priority
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
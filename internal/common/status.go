@@ -0,0 +1,35 @@
+package common
+
+// Canonical message statuses. Every event on provider.events carries one of
+// them: the webhook service maps provider spellings onto them and workers
+// emit them directly, so consumers compare against these values only.
+const (
+	StatusQueued     = "queued"
+	StatusSent       = "sent"
+	StatusDelivered  = "delivered"
+	StatusOpened     = "opened"
+	StatusClicked    = "clicked"
+	StatusBounced    = "bounced"
+	StatusFailed     = "failed"
+	StatusComplained = "complained"
+	StatusDeferred   = "deferred"
+	StatusSuppressed = "suppressed"
+)
+
+var statuses = map[string]bool{
+	StatusQueued:     true,
+	StatusSent:       true,
+	StatusDelivered:  true,
+	StatusOpened:     true,
+	StatusClicked:    true,
+	StatusBounced:    true,
+	StatusFailed:     true,
+	StatusComplained: true,
+	StatusDeferred:   true,
+	StatusSuppressed: true,
+}
+
+// IsStatus reports whether status is a canonical status.
+func IsStatus(status string) bool {
+	return statuses[status]
+}
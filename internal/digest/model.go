@@ -0,0 +1,119 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultWindow is how long a digest collects messages when the request
+// does not set digest_window_seconds.
+const DefaultWindow = time.Hour
+
+// DestinationKey is the metadata entry holding the channel topic the
+// dispatcher routed a digestible message to.
+const DestinationKey = "digest_destination"
+
+// Item is one buffered message. Value is the dispatcher's message as routed.
+type Item struct {
+	ID        string
+	TenantID  string
+	Channel   string
+	Recipient string
+	DigestKey string
+	MessageID string
+	Topic     string
+	Value     []byte
+	CreatedAt time.Time
+}
+
+// Batch is the open digest for one tenant, channel, recipient and key. Its
+// FlushAt is fixed by the first item, so a steady trickle cannot postpone
+// the digest indefinitely.
+type Batch struct {
+	ID        string
+	TenantID  string
+	Channel   string
+	Recipient string
+	DigestKey string
+	FlushAt   time.Time
+	Items     []Item
+}
+
+type Store interface {
+	// Add appends item to its open batch, opening one that flushes after
+	// window if there is none.
+	Add(ctx context.Context, item Item, window time.Duration) error
+	// Flush passes up to limit due batches to publish and deletes each only
+	// if publish succeeds.
+	Flush(ctx context.Context, now time.Time, limit int, publish func(context.Context, Batch) error) (int, error)
+}
+
+// Build turns a batch into the single message sent to the channel worker.
+// A lone item goes out unchanged. Otherwise the first item is the envelope:
+// it gets a fresh message id, the digest template if one was requested,
+// and data {digest_key, count, items} where items holds each message's data.
+func Build(b Batch) (string, []byte, error) {
+	if len(b.Items) == 0 {
+		return "", nil, errors.New("empty digest batch")
+	}
+	if len(b.Items) == 1 {
+		return b.Items[0].MessageID, b.Items[0].Value, nil
+	}
+
+	var envelope map[string]any
+	if err := json.Unmarshal(b.Items[0].Value, &envelope); err != nil {
+		return "", nil, fmt.Errorf("decode digest item: %w", err)
+	}
+	items := make([]any, 0, len(b.Items))
+	ids := make([]string, 0, len(b.Items))
+	for _, item := range b.Items {
+		var msg struct {
+			Payload struct {
+				Data map[string]any `json:"data"`
+			} `json:"payload"`
+		}
+		if err := json.Unmarshal(item.Value, &msg); err != nil {
+			return "", nil, fmt.Errorf("decode digest item: %w", err)
+		}
+		items = append(items, msg.Payload.Data)
+		ids = append(ids, item.MessageID)
+	}
+
+	payload, _ := envelope["payload"].(map[string]any)
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	if tpl, _ := payload["digest_template_id"].(string); tpl != "" {
+		envelope["template_id"] = tpl
+	}
+	payload["data"] = map[string]any{
+		"digest_key": b.DigestKey,
+		"count":      len(b.Items),
+		"items":      items,
+	}
+	delete(payload, "digest_key")
+	delete(payload, "digest_window_seconds")
+	delete(payload, "digest_template_id")
+	envelope["payload"] = payload
+
+	metadata, _ := envelope["metadata"].(map[string]any)
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	delete(metadata, DestinationKey)
+	metadata["digest_of"] = ids
+	envelope["metadata"] = metadata
+
+	id := uuid.NewString()
+	envelope["message_id"] = id
+	value, err := json.Marshal(envelope)
+	if err != nil {
+		return "", nil, fmt.Errorf("marshal digest: %w", err)
+	}
+	return id, value, nil
+}
@@ -0,0 +1,86 @@
+package digest
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func item(t *testing.T, id string, data map[string]any) Item {
+	t.Helper()
+	value, err := json.Marshal(map[string]any{
+		"message_id":  id,
+		"tenant_id":   "t1",
+		"channel":     "email",
+		"template_id": "new-comment",
+		"payload": map[string]any{
+			"to":                 map[string]any{"email": "a@b.com"},
+			"data":               data,
+			"digest_key":         "comments",
+			"digest_template_id": "comment-digest",
+		},
+		"metadata": map[string]any{DestinationKey: "dispatch.email"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Item{MessageID: id, TenantID: "t1", Channel: "email", DigestKey: "comments", Topic: "dispatch.email", Value: value}
+}
+
+func TestBuildSingleItem(t *testing.T) {
+	only := item(t, "m1", map[string]any{"comment": "hi"})
+	id, value, err := Build(Batch{DigestKey: "comments", Items: []Item{only}})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if id != "m1" || string(value) != string(only.Value) {
+		t.Fatalf("expected the lone message unchanged, got %s %s", id, value)
+	}
+}
+
+func TestBuildDigest(t *testing.T) {
+	batch := Batch{DigestKey: "comments", Items: []Item{
+		item(t, "m1", map[string]any{"comment": "first"}),
+		item(t, "m2", map[string]any{"comment": "second"}),
+		item(t, "m3", map[string]any{"comment": "third"}),
+	}}
+
+	id, value, err := Build(batch)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	var got struct {
+		MessageID  string `json:"message_id"`
+		TemplateID string `json:"template_id"`
+		Payload    struct {
+			To        map[string]any `json:"to"`
+			DigestKey string         `json:"digest_key"`
+			Data      struct {
+				DigestKey string           `json:"digest_key"`
+				Count     int              `json:"count"`
+				Items     []map[string]any `json:"items"`
+			} `json:"data"`
+		} `json:"payload"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal(value, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.MessageID != id || id == "m1" {
+		t.Fatalf("expected a new digest id, got %q (returned %q)", got.MessageID, id)
+	}
+	if got.TemplateID != "comment-digest" {
+		t.Fatalf("expected digest template, got %q", got.TemplateID)
+	}
+	if got.Payload.To["email"] != "a@b.com" || got.Payload.DigestKey != "" {
+		t.Fatalf("unexpected payload %+v", got.Payload)
+	}
+	if got.Payload.Data.Count != 3 || got.Payload.Data.DigestKey != "comments" || got.Payload.Data.Items[2]["comment"] != "third" {
+		t.Fatalf("unexpected digest data %+v", got.Payload.Data)
+	}
+	if _, ok := got.Metadata[DestinationKey]; ok {
+		t.Fatal("destination should not be forwarded")
+	}
+	if ids, _ := got.Metadata["digest_of"].([]any); len(ids) != 3 {
+		t.Fatalf("expected digest_of to list the items, got %v", got.Metadata["digest_of"])
+	}
+}
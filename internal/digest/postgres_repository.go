@@ -0,0 +1,141 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The no-op update makes RETURNING yield the open batch's id on conflict,
+// and waits on a flush holding the row so a late item lands in a new batch
+// instead of one being deleted.
+const upsertBatch = `
+INSERT INTO digest_batches (id, tenant_id, channel, recipient, digest_key, flush_at, created_at)
+VALUES ($1,$2,$3,$4,$5,$6, now())
+ON CONFLICT (tenant_id, channel, recipient, digest_key)
+DO UPDATE SET flush_at = digest_batches.flush_at
+RETURNING id
+`
+
+const insertItem = `
+INSERT INTO digest_items (id, batch_id, message_id, topic, message_value, created_at)
+VALUES ($1,$2,$3,$4,$5, now())
+`
+
+const selectDueBatches = `
+SELECT id, tenant_id, channel, recipient, digest_key, flush_at
+FROM digest_batches
+WHERE flush_at <= $1
+ORDER BY flush_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+const selectItems = `
+SELECT id, message_id, topic, message_value, created_at
+FROM digest_items
+WHERE batch_id = $1
+ORDER BY created_at
+`
+
+const deleteItems = `
+DELETE FROM digest_items WHERE batch_id = $1
+`
+
+const deleteBatch = `
+DELETE FROM digest_batches WHERE id = $1
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) Add(ctx context.Context, item Item, window time.Duration) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var batchID string
+		if err := tx.QueryRow(ctx, upsertBatch,
+			uuid.NewString(),
+			item.TenantID,
+			item.Channel,
+			item.Recipient,
+			item.DigestKey,
+			time.Now().UTC().Add(window),
+		).Scan(&batchID); err != nil {
+			return fmt.Errorf("open digest batch: %w", err)
+		}
+		if _, err := tx.Exec(ctx, insertItem, item.ID, batchID, item.MessageID, item.Topic, item.Value); err != nil {
+			return fmt.Errorf("insert digest item: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *PostgresRepository) Flush(ctx context.Context, now time.Time, limit int, publish func(context.Context, Batch) error) (int, error) {
+	flushed := 0
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, selectDueBatches, now, limit)
+		if err != nil {
+			return fmt.Errorf("select due digests: %w", err)
+		}
+		var due []Batch
+		for rows.Next() {
+			var b Batch
+			if err := rows.Scan(&b.ID, &b.TenantID, &b.Channel, &b.Recipient, &b.DigestKey, &b.FlushAt); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan digest batch: %w", err)
+			}
+			due = append(due, b)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, b := range due {
+			items, err := r.items(ctx, tx, b)
+			if err != nil {
+				return err
+			}
+			b.Items = items
+			if len(items) > 0 {
+				if err := publish(ctx, b); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.Exec(ctx, deleteItems, b.ID); err != nil {
+				return fmt.Errorf("delete digest items: %w", err)
+			}
+			if _, err := tx.Exec(ctx, deleteBatch, b.ID); err != nil {
+				return fmt.Errorf("delete digest batch: %w", err)
+			}
+			flushed++
+		}
+		return nil
+	})
+	return flushed, err
+}
+
+func (r *PostgresRepository) items(ctx context.Context, tx pgx.Tx, b Batch) ([]Item, error) {
+	rows, err := tx.Query(ctx, selectItems, b.ID)
+	if err != nil {
+		return nil, fmt.Errorf("select digest items: %w", err)
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		item := Item{TenantID: b.TenantID, Channel: b.Channel, Recipient: b.Recipient, DigestKey: b.DigestKey}
+		if err := rows.Scan(&item.ID, &item.MessageID, &item.Topic, &item.Value, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan digest item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,196 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	defaultInterval  = 5 * time.Second
+	defaultBatchSize = 100
+)
+
+var (
+	bufferedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "digest_buffered_total",
+		Help: "Messages buffered into a digest",
+	}, []string{"channel"})
+	digestSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "digest_size",
+		Help:    "Messages combined per flushed digest",
+		Buckets: []float64{1, 2, 5, 10, 25, 50, 100},
+	}, []string{"channel"})
+)
+
+// Stage sits between the dispatcher and the channel workers. It buffers
+// messages carrying a digest_key from the digest topic and, once a batch's
+// window closes, publishes one combined message to the channel topic the
+// dispatcher chose.
+type Stage struct {
+	ReaderFactory func() *kafka.Reader
+	WriterFactory func(topic string) *kafka.Writer
+	Store         Store
+	// EventsTopic, when set, receives a "digested" event per buffered
+	// message naming the digest it was sent in.
+	EventsTopic string
+	Interval    time.Duration
+	BatchSize   int
+	Logger      zerolog.Logger
+}
+
+type incoming struct {
+	MessageID string         `json:"message_id"`
+	TenantID  string         `json:"tenant_id"`
+	Channel   string         `json:"channel"`
+	Payload   map[string]any `json:"payload"`
+	Metadata  map[string]any `json:"metadata"`
+}
+
+func (s *Stage) Run(ctx context.Context) error {
+	if s.ReaderFactory == nil || s.WriterFactory == nil || s.Store == nil {
+		return errors.New("digest stage requires a reader, writer factory and store")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.flushLoop(ctx)
+
+	reader := s.ReaderFactory()
+	defer reader.Close()
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch message: %w", err)
+		}
+		if err := s.buffer(ctx, m.Value); err != nil {
+			return err
+		}
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			return fmt.Errorf("commit message: %w", err)
+		}
+	}
+}
+
+func (s *Stage) buffer(ctx context.Context, value []byte) error {
+	var msg incoming
+	if err := json.Unmarshal(value, &msg); err != nil {
+		s.Logger.Error().Err(err).Msg("failed to decode digest message")
+		return nil
+	}
+	key, _ := msg.Payload["digest_key"].(string)
+	topic, _ := msg.Metadata[DestinationKey].(string)
+	if key == "" || topic == "" {
+		s.Logger.Warn().Str("message_id", msg.MessageID).Msg("digest message without key or destination, dropping")
+		return nil
+	}
+	recipient, err := json.Marshal(msg.Payload["to"])
+	if err != nil {
+		return fmt.Errorf("encode digest recipient: %w", err)
+	}
+	window := DefaultWindow
+	if seconds, _ := msg.Payload["digest_window_seconds"].(float64); seconds > 0 {
+		window = time.Duration(seconds * float64(time.Second))
+	}
+
+	if err := s.Store.Add(ctx, Item{
+		ID:        uuid.NewString(),
+		TenantID:  msg.TenantID,
+		Channel:   msg.Channel,
+		Recipient: string(recipient),
+		DigestKey: key,
+		MessageID: msg.MessageID,
+		Topic:     topic,
+		Value:     value,
+	}, window); err != nil {
+		return fmt.Errorf("buffer digest item: %w", err)
+	}
+	bufferedMessages.WithLabelValues(msg.Channel).Inc()
+	return nil
+}
+
+func (s *Stage) flushLoop(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	batch := s.BatchSize
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for {
+			n, err := s.Store.Flush(ctx, time.Now().UTC(), batch, s.publish)
+			if err != nil {
+				s.Logger.Error().Err(err).Msg("failed to flush digests")
+				break
+			}
+			if n < batch {
+				break
+			}
+		}
+	}
+}
+
+func (s *Stage) publish(ctx context.Context, b Batch) error {
+	id, value, err := Build(b)
+	if err != nil {
+		// A batch that cannot be built never will be; drop it rather than
+		// retry forever.
+		s.Logger.Error().Err(err).Str("batch_id", b.ID).Msg("failed to build digest, dropping batch")
+		return nil
+	}
+	// Items share a destination unless routing rules changed mid-window; the
+	// most recent decision wins.
+	topic := b.Items[len(b.Items)-1].Topic
+	if err := s.WriterFactory(topic).WriteMessages(ctx, kafka.Message{
+		Key:   []byte(b.TenantID + ":" + id),
+		Value: value,
+	}); err != nil {
+		return fmt.Errorf("publish digest: %w", err)
+	}
+	digestSize.WithLabelValues(b.Channel).Observe(float64(len(b.Items)))
+	if s.EventsTopic == "" || len(b.Items) == 1 {
+		return nil
+	}
+	// The digest is out: failing now would keep the batch and send it to
+	// the recipient again, so event failures are only logged.
+	if err := s.writeEvents(ctx, b, id); err != nil {
+		s.Logger.Error().Err(err).Str("batch_id", b.ID).Str("digest_id", id).Msg("failed to write digested events")
+	}
+	return nil
+}
+
+// writeEvents reports each item of b as "digested" into digest id.
+func (s *Stage) writeEvents(ctx context.Context, b Batch, id string) error {
+	events := make([]kafka.Message, 0, len(b.Items))
+	for _, item := range b.Items {
+		event, err := json.Marshal(map[string]any{
+			"message_id": item.MessageID,
+			"tenant_id":  item.TenantID,
+			"status":     "digested",
+			"channel":    item.Channel,
+			"digest_id":  id,
+			"emitted_at": time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("marshal digest event: %w", err)
+		}
+		events = append(events, kafka.Message{Key: []byte(item.MessageID), Value: event})
+	}
+	return s.WriterFactory(s.EventsTopic).WriteMessages(ctx, events...)
+}
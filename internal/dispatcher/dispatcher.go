@@ -7,16 +7,68 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/segmentio/kafka-go"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/example/notification-service/internal/digest"
+	"github.com/example/notification-service/internal/routing"
+	"github.com/example/notification-service/internal/scheduler"
 )
 
 type Dispatcher struct {
 	ReaderFactory func() *kafka.Reader
 	WriterFactory func(topic string) *kafka.Writer
-	Logger        zerolog.Logger
+	// Recipients is optional; when set, to.user_id is resolved to the
+	// channel's address from the recipient's profile.
+	Recipients RecipientDirectory
+	// Preferences is optional; when set, messages the recipient opted out of
+	// are dropped and reported to EventsTopic as "suppressed". Critical
+	// messages are always sent.
+	Preferences SuppressionChecker
+	// QuietHours and Scheduler are optional; together they hold non-critical
+	// messages that land in a tenant's quiet window until it ends.
+	QuietHours QuietHoursPolicy
+	Scheduler  Scheduler
+	// Throttle is optional and also needs Scheduler; sends over a tenant's
+	// rate cap are deferred to the slot the limiter reserved for them.
+	Throttle Throttle
+	// Router selects each message's destination topic; when nil the
+	// built-in one-topic-per-channel rules apply.
+	Router Router
+	// Fallbacks is optional; when set, messages with a fallback list have
+	// their next channel tracked so fallback.Watcher can send it if this
+	// one fails or times out.
+	Fallbacks FallbackTracker
+	// DigestTopic is optional; when set, messages with a digest_key go to
+	// the digest stage instead of straight to their channel topic.
+	DigestTopic string
+	EventsTopic string
+	Logger      zerolog.Logger
+}
+
+type SuppressionChecker interface {
+	Suppressed(ctx context.Context, tenantID, recipient, category, channel string) (bool, error)
+}
+
+type QuietHoursPolicy interface {
+	DeferUntil(ctx context.Context, tenantID, channel, timezone string, now time.Time) (time.Time, bool, error)
+}
+
+type Throttle interface {
+	Reserve(tenantID, channel string, now time.Time) time.Duration
+}
+
+type Router interface {
+	Route(msg routing.Message) routing.Decision
+}
+
+var defaultRoutes = routing.NewTable(routing.DefaultRules(), routing.DefaultFallback)
+
+type Scheduler interface {
+	Schedule(ctx context.Context, d scheduler.Deferred) error
 }
 
 type IncomingMessage struct {
@@ -25,6 +77,7 @@ type IncomingMessage struct {
 	Channel   string                 `json:"channel"`
 	Payload   map[string]any         `json:"payload"`
 	Template  string                 `json:"template_id"`
+	Priority  string                 `json:"priority,omitempty"`
 	CreatedAt time.Time              `json:"created_at"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 }
@@ -53,10 +106,114 @@ func (d *Dispatcher) Run(ctx context.Context) error {
 		spanCtx, span := tracer.Start(ctx, "dispatch")
 		span.SetAttributes(attribute.String("message.id", incoming.MessageID))
 
-		topic := topicForChannel(incoming.Channel)
-		if topic == "" {
-			d.Logger.Warn().Str("channel", incoming.Channel).Msg("unknown channel, sending to DLQ")
-			topic = "dlq.notifications"
+		resolved, err := d.resolveRecipient(spanCtx, &incoming)
+		if err != nil {
+			span.RecordError(err)
+			span.End()
+			return err
+		}
+		if !resolved {
+			d.Logger.Warn().Str("message_id", incoming.MessageID).Str("channel", incoming.Channel).Msg("recipient has no address for channel, dropping message")
+			if err := d.trackFallback(spanCtx, m, incoming); err != nil {
+				span.RecordError(err)
+				span.End()
+				return err
+			}
+			if err := d.emitEvent(spanCtx, incoming, "failed", "recipient_unresolved"); err != nil {
+				span.RecordError(err)
+				span.End()
+				return err
+			}
+			span.End()
+			if err := reader.CommitMessages(ctx, m); err != nil {
+				return fmt.Errorf("commit message: %w", err)
+			}
+			continue
+		}
+
+		suppressed, err := d.suppressed(spanCtx, incoming)
+		if err != nil {
+			span.RecordError(err)
+			span.End()
+			return fmt.Errorf("check preferences: %w", err)
+		}
+		if suppressed {
+			d.Logger.Info().Str("message_id", incoming.MessageID).Msg("recipient opted out, dropping message")
+			if err := d.trackFallback(spanCtx, m, incoming); err != nil {
+				span.RecordError(err)
+				span.End()
+				return err
+			}
+			if err := d.emitEvent(spanCtx, incoming, "suppressed", "preference"); err != nil {
+				span.RecordError(err)
+				span.End()
+				return err
+			}
+			span.End()
+			if err := reader.CommitMessages(ctx, m); err != nil {
+				return fmt.Errorf("commit message: %w", err)
+			}
+			continue
+		}
+
+		until, deferred, err := d.quietUntil(spanCtx, incoming)
+		if err != nil {
+			span.RecordError(err)
+			span.End()
+			return fmt.Errorf("check quiet hours: %w", err)
+		}
+		if deferred {
+			if err := d.deferMessage(spanCtx, m, incoming, until, "quiet_hours"); err != nil {
+				span.RecordError(err)
+				span.End()
+				return err
+			}
+			span.End()
+			if err := reader.CommitMessages(ctx, m); err != nil {
+				return fmt.Errorf("commit message: %w", err)
+			}
+			continue
+		}
+
+		if wait := d.throttleWait(incoming); wait > 0 {
+			if err := d.deferThrottled(spanCtx, m, incoming, time.Now().UTC().Add(wait)); err != nil {
+				span.RecordError(err)
+				span.End()
+				return err
+			}
+			span.End()
+			if err := reader.CommitMessages(ctx, m); err != nil {
+				return fmt.Errorf("commit message: %w", err)
+			}
+			continue
+		}
+		delete(incoming.Metadata, throttleReservedKey)
+
+		decision := d.route(incoming)
+		if !decision.Matched {
+			d.Logger.Warn().Str("channel", incoming.Channel).Str("topic", decision.Topic).Msg("no routing rule matched, sending to fallback topic")
+		}
+		span.SetAttributes(attribute.String("routing.rule", decision.RuleID))
+
+		// Digested messages are never resolved under their own id, so a
+		// fallback chain on them is not tracked; ingestion rejects the
+		// combination.
+		digestible := d.digestible(incoming)
+		if !digestible {
+			if err := d.trackFallback(spanCtx, m, incoming); err != nil {
+				span.RecordError(err)
+				span.End()
+				return err
+			}
+		}
+
+		topic := decision.Topic
+		if digestible {
+			if incoming.Metadata == nil {
+				incoming.Metadata = map[string]interface{}{}
+			}
+			incoming.Metadata[digest.DestinationKey] = topic
+			topic = d.DigestTopic
 		}
 
 		writer := d.WriterFactory(topic)
@@ -84,17 +241,139 @@ func (d *Dispatcher) Run(ctx context.Context) error {
 	}
 }
 
-func topicForChannel(channel string) string {
+func (d *Dispatcher) suppressed(ctx context.Context, msg IncomingMessage) (bool, error) {
+	if d.Preferences == nil || msg.Priority == "critical" {
+		return false, nil
+	}
+	category, _ := msg.Payload["category"].(string)
+	return d.Preferences.Suppressed(ctx, msg.TenantID, recipientAddress(msg.Channel, msg.Payload), category, msg.Channel)
+}
+
+func (d *Dispatcher) quietUntil(ctx context.Context, msg IncomingMessage) (time.Time, bool, error) {
+	if d.QuietHours == nil || d.Scheduler == nil || msg.Priority == "critical" {
+		return time.Time{}, false, nil
+	}
+	timezone, _ := msg.Payload["timezone"].(string)
+	return d.QuietHours.DeferUntil(ctx, msg.TenantID, msg.Channel, timezone, time.Now().UTC())
+}
+
+// throttleReservedKey marks a record deferred by the throttle. Its send slot
+// was already reserved, so it is not charged against the limit a second time
+// when it re-enters.
+const throttleReservedKey = "throttle_reserved"
+
+func (d *Dispatcher) throttleWait(msg IncomingMessage) time.Duration {
+	if d.Throttle == nil || d.Scheduler == nil || msg.Priority == "critical" {
+		return 0
+	}
+	if reserved, _ := msg.Metadata[throttleReservedKey].(bool); reserved {
+		return 0
+	}
+	return d.Throttle.Reserve(msg.TenantID, msg.Channel, time.Now().UTC())
+}
+
+func (d *Dispatcher) deferThrottled(ctx context.Context, m kafka.Message, msg IncomingMessage, until time.Time) error {
+	if msg.Metadata == nil {
+		msg.Metadata = map[string]interface{}{}
+	}
+	msg.Metadata[throttleReservedKey] = true
+	value, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal throttled message: %w", err)
+	}
+	m.Value = value
+	return d.deferMessage(ctx, m, msg, until, "throttled")
+}
+
+// deferMessage parks the original record so it re-enters the dispatcher
+// from its source topic once until has passed.
+func (d *Dispatcher) deferMessage(ctx context.Context, m kafka.Message, msg IncomingMessage, until time.Time, reason string) error {
+	if err := d.Scheduler.Schedule(ctx, scheduler.Deferred{
+		ID:        uuid.NewString(),
+		TenantID:  msg.TenantID,
+		MessageID: msg.MessageID,
+		Topic:     m.Topic,
+		Key:       m.Key,
+		Value:     m.Value,
+		NotBefore: until,
+		Reason:    reason,
+	}); err != nil {
+		return fmt.Errorf("defer message: %w", err)
+	}
+	d.Logger.Info().Str("message_id", msg.MessageID).Time("until", until).Str("reason", reason).Msg("message deferred")
+	return d.emitEvent(ctx, msg, "deferred", reason)
+}
+
+func (d *Dispatcher) emitEvent(ctx context.Context, msg IncomingMessage, status, reason string) error {
+	if d.EventsTopic == "" {
+		return nil
+	}
+	event := map[string]any{
+		"message_id":  msg.MessageID,
+		"tenant_id":   msg.TenantID,
+		"status":      status,
+		"reason":      reason,
+		"channel":     msg.Channel,
+		"template_id": msg.Template,
+		"emitted_at":  time.Now().UTC(),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	if err := d.WriterFactory(d.EventsTopic).WriteMessages(ctx, kafka.Message{
+		Key:   []byte(msg.MessageID),
+		Value: payload,
+	}); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+	return nil
+}
+
+// recipientAddress picks the address the channel delivers to out of the
+// message's "to" object.
+func recipientAddress(channel string, payload map[string]any) string {
+	to, _ := payload["to"].(map[string]any)
+	address, _ := to[addressKey(channel)].(string)
+	return address
+}
+
+// addressKey names the "to" field a channel delivers to, or "" for channels
+// the dispatcher does not know.
+func addressKey(channel string) string {
 	switch channel {
 	case "email":
-		return "dispatch.email"
-	case "sms":
-		return "dispatch.sms"
+		return "email"
+	case "sms", "whatsapp":
+		return "phone"
 	case "push":
-		return "dispatch.push"
-	case "whatsapp":
-		return "dispatch.wa"
-	default:
-		return ""
+		return "push_token"
+	case "in_app":
+		return "user_id"
+	}
+	return ""
+}
+
+// digestible reports whether msg should be buffered into a digest. Critical
+// messages are never held back.
+func (d *Dispatcher) digestible(msg IncomingMessage) bool {
+	if d.DigestTopic == "" || msg.Priority == "critical" {
+		return false
+	}
+	key, _ := msg.Payload["digest_key"].(string)
+	return key != ""
+}
+
+func (d *Dispatcher) route(msg IncomingMessage) routing.Decision {
+	router := d.Router
+	if router == nil {
+		router = defaultRoutes
 	}
+	return router.Route(routing.Message{
+		TenantID:   msg.TenantID,
+		Channel:    msg.Channel,
+		TemplateID: msg.Template,
+		Priority:   msg.Priority,
+		Metadata:   msg.Metadata,
+	})
 }
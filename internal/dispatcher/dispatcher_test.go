@@ -1,19 +1,130 @@
 package dispatcher
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
-func TestTopicForChannel(t *testing.T) {
+func TestRouteDefaultTopics(t *testing.T) {
 	cases := map[string]string{
 		"email":    "dispatch.email",
 		"sms":      "dispatch.sms",
 		"push":     "dispatch.push",
 		"whatsapp": "dispatch.wa",
-		"unknown":  "",
+		"unknown":  "dlq.notifications",
 	}
 
+	var d Dispatcher
 	for input, expected := range cases {
-		if got := topicForChannel(input); got != expected {
-			t.Fatalf("topicForChannel(%s)=%s, expected %s", input, got, expected)
+		if got := d.route(IncomingMessage{TenantID: "t1", Channel: input}).Topic; got != expected {
+			t.Fatalf("route(%s)=%s, expected %s", input, got, expected)
+		}
+	}
+}
+
+func TestRoutePriority(t *testing.T) {
+	cases := []struct {
+		channel  string
+		priority string
+		expected string
+	}{
+		{"email", "critical", "dispatch.email.critical"},
+		{"email", "high", "dispatch.email.high"},
+		{"email", "normal", "dispatch.email"},
+		{"sms", "bulk", "dispatch.sms"},
+		{"whatsapp", "critical", "dispatch.wa"},
+		{"unknown", "critical", "dlq.notifications"},
+	}
+
+	var d Dispatcher
+	for _, tc := range cases {
+		msg := IncomingMessage{TenantID: "t1", Channel: tc.channel, Priority: tc.priority}
+		if got := d.route(msg).Topic; got != tc.expected {
+			t.Fatalf("route(%s, %s)=%s, expected %s", tc.channel, tc.priority, got, tc.expected)
+		}
+	}
+}
+
+func TestRecipientAddress(t *testing.T) {
+	payload := map[string]any{"to": map[string]any{"email": "a@b.com", "phone": "+15550100", "push_token": "tok"}}
+	cases := map[string]string{
+		"email":    "a@b.com",
+		"sms":      "+15550100",
+		"whatsapp": "+15550100",
+		"push":     "tok",
+		"unknown":  "",
+	}
+
+	for channel, expected := range cases {
+		if got := recipientAddress(channel, payload); got != expected {
+			t.Fatalf("recipientAddress(%s)=%s, expected %s", channel, got, expected)
+		}
+	}
+	if got := recipientAddress("email", map[string]any{}); got != "" {
+		t.Fatalf("expected empty address without to, got %s", got)
+	}
+}
+
+func TestNextStep(t *testing.T) {
+	msg := IncomingMessage{
+		MessageID: "m1",
+		TenantID:  "t1",
+		Channel:   "push",
+		Template:  "welcome",
+		Payload: map[string]any{
+			"to":     map[string]any{"push_token": "tok"},
+			"locale": "en",
+			"fallback": []any{
+				map[string]any{"channel": "sms", "to": map[string]any{"phone": "+15550100"}},
+				map[string]any{"channel": "email", "to": map[string]any{"email": "a@b.com"}, "template_id": "welcome-email"},
+			},
+		},
+		Metadata: map[string]interface{}{throttleReservedKey: true},
+	}
+
+	next, ok := nextStep(msg)
+	if !ok {
+		t.Fatal("expected a next step")
+	}
+	if next.Channel != "sms" || next.Template != "welcome" || recipientAddress("sms", next.Payload) != "+15550100" {
+		t.Fatalf("unexpected sms step %+v", next)
+	}
+	if next.Metadata["fallback_from"] != "push" || next.Metadata[throttleReservedKey] != nil {
+		t.Fatalf("unexpected metadata %v", next.Metadata)
+	}
+	if recipientAddress("push", msg.Payload) != "tok" {
+		t.Fatal("nextStep modified the original payload")
+	}
+
+	last, ok := nextStep(next)
+	if !ok || last.Channel != "email" || last.Template != "welcome-email" {
+		t.Fatalf("unexpected email step %+v", last)
+	}
+	if _, ok := last.Payload["fallback"]; ok {
+		t.Fatal("last step should carry no fallback list")
+	}
+	if _, ok := nextStep(last); ok {
+		t.Fatal("expected the chain to end")
+	}
+}
+
+type optedOut struct{}
+
+func (optedOut) Suppressed(context.Context, string, string, string, string) (bool, error) {
+	return true, nil
+}
+
+func TestSuppressedCriticalBypass(t *testing.T) {
+	d := Dispatcher{Preferences: optedOut{}}
+	cases := map[string]bool{"critical": false, "high": true, "": true}
+	for priority, want := range cases {
+		msg := IncomingMessage{TenantID: "t1", Channel: "email", Priority: priority, Payload: map[string]any{"to": map[string]any{"email": "ana@example.com"}}}
+		got, err := d.suppressed(context.Background(), msg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Fatalf("suppressed(%q)=%v, expected %v", priority, got, want)
 		}
 	}
 }
@@ -0,0 +1,102 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/fallback"
+)
+
+type FallbackTracker interface {
+	Track(ctx context.Context, p fallback.Pending) error
+}
+
+// trackFallback records the next channel of msg's fallback list before the
+// current channel is attempted. The next step re-enters the dispatcher from
+// m's topic so preferences, quiet hours and routing apply to it as well.
+func (d *Dispatcher) trackFallback(ctx context.Context, m kafka.Message, msg IncomingMessage) error {
+	if d.Fallbacks == nil {
+		return nil
+	}
+	next, ok := nextStep(msg)
+	if !ok {
+		return nil
+	}
+	value, err := json.Marshal(next)
+	if err != nil {
+		return fmt.Errorf("marshal fallback step: %w", err)
+	}
+	if err := d.Fallbacks.Track(ctx, fallback.Pending{
+		MessageID:   msg.MessageID,
+		TenantID:    msg.TenantID,
+		Channel:     msg.Channel,
+		NextChannel: next.Channel,
+		Topic:       m.Topic,
+		Key:         m.Key,
+		Value:       value,
+		Deadline:    time.Now().UTC().Add(fallbackTimeout(msg.Payload)),
+	}); err != nil {
+		return fmt.Errorf("track fallback: %w", err)
+	}
+	return nil
+}
+
+// nextStep turns the first remaining fallback entry into a message for that
+// channel, carrying the rest of the list with it.
+func nextStep(msg IncomingMessage) (IncomingMessage, bool) {
+	steps := fallbackSteps(msg.Payload)
+	if len(steps) == 0 {
+		return IncomingMessage{}, false
+	}
+	step := steps[0]
+
+	next := msg
+	next.Channel = step.Channel
+	if step.TemplateID != "" {
+		next.Template = step.TemplateID
+	}
+	next.Payload = make(map[string]any, len(msg.Payload))
+	for k, v := range msg.Payload {
+		next.Payload[k] = v
+	}
+	next.Payload["to"] = step.To
+	if rest := steps[1:]; len(rest) > 0 {
+		next.Payload["fallback"] = rest
+	} else {
+		delete(next.Payload, "fallback")
+	}
+	next.Metadata = map[string]interface{}{}
+	for k, v := range msg.Metadata {
+		next.Metadata[k] = v
+	}
+	delete(next.Metadata, throttleReservedKey)
+	next.Metadata["fallback_from"] = msg.Channel
+	return next, true
+}
+
+func fallbackSteps(payload map[string]any) []fallback.Step {
+	raw, ok := payload["fallback"]
+	if !ok || raw == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var steps []fallback.Step
+	if err := json.Unmarshal(encoded, &steps); err != nil {
+		return nil
+	}
+	return steps
+}
+
+func fallbackTimeout(payload map[string]any) time.Duration {
+	if seconds, _ := payload["fallback_timeout_seconds"].(float64); seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return fallback.DefaultTimeout
+}
@@ -0,0 +1,48 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/example/notification-service/internal/recipients"
+)
+
+type RecipientDirectory interface {
+	Get(ctx context.Context, tenantID, userID string) (recipients.Profile, error)
+}
+
+// resolveRecipient expands to.user_id into the channel's address and fills
+// locale and timezone the request left empty, so every later stage sees a
+// plain address. It reports false when the user or their address for the
+// channel is unknown.
+func (d *Dispatcher) resolveRecipient(ctx context.Context, msg *IncomingMessage) (bool, error) {
+	if d.Recipients == nil {
+		return true, nil
+	}
+	to, _ := msg.Payload["to"].(map[string]any)
+	userID, _ := to["user_id"].(string)
+	if userID == "" {
+		return true, nil
+	}
+
+	profile, err := d.Recipients.Get(ctx, msg.TenantID, userID)
+	if errors.Is(err, recipients.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("resolve recipient: %w", err)
+	}
+
+	resolved, ok := profile.Address(msg.Channel, to)
+	if !ok && addressKey(msg.Channel) != "" {
+		return false, nil
+	}
+	msg.Payload["to"] = resolved
+	for key, value := range map[string]string{"locale": profile.Locale, "timezone": profile.Timezone} {
+		if current, _ := msg.Payload[key].(string); current == "" && value != "" {
+			msg.Payload[key] = value
+		}
+	}
+	return true, nil
+}
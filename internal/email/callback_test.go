@@ -0,0 +1,130 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/notification-service/internal/webhook"
+)
+
+// TestProviderCallbacksCarryIDs sends a message through each provider, plays
+// the captured request back the way the provider echoes it on its event
+// callback and checks the normalizer recovers our message and tenant ids.
+func TestProviderCallbacksCarryIDs(t *testing.T) {
+	msg := Message{
+		MessageID: "msg-123",
+		TenantID:  "acme",
+		Channel:   "email",
+		Template:  "welcome",
+		Payload:   map[string]any{"to": map[string]any{"email": "ana@example.com"}},
+	}
+
+	tests := []struct {
+		name       string
+		send       func(endpoint string) error
+		callback   func(t *testing.T, sent map[string]any) []byte
+		normalizer webhook.Normalizer
+	}{
+		{
+			name: "sendgrid custom_args",
+			send: func(endpoint string) error {
+				return (&SendGridProvider{Endpoint: endpoint}).Send(context.Background(), msg)
+			},
+			// SendGrid merges custom_args into every event as top-level fields.
+			callback: func(t *testing.T, sent map[string]any) []byte {
+				args, _ := sent["custom_args"].(map[string]any)
+				event := map[string]any{
+					"email":         "ana@example.com",
+					"timestamp":     1709294406,
+					"event":         "bounce",
+					"type":          "bounce",
+					"sg_event_id":   "Ym91bmNlLTAtMzg2NjQyMjc",
+					"sg_message_id": "27a1f3c9d14.b2e.71c5a2.filter0002.20913.65E1B4C22.0",
+				}
+				for k, v := range args {
+					event[k] = v
+				}
+				return mustJSON(t, []any{event})
+			},
+			normalizer: &webhook.SendGrid{},
+		},
+		{
+			name: "ses message tags",
+			send: func(endpoint string) error {
+				return (&SESProvider{Endpoint: endpoint}).Send(context.Background(), msg)
+			},
+			// SES returns message tags in mail.tags, next to its own ses:* tags.
+			callback: func(t *testing.T, sent map[string]any) []byte {
+				tags := map[string]any{"ses:configuration-set": []any{"notifications"}}
+				list, _ := sent["tags"].([]any)
+				for _, item := range list {
+					tag, _ := item.(map[string]any)
+					name, _ := tag["name"].(string)
+					tags[name] = []any{tag["value"]}
+				}
+				event := map[string]any{
+					"eventType": "Bounce",
+					"mail": map[string]any{
+						"messageId":   "0100018df1f2a3b5-6d7e8f9a-000000",
+						"destination": []any{"ana@example.com"},
+						"tags":        tags,
+					},
+					"bounce": map[string]any{"bounceType": "Permanent", "bounceSubType": "General"},
+				}
+				return mustJSON(t, map[string]any{
+					"Type":      "Notification",
+					"MessageId": "7c1d9e2f-1a2b-5c3d-8e4f-5a6b7c8d9e0f",
+					"TopicArn":  "arn:aws:sns:us-east-1:123456789012:ses-events",
+					"Message":   string(mustJSON(t, event)),
+				})
+			},
+			normalizer: &webhook.SES{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent map[string]any
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &sent); err != nil {
+					t.Errorf("decode send request: %v", err)
+				}
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer srv.Close()
+
+			if err := tt.send(srv.URL); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			cb, err := tt.normalizer.Decode(httptest.NewRequest(http.MethodPost, "/", nil), tt.callback(t, sent))
+			if err != nil {
+				t.Fatalf("decode callback: %v", err)
+			}
+			if len(cb.Events) != 1 {
+				t.Fatalf("expected one event, got %d", len(cb.Events))
+			}
+			event, err := tt.normalizer.Normalize(cb.Events[0], time.Now())
+			if err != nil {
+				t.Fatalf("normalize: %v", err)
+			}
+			if event.MessageID != msg.MessageID || event.TenantID != msg.TenantID {
+				t.Fatalf("expected %s/%s, got %s/%s", msg.MessageID, msg.TenantID, event.MessageID, event.TenantID)
+			}
+		})
+	}
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,158 @@
+package email
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+)
+
+var laneMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "email_lane_messages_total",
+	Help: "Messages taken from each priority lane",
+}, []string{"lane"})
+
+// Lane is one priority topic consumed by the worker. Strict lanes are always
+// served first when they have a message waiting, so critical traffic never
+// queues behind a bulk backlog. The remaining lanes share throughput in
+// proportion to Weight, so bulk is slowed but never starved.
+type Lane struct {
+	Name          string
+	Weight        int
+	Strict        bool
+	ReaderFactory func() *kafka.Reader
+}
+
+// DefaultLanes builds the standard critical/high/normal/bulk lanes on top of
+// readerFor, which returns a reader for a priority's topic.
+func DefaultLanes(readerFor func(priority string) *kafka.Reader) []Lane {
+	weights := map[string]int{"critical": 1, "high": 6, "normal": 3, "bulk": 1}
+	lanes := make([]Lane, 0, len(weights))
+	for _, p := range []string{"critical", "high", "normal", "bulk"} {
+		priority := p
+		lanes = append(lanes, Lane{
+			Name:          priority,
+			Weight:        weights[priority],
+			Strict:        priority == "critical",
+			ReaderFactory: func() *kafka.Reader { return readerFor(priority) },
+		})
+	}
+	return lanes
+}
+
+type fetched struct {
+	msg kafka.Message
+	err error
+}
+
+type laneState struct {
+	lane    Lane
+	reader  *kafka.Reader
+	ch      chan fetched
+	pending *fetched
+	current int
+}
+
+// laneScheduler runs one fetcher per lane, each holding at most one message
+// ahead, and hands messages to the worker in priority order.
+type laneScheduler struct {
+	lanes []*laneState
+	wake  chan struct{}
+}
+
+func newLaneScheduler(ctx context.Context, lanes []Lane) *laneScheduler {
+	s := &laneScheduler{wake: make(chan struct{}, 1)}
+	for _, lane := range lanes {
+		state := &laneState{lane: lane, reader: lane.ReaderFactory(), ch: make(chan fetched, 1)}
+		s.lanes = append(s.lanes, state)
+		go s.fetch(ctx, state)
+	}
+	return s
+}
+
+func (s *laneScheduler) fetch(ctx context.Context, state *laneState) {
+	for {
+		msg, err := state.reader.FetchMessage(ctx)
+		select {
+		case state.ch <- fetched{msg: msg, err: err}:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *laneScheduler) next(ctx context.Context) (*kafka.Reader, kafka.Message, error) {
+	for {
+		ready := make([]bool, len(s.lanes))
+		for i, state := range s.lanes {
+			if state.pending == nil {
+				select {
+				case f := <-state.ch:
+					state.pending = &f
+				default:
+				}
+			}
+			ready[i] = state.pending != nil
+		}
+
+		if i := s.pick(ready); i >= 0 {
+			state := s.lanes[i]
+			f := *state.pending
+			state.pending = nil
+			if f.err != nil {
+				return nil, kafka.Message{}, f.err
+			}
+			laneMessages.WithLabelValues(state.lane.Name).Inc()
+			return state.reader, f.msg, nil
+		}
+
+		select {
+		case <-s.wake:
+		case <-ctx.Done():
+			return nil, kafka.Message{}, ctx.Err()
+		}
+	}
+}
+
+// pick chooses among ready lanes: the first ready strict lane, otherwise
+// smooth weighted round-robin over the ready weighted lanes.
+func (s *laneScheduler) pick(ready []bool) int {
+	for i, state := range s.lanes {
+		if ready[i] && state.lane.Strict {
+			return i
+		}
+	}
+	best, total := -1, 0
+	for i, state := range s.lanes {
+		if !ready[i] {
+			continue
+		}
+		weight := state.lane.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		state.current += weight
+		total += weight
+		if best < 0 || state.current > s.lanes[best].current {
+			best = i
+		}
+	}
+	if best >= 0 {
+		s.lanes[best].current -= total
+	}
+	return best
+}
+
+func (s *laneScheduler) close() {
+	for _, state := range s.lanes {
+		_ = state.reader.Close()
+	}
+}
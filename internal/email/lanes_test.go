@@ -0,0 +1,30 @@
+package email
+
+import "testing"
+
+func TestLaneSchedulerPick(t *testing.T) {
+	s := &laneScheduler{lanes: []*laneState{
+		{lane: Lane{Name: "critical", Weight: 1, Strict: true}},
+		{lane: Lane{Name: "high", Weight: 3}},
+		{lane: Lane{Name: "bulk", Weight: 1}},
+	}}
+
+	if got := s.pick([]bool{true, true, true}); got != 0 {
+		t.Fatalf("expected strict lane to win, got %d", got)
+	}
+	if got := s.pick([]bool{false, false, false}); got != -1 {
+		t.Fatalf("expected no pick, got %d", got)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 40; i++ {
+		counts[s.lanes[s.pick([]bool{false, true, true})].lane.Name]++
+	}
+	if counts["high"] != 30 || counts["bulk"] != 10 {
+		t.Fatalf("expected 3:1 split, got %v", counts)
+	}
+
+	if got := s.pick([]bool{false, false, true}); got != 2 {
+		t.Fatalf("expected the only ready lane, got %d", got)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+
+	"github.com/example/notification-service/internal/templates"
 )
 
 type SendGridProvider struct {
@@ -25,6 +27,19 @@ func (p *SendGridProvider) Send(ctx context.Context, msg Message) error {
 		"personalizations":      []any{msg.Payload["to"]},
 		"dynamic_template_data": msg.Payload["data"],
 	}
+	if msg.Rendered != nil {
+		payload = map[string]any{
+			"personalizations": []any{msg.Payload["to"]},
+			"subject":          msg.Rendered.Subject,
+			"content":          sendGridContent(*msg.Rendered),
+		}
+	}
+	if len(msg.Headers) > 0 {
+		payload["headers"] = msg.Headers
+	}
+	// SendGrid copies custom_args onto every Event Webhook event as
+	// top-level fields, which is how callbacks find their way back.
+	payload["custom_args"] = callbackArgs(msg)
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return err
@@ -56,3 +71,14 @@ func (p *SendGridProvider) Send(ctx context.Context, msg Message) error {
 	}
 	return nil
 }
+
+func sendGridContent(r templates.Rendered) []map[string]string {
+	var content []map[string]string
+	if r.Text != "" {
+		content = append(content, map[string]string{"type": "text/plain", "value": r.Text})
+	}
+	if r.HTML != "" {
+		content = append(content, map[string]string{"type": "text/html", "value": r.HTML})
+	}
+	return content
+}
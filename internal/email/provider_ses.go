@@ -25,6 +25,23 @@ func (p *SESProvider) Send(ctx context.Context, msg Message) error {
 		"to":          msg.Payload["to"],
 		"data":        msg.Payload["data"],
 	}
+	if msg.Rendered != nil {
+		payload = map[string]any{
+			"to":      msg.Payload["to"],
+			"subject": msg.Rendered.Subject,
+			"html":    msg.Rendered.HTML,
+			"text":    msg.Rendered.Text,
+		}
+	}
+	if len(msg.Headers) > 0 {
+		payload["headers"] = msg.Headers
+	}
+	// Message tags come back in mail.tags of configuration set events.
+	args := callbackArgs(msg)
+	payload["tags"] = []map[string]string{
+		{"name": "message_id", "value": args["message_id"]},
+		{"name": "tenant_id", "value": args["tenant_id"]},
+	}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return err
@@ -12,6 +12,10 @@ import (
 	"github.com/segmentio/kafka-go"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/example/notification-service/internal/preferences"
+	"github.com/example/notification-service/internal/templates"
 )
 
 type Provider interface {
@@ -19,78 +23,196 @@ type Provider interface {
 	Send(ctx context.Context, msg Message) error
 }
 
+// Renderer turns a stored template into message content before it is handed
+// to a provider.
+type Renderer interface {
+	Render(ctx context.Context, req templates.RenderRequest) (templates.Rendered, error)
+}
+
+// Tracker adds open and click tracking to rendered HTML.
+type Tracker interface {
+	Instrument(ctx context.Context, tenantID, messageID, html string) (string, error)
+}
+
 type Message struct {
-	MessageID string         `json:"message_id"`
-	TenantID  string         `json:"tenant_id"`
-	Channel   string         `json:"channel"`
-	Payload   map[string]any `json:"payload"`
-	Template  string         `json:"template_id"`
-	CreatedAt time.Time      `json:"created_at"`
+	MessageID string              `json:"message_id"`
+	TenantID  string              `json:"tenant_id"`
+	Channel   string              `json:"channel"`
+	Payload   map[string]any      `json:"payload"`
+	Template  string              `json:"template_id"`
+	Priority  string              `json:"priority,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	Rendered  *templates.Rendered `json:"rendered,omitempty"`
+	Metadata  map[string]any      `json:"metadata,omitempty"`
+	Headers   map[string]string   `json:"headers,omitempty"`
+}
+
+// IsTest reports whether the message is template test-send traffic.
+func (m Message) IsTest() bool {
+	test, _ := m.Metadata["test"].(bool)
+	return test
+}
+
+// Unsubscribable reports whether the message gets List-Unsubscribe headers.
+// Uncategorised mail would opt the recipient out of every category, and
+// critical mail such as password resets must stay deliverable, so neither
+// offers one.
+func (m Message) Unsubscribable() bool {
+	return payloadString(m, "category") != "" && m.Priority != "critical"
 }
 
 type Worker struct {
 	ReaderFactory func() *kafka.Reader
-	DLQWriter     *kafka.Writer
-	EventWriter   *kafka.Writer
-	Providers     []Provider
-	Logger        zerolog.Logger
+	// Lanes, when set, replaces ReaderFactory with one reader per priority
+	// topic; see Lane for how they are scheduled.
+	Lanes       []Lane
+	DLQWriter   *kafka.Writer
+	EventWriter *kafka.Writer
+	Providers   []Provider
+	// Templates is optional; when nil providers receive the template id and
+	// render with their own hosted templates.
+	Templates Renderer
+	// Unsubscribe is optional; when set every message carries one-click
+	// List-Unsubscribe headers for its category.
+	Unsubscribe *preferences.Signer
+	// Tracking is optional; when set rendered HTML of non-test messages
+	// gets tracked links and an open pixel.
+	Tracking Tracker
+	// Suppressions is optional; suppressed addresses are skipped before any
+	// provider is called.
+	Suppressions SuppressionChecker
+	Logger       zerolog.Logger
+}
+
+type SuppressionChecker interface {
+	IsSuppressed(ctx context.Context, tenantID, address string) (bool, error)
 }
 
 func (w *Worker) Run(ctx context.Context) error {
 	if len(w.Providers) == 0 {
 		return errors.New("at least one provider required")
 	}
-	reader := w.ReaderFactory()
-	defer reader.Close()
+	lanes := w.Lanes
+	if len(lanes) == 0 {
+		if w.ReaderFactory == nil {
+			return errors.New("worker requires a reader factory or lanes")
+		}
+		lanes = []Lane{{Name: "default", Weight: 1, ReaderFactory: w.ReaderFactory}}
+	}
 	tracer := otel.Tracer("email-worker")
 
+	ctx, cancel := context.WithCancel(ctx)
+	sched := newLaneScheduler(ctx, lanes)
+	defer func() {
+		cancel()
+		sched.close()
+	}()
+
 	for {
-		msg, err := reader.FetchMessage(ctx)
+		reader, msg, err := sched.next(ctx)
 		if err != nil {
 			return fmt.Errorf("fetch message: %w", err)
 		}
-
-		var payload Message
-		if err := json.Unmarshal(msg.Value, &payload); err != nil {
-			w.Logger.Error().Err(err).Msg("failed to decode email payload")
-			_ = reader.CommitMessages(ctx, msg)
-			continue
+		if err := w.process(ctx, tracer, msg); err != nil {
+			return err
 		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			return fmt.Errorf("commit message: %w", err)
+		}
+	}
+}
+
+// process handles one record. A nil error means the record is finished with,
+// whether it was sent, suppressed or dead-lettered, and can be committed.
+func (w *Worker) process(ctx context.Context, tracer trace.Tracer, msg kafka.Message) error {
+	var payload Message
+	if err := json.Unmarshal(msg.Value, &payload); err != nil {
+		w.Logger.Error().Err(err).Msg("failed to decode email payload")
+		return nil
+	}
 
-		spanCtx, span := tracer.Start(ctx, "deliver_email")
-		span.SetAttributes(attribute.String("message.id", payload.MessageID))
+	spanCtx, span := tracer.Start(ctx, "deliver_email")
+	defer span.End()
+	span.SetAttributes(attribute.String("message.id", payload.MessageID))
 
-		sent := false
-		for _, provider := range w.Providers {
-			if err := w.deliverWithProvider(spanCtx, provider, payload); err != nil {
+	if w.Suppressions != nil {
+		suppressed, err := w.Suppressions.IsSuppressed(spanCtx, payload.TenantID, recipientAddress(payload))
+		if err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("check suppression list: %w", err)
+		}
+		if suppressed {
+			w.Logger.Info().Str("message_id", payload.MessageID).Msg("recipient is on the suppression list, skipping")
+			if err := w.emitEvent(ctx, payload, "suppressed", "suppression_list"); err != nil {
 				span.RecordError(err)
-				w.Logger.Warn().Err(err).Str("provider", provider.Name()).Msg("provider send failed")
-				continue
+				return err
 			}
-			sent = true
-			break
+			return nil
 		}
+	}
 
-		if !sent {
-			w.Logger.Error().Str("message_id", payload.MessageID).Msg("all providers failed, sending to DLQ")
-			if err := w.writeDLQ(ctx, payload); err != nil {
-				span.RecordError(err)
-				span.End()
-				return err
+	if w.Templates != nil {
+		rendered, err := w.Templates.Render(spanCtx, templates.RenderRequest{
+			TenantID:   payload.TenantID,
+			TemplateID: payload.Template,
+			Version:    payloadInt(payload, "template_version"),
+			Locale:     payloadString(payload, "locale"),
+			Recipient:  recipientAddress(payload),
+			Data:       payloadData(payload),
+		})
+		if err != nil {
+			span.RecordError(err)
+			w.Logger.Error().Err(err).Str("message_id", payload.MessageID).Msg("template render failed, sending to DLQ")
+			return w.fail(ctx, payload, "render_error")
+		}
+		if w.Tracking != nil && rendered.HTML != "" && !payload.IsTest() {
+			html, err := w.Tracking.Instrument(spanCtx, payload.TenantID, payload.MessageID, rendered.HTML)
+			if err != nil {
+				w.Logger.Warn().Err(err).Str("message_id", payload.MessageID).Msg("failed to add tracking, sending untracked")
+			} else {
+				rendered.HTML = html
 			}
+		}
+		payload.Rendered = &rendered
+	}
+	if w.Unsubscribe != nil && payload.Unsubscribable() {
+		headers, err := w.Unsubscribe.Headers(preferences.Claims{
+			TenantID:  payload.TenantID,
+			Recipient: recipientAddress(payload),
+			Category:  payloadString(payload, "category"),
+			Channel:   payload.Channel,
+		})
+		if err != nil {
+			w.Logger.Warn().Err(err).Str("message_id", payload.MessageID).Msg("failed to build unsubscribe headers")
 		} else {
-			if err := w.emitEvent(ctx, payload, "sent"); err != nil {
-				span.RecordError(err)
-				span.End()
-				return err
-			}
+			payload.Headers = headers
 		}
+	}
 
-		span.End()
-		if err := reader.CommitMessages(ctx, msg); err != nil {
-			return fmt.Errorf("commit message: %w", err)
+	sent := false
+	for _, provider := range w.Providers {
+		if err := w.deliverWithProvider(spanCtx, provider, payload); err != nil {
+			span.RecordError(err)
+			w.Logger.Warn().Err(err).Str("provider", provider.Name()).Msg("provider send failed")
+			continue
+		}
+		sent = true
+		break
+	}
+
+	if !sent {
+		w.Logger.Error().Str("message_id", payload.MessageID).Msg("all providers failed, sending to DLQ")
+		if err := w.fail(ctx, payload, "all_providers_failed"); err != nil {
+			span.RecordError(err)
+			return err
 		}
+		return nil
 	}
+	if err := w.emitEvent(ctx, payload, "sent", ""); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
 }
 
 func (w *Worker) deliverWithProvider(ctx context.Context, provider Provider, msg Message) error {
@@ -106,6 +228,41 @@ func (w *Worker) deliverWithProvider(ctx context.Context, provider Provider, msg
 	}, op)
 }
 
+// fail routes a message that can never be delivered to the DLQ and reports it.
+func (w *Worker) fail(ctx context.Context, msg Message, reason string) error {
+	if err := w.writeDLQ(ctx, msg); err != nil {
+		return err
+	}
+	return w.emitEvent(ctx, msg, "failed", reason)
+}
+
+// callbackArgs are attached to every provider send so delivery callbacks
+// can be tied back to the message and tenant.
+func callbackArgs(msg Message) map[string]string {
+	return map[string]string{"message_id": msg.MessageID, "tenant_id": msg.TenantID}
+}
+
+func payloadData(msg Message) map[string]any {
+	data, _ := msg.Payload["data"].(map[string]any)
+	return data
+}
+
+func recipientAddress(msg Message) string {
+	to, _ := msg.Payload["to"].(map[string]any)
+	address, _ := to["email"].(string)
+	return address
+}
+
+func payloadString(msg Message, key string) string {
+	v, _ := msg.Payload[key].(string)
+	return v
+}
+
+func payloadInt(msg Message, key string) int {
+	v, _ := msg.Payload[key].(float64)
+	return int(v)
+}
+
 func (w *Worker) writeDLQ(ctx context.Context, msg Message) error {
 	payload, err := json.Marshal(msg)
 	if err != nil {
@@ -114,7 +271,7 @@ func (w *Worker) writeDLQ(ctx context.Context, msg Message) error {
 	return w.DLQWriter.WriteMessages(ctx, kafka.Message{Key: []byte(msg.MessageID), Value: payload})
 }
 
-func (w *Worker) emitEvent(ctx context.Context, msg Message, status string) error {
+func (w *Worker) emitEvent(ctx context.Context, msg Message, status, reason string) error {
 	event := map[string]any{
 		"message_id":  msg.MessageID,
 		"tenant_id":   msg.TenantID,
@@ -123,6 +280,18 @@ func (w *Worker) emitEvent(ctx context.Context, msg Message, status string) erro
 		"template_id": msg.Template,
 		"emitted_at":  time.Now().UTC(),
 	}
+	if reason != "" {
+		event["reason"] = reason
+	}
+	if msg.IsTest() {
+		event["test"] = true
+	}
+	if msg.Rendered != nil {
+		event["template_version"] = msg.Rendered.Version
+		if msg.Rendered.Variant != "" {
+			event["variant"] = msg.Rendered.Variant
+		}
+	}
 	payload, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("marshal event: %w", err)
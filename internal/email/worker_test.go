@@ -0,0 +1,23 @@
+package email
+
+import "testing"
+
+func TestMessageUnsubscribable(t *testing.T) {
+	cases := []struct {
+		name     string
+		category string
+		priority string
+		want     bool
+	}{
+		{name: "categorised", category: "marketing", want: true},
+		{name: "categorised high", category: "marketing", priority: "high", want: true},
+		{name: "uncategorised", priority: "normal"},
+		{name: "critical", category: "account", priority: "critical"},
+	}
+	for _, tc := range cases {
+		msg := Message{Priority: tc.priority, Payload: map[string]any{"category": tc.category}}
+		if got := msg.Unsubscribable(); got != tc.want {
+			t.Fatalf("%s: Unsubscribable()=%v, expected %v", tc.name, got, tc.want)
+		}
+	}
+}
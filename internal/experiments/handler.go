@@ -0,0 +1,54 @@
+package experiments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+type Handler struct {
+	store  Store
+	logger zerolog.Logger
+}
+
+func NewHandler(store Store, logger zerolog.Logger) *Handler {
+	return &Handler{store: store, logger: logger}
+}
+
+func (h *Handler) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/v1/experiments/{template_id}", h.report)
+	return r
+}
+
+func (h *Handler) report(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	templateID := chi.URLParam(r, "template_id")
+	stats, err := h.store.Report(ctx, tenantID, templateID)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"template_id": templateID,
+		"variants":    stats,
+	})
+}
+
+func (h *Handler) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
+	logger := common.WithContext(ctx, h.logger)
+	logger.Error().Err(err).Int("status", status).Msg("experiments handler failed")
+	http.Error(w, err.Error(), status)
+}
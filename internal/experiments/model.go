@@ -0,0 +1,44 @@
+package experiments
+
+import (
+	"context"
+	"time"
+)
+
+// Assignment records which template variant a message was sent with.
+type Assignment struct {
+	MessageID  string
+	TenantID   string
+	TemplateID string
+	Version    int
+	Variant    string
+	AssignedAt time.Time
+}
+
+type VariantStats struct {
+	Variant   string  `json:"variant"`
+	Sent      int64   `json:"sent"`
+	Opened    int64   `json:"opened"`
+	Clicked   int64   `json:"clicked"`
+	OpenRate  float64 `json:"open_rate"`
+	ClickRate float64 `json:"click_rate"`
+}
+
+type Store interface {
+	RecordAssignment(ctx context.Context, a Assignment) error
+	// RecordEngagement marks an assigned message as opened or clicked. Only
+	// the first occurrence of each is kept so rates count unique messages.
+	RecordEngagement(ctx context.Context, tenantID, messageID, status string, at time.Time) error
+	Report(ctx context.Context, tenantID, templateID string) ([]VariantStats, error)
+}
+
+func withRates(stats []VariantStats) []VariantStats {
+	for i := range stats {
+		if stats[i].Sent == 0 {
+			continue
+		}
+		stats[i].OpenRate = float64(stats[i].Opened) / float64(stats[i].Sent)
+		stats[i].ClickRate = float64(stats[i].Clicked) / float64(stats[i].Sent)
+	}
+	return stats
+}
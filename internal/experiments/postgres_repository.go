@@ -0,0 +1,98 @@
+package experiments
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const insertAssignment = `
+INSERT INTO message_variants (
+message_id,
+tenant_id,
+template_id,
+template_version,
+variant,
+assigned_at
+) VALUES ($1,$2,$3,$4,$5,$6)
+ON CONFLICT (message_id) DO NOTHING
+`
+
+const markOpened = `
+UPDATE message_variants SET opened_at = $3
+WHERE tenant_id = $1 AND message_id = $2 AND opened_at IS NULL
+`
+
+// A click implies the message was opened even if the pixel was blocked.
+const markClicked = `
+UPDATE message_variants
+SET clicked_at = $3, opened_at = COALESCE(opened_at, $3)
+WHERE tenant_id = $1 AND message_id = $2 AND clicked_at IS NULL
+`
+
+const selectReport = `
+SELECT variant,
+count(*),
+count(opened_at),
+count(clicked_at)
+FROM message_variants
+WHERE tenant_id = $1 AND template_id = $2
+GROUP BY variant
+ORDER BY variant
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) RecordAssignment(ctx context.Context, a Assignment) error {
+	if _, err := r.pool.Exec(ctx, insertAssignment,
+		a.MessageID,
+		a.TenantID,
+		a.TemplateID,
+		a.Version,
+		a.Variant,
+		a.AssignedAt,
+	); err != nil {
+		return fmt.Errorf("insert variant assignment: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) RecordEngagement(ctx context.Context, tenantID, messageID, status string, at time.Time) error {
+	query := markOpened
+	if status == StatusClicked {
+		query = markClicked
+	}
+	if _, err := r.pool.Exec(ctx, query, tenantID, messageID, at); err != nil {
+		return fmt.Errorf("record %s: %w", status, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Report(ctx context.Context, tenantID, templateID string) ([]VariantStats, error) {
+	rows, err := r.pool.Query(ctx, selectReport, tenantID, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("query variant report: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []VariantStats
+	for rows.Next() {
+		var s VariantStats
+		if err := rows.Scan(&s.Variant, &s.Sent, &s.Opened, &s.Clicked); err != nil {
+			return nil, fmt.Errorf("scan variant report: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return withRates(stats), nil
+}
@@ -0,0 +1,124 @@
+package experiments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+const (
+	StatusSent    = common.StatusSent
+	StatusOpened  = common.StatusOpened
+	StatusClicked = common.StatusClicked
+)
+
+var trackedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "experiment_events_total",
+	Help: "Provider events correlated to template variants",
+}, []string{"status"})
+
+// event is the subset of provider.events fields the tracker needs. Worker
+// events carry emitted_at and the variant; webhook events carry occurred_at.
+type event struct {
+	MessageID       string    `json:"message_id"`
+	TenantID        string    `json:"tenant_id"`
+	Status          string    `json:"status"`
+	TemplateID      string    `json:"template_id"`
+	TemplateVersion int       `json:"template_version"`
+	Variant         string    `json:"variant"`
+	Test            bool      `json:"test"`
+	EmittedAt       time.Time `json:"emitted_at"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+func (e event) at() time.Time {
+	if !e.OccurredAt.IsZero() {
+		return e.OccurredAt
+	}
+	if !e.EmittedAt.IsZero() {
+		return e.EmittedAt
+	}
+	return time.Now().UTC()
+}
+
+// Tracker consumes provider.events, recording variant assignments from worker
+// "sent" events and correlating later opens and clicks by message id.
+type Tracker struct {
+	ReaderFactory func() *kafka.Reader
+	Store         Store
+	Logger        zerolog.Logger
+}
+
+func (t *Tracker) Run(ctx context.Context) error {
+	if t.ReaderFactory == nil || t.Store == nil {
+		return errors.New("tracker requires a reader factory and store")
+	}
+	reader := t.ReaderFactory()
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch message: %w", err)
+		}
+		var e event
+		if err := json.Unmarshal(m.Value, &e); err != nil {
+			t.Logger.Error().Err(err).Msg("failed to decode provider event")
+			_ = reader.CommitMessages(ctx, m)
+			continue
+		}
+		if err := t.handle(ctx, e); err != nil {
+			return err
+		}
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			return fmt.Errorf("commit message: %w", err)
+		}
+	}
+}
+
+func (t *Tracker) handle(ctx context.Context, e event) error {
+	if e.MessageID == "" || e.Test {
+		return nil
+	}
+	status := engagementStatus(e.Status)
+	switch {
+	case status == StatusSent && e.Variant != "":
+		if err := t.Store.RecordAssignment(ctx, Assignment{
+			MessageID:  e.MessageID,
+			TenantID:   e.TenantID,
+			TemplateID: e.TemplateID,
+			Version:    e.TemplateVersion,
+			Variant:    e.Variant,
+			AssignedAt: e.at(),
+		}); err != nil {
+			return err
+		}
+	case status == StatusOpened || status == StatusClicked:
+		if err := t.Store.RecordEngagement(ctx, e.TenantID, e.MessageID, status, e.at()); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	trackedEvents.WithLabelValues(status).Inc()
+	return nil
+}
+
+// engagementStatus returns status when the tracker counts it, else "".
+func engagementStatus(status string) string {
+	switch status {
+	case StatusSent, StatusOpened, StatusClicked:
+		return status
+	default:
+		return ""
+	}
+}
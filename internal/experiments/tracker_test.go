@@ -0,0 +1,63 @@
+package experiments
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	assignments map[string]Assignment
+	engagements map[string][]string
+}
+
+func (m *memoryStore) RecordAssignment(_ context.Context, a Assignment) error {
+	m.assignments[a.MessageID] = a
+	return nil
+}
+
+func (m *memoryStore) RecordEngagement(_ context.Context, _, messageID, status string, _ time.Time) error {
+	m.engagements[messageID] = append(m.engagements[messageID], status)
+	return nil
+}
+
+func (m *memoryStore) Report(context.Context, string, string) ([]VariantStats, error) {
+	return nil, nil
+}
+
+func TestTrackerHandle(t *testing.T) {
+	store := &memoryStore{assignments: map[string]Assignment{}, engagements: map[string][]string{}}
+	tracker := &Tracker{Store: store}
+	ctx := context.Background()
+
+	events := []event{
+		{MessageID: "m1", TenantID: "t1", Status: "sent", TemplateID: "welcome", TemplateVersion: 2, Variant: "B"},
+		{MessageID: "m2", TenantID: "t1", Status: "sent", TemplateID: "welcome"},
+		{MessageID: "m3", TenantID: "t1", Status: "sent", TemplateID: "welcome", Variant: "A", Test: true},
+		{MessageID: "m1", TenantID: "t1", Status: "opened"},
+		{MessageID: "m1", TenantID: "t1", Status: "clicked"},
+		{MessageID: "m1", TenantID: "t1", Status: "delivered"},
+	}
+	for _, e := range events {
+		if err := tracker.handle(ctx, e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(store.assignments) != 1 || store.assignments["m1"].Variant != "B" || store.assignments["m1"].Version != 2 {
+		t.Fatalf("unexpected assignments: %+v", store.assignments)
+	}
+	if got := store.engagements["m1"]; len(got) != 2 || got[0] != StatusOpened || got[1] != StatusClicked {
+		t.Fatalf("unexpected engagements: %v", got)
+	}
+}
+
+func TestWithRates(t *testing.T) {
+	stats := withRates([]VariantStats{{Variant: "A", Sent: 200, Opened: 50, Clicked: 10}, {Variant: "B"}})
+	if stats[0].OpenRate != 0.25 || stats[0].ClickRate != 0.05 {
+		t.Fatalf("unexpected rates: %+v", stats[0])
+	}
+	if stats[1].OpenRate != 0 {
+		t.Fatalf("expected zero rate without sends")
+	}
+}
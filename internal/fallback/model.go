@@ -0,0 +1,77 @@
+package fallback
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+// DefaultTimeout is how long a channel has to report delivery before the
+// message falls through to the next channel.
+const DefaultTimeout = 10 * time.Minute
+
+// Step is one entry of a notification's channel fallback list.
+type Step struct {
+	Channel string         `json:"channel"`
+	To      map[string]any `json:"to"`
+	// TemplateID overrides the original template for this channel.
+	TemplateID string `json:"template_id,omitempty"`
+}
+
+// Pending is the next attempt of a message, held until the current channel
+// fails terminally or Deadline passes, and cancelled on delivery. Value is
+// the notification republished unchanged to Topic.
+type Pending struct {
+	MessageID   string
+	TenantID    string
+	Channel     string
+	NextChannel string
+	Topic       string
+	Key         []byte
+	Value       []byte
+	Deadline    time.Time
+	CreatedAt   time.Time
+}
+
+type Store interface {
+	// Track records the next attempt for a message, replacing any earlier
+	// one, so each message has at most one pending step.
+	Track(ctx context.Context, p Pending) error
+	// Take passes the pending step for messageID to publish and deletes it
+	// only if publish succeeds. The step is only taken when channel is the
+	// one it waits on: message ids are shared across the chain, so events
+	// from an earlier channel must not move it.
+	Take(ctx context.Context, messageID, channel string, publish func(context.Context, Pending) error) (bool, error)
+	// Resolve drops the pending step once the current channel delivered.
+	Resolve(ctx context.Context, messageID, channel string) error
+	// Expire hands up to limit steps past their deadline to publish.
+	Expire(ctx context.Context, now time.Time, limit int, publish func(context.Context, Pending) error) (int, error)
+}
+
+// Outcome classifies a provider event for a fallback chain.
+type Outcome int
+
+const (
+	OutcomeNone Outcome = iota
+	OutcomeDelivered
+	OutcomeFailed
+)
+
+// Classify maps a canonical provider.events status to whether the channel
+// delivered, failed for good, or neither yet. Soft bounces may still
+// deliver on retry so they are not terminal.
+func Classify(status, bounceType string) Outcome {
+	switch status {
+	case common.StatusDelivered, common.StatusOpened, common.StatusClicked:
+		return OutcomeDelivered
+	case common.StatusBounced:
+		if bounceType == "soft" {
+			return OutcomeNone
+		}
+		return OutcomeFailed
+	case common.StatusFailed, common.StatusSuppressed:
+		return OutcomeFailed
+	}
+	return OutcomeNone
+}
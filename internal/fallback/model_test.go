@@ -0,0 +1,30 @@
+package fallback
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		status     string
+		bounceType string
+		want       Outcome
+	}{
+		{"delivered", "", OutcomeDelivered},
+		{"opened", "", OutcomeDelivered},
+		{"clicked", "", OutcomeDelivered},
+		{"failed", "", OutcomeFailed},
+		{"bounced", "hard", OutcomeFailed},
+		{"bounced", "", OutcomeFailed},
+		{"bounced", "soft", OutcomeNone},
+		{"suppressed", "", OutcomeFailed},
+		{"complained", "", OutcomeNone},
+		{"sent", "", OutcomeNone},
+		{"deferred", "", OutcomeNone},
+		{"fallback", "", OutcomeNone},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.status, tt.bounceType); got != tt.want {
+			t.Fatalf("Classify(%q, %q)=%v, want %v", tt.status, tt.bounceType, got, tt.want)
+		}
+	}
+}
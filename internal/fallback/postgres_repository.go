@@ -0,0 +1,141 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const pendingColumns = `message_id, tenant_id, channel, next_channel, topic, message_key, message_value, deadline, created_at`
+
+const upsertPending = `
+INSERT INTO fallback_steps (message_id, tenant_id, channel, next_channel, topic, message_key, message_value, deadline, created_at)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8, now())
+ON CONFLICT (message_id)
+DO UPDATE SET
+tenant_id = EXCLUDED.tenant_id,
+channel = EXCLUDED.channel,
+next_channel = EXCLUDED.next_channel,
+topic = EXCLUDED.topic,
+message_key = EXCLUDED.message_key,
+message_value = EXCLUDED.message_value,
+deadline = EXCLUDED.deadline
+`
+
+const selectPendingForUpdate = `
+SELECT ` + pendingColumns + `
+FROM fallback_steps
+WHERE message_id = $1 AND channel = $2
+FOR UPDATE
+`
+
+// SKIP LOCKED lets several dispatcher replicas sweep in parallel.
+const selectExpired = `
+SELECT ` + pendingColumns + `
+FROM fallback_steps
+WHERE deadline <= $1
+ORDER BY deadline
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+const deletePending = `
+DELETE FROM fallback_steps WHERE message_id = $1 AND channel = $2
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) Track(ctx context.Context, p Pending) error {
+	if _, err := r.pool.Exec(ctx, upsertPending,
+		p.MessageID,
+		p.TenantID,
+		p.Channel,
+		p.NextChannel,
+		p.Topic,
+		p.Key,
+		p.Value,
+		p.Deadline,
+	); err != nil {
+		return fmt.Errorf("track fallback step: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Take(ctx context.Context, messageID, channel string, publish func(context.Context, Pending) error) (bool, error) {
+	taken := false
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		p, err := scanPending(tx.QueryRow(ctx, selectPendingForUpdate, messageID, channel))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("select fallback step: %w", err)
+		}
+		if err := publish(ctx, p); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, deletePending, messageID, p.Channel); err != nil {
+			return fmt.Errorf("delete fallback step: %w", err)
+		}
+		taken = true
+		return nil
+	})
+	return taken, err
+}
+
+func (r *PostgresRepository) Resolve(ctx context.Context, messageID, channel string) error {
+	if _, err := r.pool.Exec(ctx, deletePending, messageID, channel); err != nil {
+		return fmt.Errorf("resolve fallback step: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Expire(ctx context.Context, now time.Time, limit int, publish func(context.Context, Pending) error) (int, error) {
+	expired := 0
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, selectExpired, now, limit)
+		if err != nil {
+			return fmt.Errorf("select expired fallback steps: %w", err)
+		}
+		var due []Pending
+		for rows.Next() {
+			p, err := scanPending(rows)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("scan fallback step: %w", err)
+			}
+			due = append(due, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, p := range due {
+			if err := publish(ctx, p); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, deletePending, p.MessageID, p.Channel); err != nil {
+				return fmt.Errorf("delete fallback step: %w", err)
+			}
+			expired++
+		}
+		return nil
+	})
+	return expired, err
+}
+
+func scanPending(row pgx.Row) (Pending, error) {
+	var p Pending
+	err := row.Scan(&p.MessageID, &p.TenantID, &p.Channel, &p.NextChannel, &p.Topic, &p.Key, &p.Value, &p.Deadline, &p.CreatedAt)
+	return p, err
+}
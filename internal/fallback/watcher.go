@@ -0,0 +1,151 @@
+package fallback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	defaultInterval  = 5 * time.Second
+	defaultBatchSize = 200
+)
+
+var transitions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "fallback_transitions_total",
+	Help: "Messages moved to their next fallback channel",
+}, []string{"from", "to", "reason"})
+
+// Watcher follows provider.events and moves messages down their fallback
+// chain: a terminal failure on the current channel re-publishes the next
+// step at once, delivery cancels it, and a sweep re-publishes steps whose
+// deadline passed without either.
+type Watcher struct {
+	ReaderFactory func() *kafka.Reader
+	WriterFactory func(topic string) *kafka.Writer
+	Store         Store
+	// EventsTopic, when set, receives a "fallback" event per transition.
+	EventsTopic string
+	Interval    time.Duration
+	BatchSize   int
+	Logger      zerolog.Logger
+}
+
+type providerEvent struct {
+	MessageID  string `json:"message_id"`
+	Status     string `json:"status"`
+	Channel    string `json:"channel"`
+	BounceType string `json:"bounce_type"`
+}
+
+func (w *Watcher) Run(ctx context.Context) error {
+	if w.ReaderFactory == nil || w.WriterFactory == nil || w.Store == nil {
+		return errors.New("fallback watcher requires a reader, writer factory and store")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go w.sweep(ctx)
+
+	reader := w.ReaderFactory()
+	defer reader.Close()
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch event: %w", err)
+		}
+		if err := w.handle(ctx, m.Value); err != nil {
+			return err
+		}
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			return fmt.Errorf("commit event: %w", err)
+		}
+	}
+}
+
+func (w *Watcher) handle(ctx context.Context, value []byte) error {
+	var event providerEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		w.Logger.Warn().Err(err).Msg("failed to decode provider event")
+		return nil
+	}
+	// Events without a channel cannot be told apart from the other steps
+	// of the chain, so they never move it.
+	if event.MessageID == "" || event.Channel == "" {
+		return nil
+	}
+	switch Classify(event.Status, event.BounceType) {
+	case OutcomeDelivered:
+		if err := w.Store.Resolve(ctx, event.MessageID, event.Channel); err != nil {
+			return fmt.Errorf("resolve fallback: %w", err)
+		}
+	case OutcomeFailed:
+		if _, err := w.Store.Take(ctx, event.MessageID, event.Channel, w.publisher(event.Status)); err != nil {
+			return fmt.Errorf("take fallback: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) sweep(ctx context.Context) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	batch := w.BatchSize
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	publish := w.publisher("timeout")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for {
+			n, err := w.Store.Expire(ctx, time.Now().UTC(), batch, publish)
+			if err != nil {
+				w.Logger.Error().Err(err).Msg("failed to expire fallback steps")
+				break
+			}
+			if n < batch {
+				break
+			}
+		}
+	}
+}
+
+func (w *Watcher) publisher(reason string) func(context.Context, Pending) error {
+	return func(ctx context.Context, p Pending) error {
+		if err := w.WriterFactory(p.Topic).WriteMessages(ctx, kafka.Message{Key: p.Key, Value: p.Value}); err != nil {
+			return fmt.Errorf("publish fallback: %w", err)
+		}
+		transitions.WithLabelValues(p.Channel, p.NextChannel, reason).Inc()
+		w.Logger.Info().Str("message_id", p.MessageID).Str("from", p.Channel).Str("to", p.NextChannel).Str("reason", reason).Msg("falling back to next channel")
+		if w.EventsTopic == "" {
+			return nil
+		}
+		event, err := json.Marshal(map[string]any{
+			"message_id":   p.MessageID,
+			"tenant_id":    p.TenantID,
+			"status":       "fallback",
+			"reason":       reason,
+			"channel":      p.Channel,
+			"next_channel": p.NextChannel,
+			"emitted_at":   time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("marshal fallback event: %w", err)
+		}
+		return w.WriterFactory(w.EventsTopic).WriteMessages(ctx, kafka.Message{Key: []byte(p.MessageID), Value: event})
+	}
+}
@@ -0,0 +1,63 @@
+package fallback
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// channelStore holds one pending step and matches events the way the
+// Postgres store does.
+type channelStore struct {
+	channel  string
+	resolved bool
+	taken    bool
+}
+
+func (s *channelStore) Track(context.Context, Pending) error { return nil }
+
+func (s *channelStore) Take(_ context.Context, _, channel string, _ func(context.Context, Pending) error) (bool, error) {
+	if channel != s.channel {
+		return false, nil
+	}
+	s.taken = true
+	return true, nil
+}
+
+func (s *channelStore) Resolve(_ context.Context, _, channel string) error {
+	if channel == s.channel {
+		s.resolved = true
+	}
+	return nil
+}
+
+func (s *channelStore) Expire(context.Context, time.Time, int, func(context.Context, Pending) error) (int, error) {
+	return 0, nil
+}
+
+func TestWatcherMatchesCurrentChannel(t *testing.T) {
+	tests := []struct {
+		name         string
+		event        string
+		wantResolved bool
+		wantTaken    bool
+	}{
+		{name: "late delivery of earlier channel", event: `{"message_id":"m1","status":"delivered","channel":"email"}`},
+		{name: "late bounce of earlier channel", event: `{"message_id":"m1","status":"bounced","bounce_type":"hard","channel":"email"}`},
+		{name: "event without channel", event: `{"message_id":"m1","status":"failed"}`},
+		{name: "current channel delivered", event: `{"message_id":"m1","status":"delivered","channel":"sms"}`, wantResolved: true},
+		{name: "current channel failed", event: `{"message_id":"m1","status":"failed","channel":"sms"}`, wantTaken: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &channelStore{channel: "sms"}
+			w := &Watcher{Store: store}
+			if err := w.handle(context.Background(), []byte(tt.event)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if store.resolved != tt.wantResolved || store.taken != tt.wantTaken {
+				t.Fatalf("resolved=%v taken=%v, want %v/%v", store.resolved, store.taken, tt.wantResolved, tt.wantTaken)
+			}
+		})
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rs/zerolog"
@@ -33,26 +32,27 @@ var (
 )
 
 type Handler struct {
-	repo     MessageRepository
-	producer *kafka.Writer
-	cfg      *common.Config
-	tracer   trace.Tracer
-	logger   zerolog.Logger
+	repo    MessageRepository
+	service *Service
+	cfg     *common.Config
+	tracer  trace.Tracer
+	logger  zerolog.Logger
 }
 
 func NewHandler(repo MessageRepository, producer *kafka.Writer, cfg *common.Config, logger zerolog.Logger) *Handler {
 	return &Handler{
-		repo:     repo,
-		producer: producer,
-		cfg:      cfg,
-		tracer:   otel.Tracer("ingestion"),
-		logger:   logger,
+		repo:    repo,
+		service: NewService(repo, producer),
+		cfg:     cfg,
+		tracer:  otel.Tracer("ingestion"),
+		logger:  logger,
 	}
 }
 
 func (h *Handler) Router() http.Handler {
 	r := chi.NewRouter()
 	r.Post("/v1/notify", h.notify)
+	r.Get("/v1/messages/{id}", h.getMessage)
 	return r
 }
 
@@ -76,72 +76,41 @@ func (h *Handler) notify(w http.ResponseWriter, r *http.Request) {
 		h.respondErr(ctx, w, http.StatusBadRequest, err)
 		return
 	}
-	if err := validateRequest(req); err != nil {
-		h.respondErr(ctx, w, http.StatusBadRequest, err)
-		return
-	}
-
 	start := time.Now()
-
-	msg := Message{
-		ID:         uuid.NewString(),
-		TenantID:   tenantID,
-		MessageKey: idempotencyKey,
-		Channel:    req.Channel,
-		TemplateID: req.TemplateID,
-		Payload: map[string]any{
-			"to":      req.To,
-			"data":    req.Data,
-			"options": req.Options,
-		},
-		Status:    "queued",
-		CreatedAt: time.Now().UTC(),
-	}
-
-	saved, duplicate, err := h.repo.CreateMessage(ctx, msg)
+	result, err := h.service.Notify(ctx, tenantID, idempotencyKey, req)
 	if err != nil {
-		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		status := http.StatusInternalServerError
+		var invalid *ValidationError
+		if errors.As(err, &invalid) {
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, ErrKeyConflict) {
+			status = http.StatusConflict
+		}
+		h.respondErr(ctx, w, status, err)
 		return
 	}
-	msg = saved
-
-	reqCounter.WithLabelValues(statusLabel(duplicate), string(req.Channel)).Inc()
-	requestLatency.WithLabelValues(string(req.Channel)).Observe(time.Since(start).Seconds())
 
-	if duplicate {
-		w.WriteHeader(http.StatusConflict)
-		_ = json.NewEncoder(w).Encode(map[string]any{
-			"message_id": msg.ID,
-			"status":     "duplicate",
-		})
-		return
+	channel := string(req.Channel)
+	if len(req.Channels) > 0 {
+		channel = string(ChannelMulti)
 	}
+	reqCounter.WithLabelValues(statusLabel(result.Duplicate), channel).Inc()
+	requestLatency.WithLabelValues(channel).Observe(time.Since(start).Seconds())
+	span.SetAttributes(attribute.String("message.id", result.MessageID))
 
-	event := map[string]any{
-		"message_id":  msg.ID,
-		"tenant_id":   msg.TenantID,
-		"channel":     msg.Channel,
-		"payload":     msg.Payload,
-		"template_id": msg.TemplateID,
-		"created_at":  msg.CreatedAt,
+	resp := map[string]any{"message_id": result.MessageID}
+	if len(result.Children) > 0 {
+		resp["children"] = result.Children
 	}
-	payload, err := json.Marshal(event)
-	if err != nil {
-		h.respondErr(ctx, w, http.StatusInternalServerError, err)
-		return
-	}
-	span.SetAttributes(attribute.String("message.id", msg.ID))
-
-	if err := h.producer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(msg.TenantID + ":" + msg.MessageKey),
-		Value: payload,
-	}); err != nil {
-		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+	if result.Duplicate {
+		resp["status"] = "duplicate"
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(resp)
 		return
 	}
-
 	w.WriteHeader(http.StatusAccepted)
-	_ = json.NewEncoder(w).Encode(map[string]any{"message_id": msg.ID})
+	_ = json.NewEncoder(w).Encode(resp)
 }
 
 func (h *Handler) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
@@ -157,16 +126,3 @@ func statusLabel(duplicate bool) string {
 	}
 	return "accepted"
 }
-
-func validateRequest(req NotifyRequest) error {
-	if req.Channel == "" {
-		return errors.New("channel is required")
-	}
-	if req.TemplateID == "" {
-		return errors.New("template_id is required")
-	}
-	if len(req.To) == 0 {
-		return errors.New("to is required")
-	}
-	return nil
-}
@@ -27,6 +27,87 @@ func TestValidateRequest(t *testing.T) {
 			request: NotifyRequest{Channel: ChannelEmail, TemplateID: "tpl"},
 			wantErr: true,
 		},
+		{
+			name:    "critical with timezone",
+			request: NotifyRequest{Channel: ChannelEmail, TemplateID: "tpl", To: map[string]any{"email": "a@b.com"}, Priority: PriorityCritical, Timezone: "UTC"},
+		},
+		{
+			name:    "unknown priority",
+			request: NotifyRequest{Channel: ChannelEmail, TemplateID: "tpl", To: map[string]any{"email": "a@b.com"}, Priority: "urgent"},
+			wantErr: true,
+		},
+		{
+			name: "fallback chain",
+			request: NotifyRequest{Channel: ChannelSMS, TemplateID: "tpl", To: map[string]any{"phone": "+15550100"},
+				Fallback: []FallbackStep{{Channel: ChannelEmail, To: map[string]any{"email": "a@b.com"}}}},
+		},
+		{
+			name: "fallback repeats channel",
+			request: NotifyRequest{Channel: ChannelSMS, TemplateID: "tpl", To: map[string]any{"phone": "+15550100"},
+				Fallback: []FallbackStep{{Channel: ChannelSMS, To: map[string]any{"phone": "+15550101"}}}},
+			wantErr: true,
+		},
+		{
+			name: "fallback missing recipient",
+			request: NotifyRequest{Channel: ChannelPush, TemplateID: "tpl", To: map[string]any{"push_token": "tok"},
+				Fallback: []FallbackStep{{Channel: ChannelSMS}}},
+			wantErr: true,
+		},
+		{
+			name: "fan-out",
+			request: NotifyRequest{TemplateID: "tpl", Channels: []ChannelTarget{
+				{Channel: ChannelEmail, To: map[string]any{"email": "a@b.com"}},
+				{Channel: ChannelPush, To: map[string]any{"push_token": "tok"}},
+				{Channel: ChannelInApp, To: map[string]any{"user_id": "u1"}, TemplateID: "tpl-inapp"},
+			}},
+		},
+		{
+			name: "fan-out with channel",
+			request: NotifyRequest{Channel: ChannelEmail, TemplateID: "tpl", Channels: []ChannelTarget{
+				{Channel: ChannelPush, To: map[string]any{"push_token": "tok"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "fan-out repeats channel",
+			request: NotifyRequest{TemplateID: "tpl", Channels: []ChannelTarget{
+				{Channel: ChannelEmail, To: map[string]any{"email": "a@b.com"}},
+				{Channel: ChannelEmail, To: map[string]any{"email": "c@d.com"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "fan-out missing template",
+			request: NotifyRequest{Channels: []ChannelTarget{
+				{Channel: ChannelEmail, To: map[string]any{"email": "a@b.com"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "digest",
+			request: NotifyRequest{Channel: ChannelEmail, TemplateID: "tpl", To: map[string]any{"email": "a@b.com"}, DigestKey: "comments", DigestWindowSeconds: 3600, DigestTemplateID: "tpl-digest"},
+		},
+		{
+			name:    "digest without template",
+			request: NotifyRequest{Channel: ChannelEmail, TemplateID: "tpl", To: map[string]any{"email": "a@b.com"}, DigestKey: "comments"},
+			wantErr: true,
+		},
+		{
+			name:    "critical digest",
+			request: NotifyRequest{Channel: ChannelEmail, TemplateID: "tpl", To: map[string]any{"email": "a@b.com"}, DigestKey: "comments", DigestTemplateID: "tpl-digest", Priority: PriorityCritical},
+			wantErr: true,
+		},
+		{
+			name: "digest with fallback",
+			request: NotifyRequest{Channel: ChannelEmail, TemplateID: "tpl", To: map[string]any{"email": "a@b.com"}, DigestKey: "comments", DigestTemplateID: "tpl-digest",
+				Fallback: []FallbackStep{{Channel: ChannelSMS, To: map[string]any{"phone": "+15550100"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid timezone",
+			request: NotifyRequest{Channel: ChannelEmail, TemplateID: "tpl", To: map[string]any{"email": "a@b.com"}, Timezone: "Mars/Olympus"},
+			wantErr: true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -41,3 +122,33 @@ func TestValidateRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregateStatus(t *testing.T) {
+	children := func(statuses ...string) []Message {
+		msgs := make([]Message, 0, len(statuses))
+		for _, s := range statuses {
+			msgs = append(msgs, Message{Status: s})
+		}
+		return msgs
+	}
+	tests := []struct {
+		name     string
+		children []Message
+		want     string
+	}{
+		{"no children", nil, ""},
+		{"all queued", children("queued", "queued"), "queued"},
+		{"all delivered", children("delivered", "opened"), "delivered"},
+		{"some pending", children("delivered", "sent"), "in_progress"},
+		{"partial", children("delivered", "bounced"), "partially_delivered"},
+		{"all failed", children("failed", "bounced"), "failed"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := AggregateStatus(tc.children); got != tc.want {
+				t.Fatalf("AggregateStatus()=%q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package ingest
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -12,16 +13,72 @@ const (
 	ChannelSMS      Channel = "sms"
 	ChannelPush     Channel = "push"
 	ChannelWhatsApp Channel = "whatsapp"
+	ChannelInApp    Channel = "in_app"
+	// ChannelMulti marks the parent record of a fan-out request. It is never
+	// dispatched itself; its children carry the real channels.
+	ChannelMulti Channel = "multi"
+)
+
+const (
+	PriorityCritical = "critical"
+	PriorityHigh     = "high"
+	PriorityNormal   = "normal"
+	PriorityBulk     = "bulk"
 )
 
 type NotifyRequest struct {
+	// Channel and To address a single channel. Channels replaces them to
+	// send one notification on several channels at once. To holds channel
+	// addresses or {"user_id": ...}, resolved from the recipient profile.
 	Channel    Channel        `json:"channel"`
 	To         map[string]any `json:"to"`
 	TemplateID string         `json:"template_id"`
 	Data       map[string]any `json:"data"`
 	Options    map[string]any `json:"options"`
+	// Locale selects the template variant, e.g. "pt-BR".
+	Locale string `json:"locale,omitempty"`
+	// Category groups messages for recipient preferences, e.g. "marketing".
+	// Uncategorised messages are only suppressed by a global opt-out.
+	Category string `json:"category,omitempty"`
+	// Priority is one of critical, high, normal (default) or bulk. Critical
+	// messages bypass quiet hours.
+	Priority string `json:"priority,omitempty"`
+	// Timezone is the recipient's IANA zone used to evaluate quiet hours.
+	Timezone string `json:"timezone,omitempty"`
+	// Fallback lists channels to try in order if Channel fails permanently
+	// or does not report delivery within FallbackTimeoutSeconds.
+	Fallback               []FallbackStep  `json:"fallback,omitempty"`
+	FallbackTimeoutSeconds int             `json:"fallback_timeout_seconds,omitempty"`
+	Channels               []ChannelTarget `json:"channels,omitempty"`
+	// DigestKey buffers messages with the same tenant, recipient and key for
+	// DigestWindowSeconds (default one hour) and sends them as one digest,
+	// rendered with DigestTemplateID, which is then required.
+	DigestKey           string `json:"digest_key,omitempty"`
+	DigestWindowSeconds int    `json:"digest_window_seconds,omitempty"`
+	DigestTemplateID    string `json:"digest_template_id,omitempty"`
+}
+
+// ChannelTarget is one channel of a fan-out request.
+type ChannelTarget struct {
+	Channel Channel        `json:"channel"`
+	To      map[string]any `json:"to"`
+	// TemplateID defaults to the request's template.
+	TemplateID string `json:"template_id,omitempty"`
 }
 
+type FallbackStep struct {
+	Channel Channel        `json:"channel"`
+	To      map[string]any `json:"to"`
+	// TemplateID defaults to the request's template.
+	TemplateID string `json:"template_id,omitempty"`
+}
+
+var ErrNotFound = errors.New("message not found")
+
+// ErrKeyConflict reports a fan-out child key already taken by another
+// message, e.g. an earlier single-channel request sent with key:channel.
+var ErrKeyConflict = errors.New("idempotency key conflicts with an existing message")
+
 type Message struct {
 	ID         string
 	TenantID   string
@@ -31,8 +88,21 @@ type Message struct {
 	TemplateID string
 	Status     string
 	CreatedAt  time.Time
+	// ParentID links a fan-out child to its ChannelMulti parent.
+	ParentID string
+	// Published is set once the message has been written to Kafka.
+	Published bool
 }
 
 type MessageRepository interface {
 	CreateMessage(ctx context.Context, msg Message) (Message, bool, error)
+	// CreateFanout stores a parent and its children atomically. On a
+	// duplicate idempotency key it returns the existing parent and children;
+	// a child key already used outside the fan-out fails with ErrKeyConflict.
+	CreateFanout(ctx context.Context, parent Message, children []Message) (Message, []Message, bool, error)
+	// MarkPublished records that the message reached Kafka, so a retried
+	// fan-out only republishes the children that did not.
+	MarkPublished(ctx context.Context, id string) error
+	GetMessage(ctx context.Context, tenantID, id string) (Message, error)
+	ListChildren(ctx context.Context, tenantID, parentID string) ([]Message, error)
 }
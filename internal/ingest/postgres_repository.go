@@ -5,12 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const messageColumns = `id, tenant_id, message_key, channel, payload_json, template_id, status, created_at, coalesce(parent_id, ''), published_at IS NOT NULL`
+
 const insertMessage = `
 INSERT INTO messages (
 id,
@@ -20,18 +21,44 @@ channel,
 payload_json,
 template_id,
 status,
-created_at
-) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+created_at,
+parent_id
+) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,NULLIF($9, ''))
 ON CONFLICT (tenant_id, message_key) DO NOTHING
-RETURNING id, tenant_id, message_key, channel, payload_json, template_id, status, created_at
-`
+RETURNING ` + messageColumns
 
 const selectMessage = `
-SELECT id, tenant_id, message_key, channel, payload_json, template_id, status, created_at
+SELECT ` + messageColumns + `
 FROM messages
 WHERE tenant_id = $1 AND message_key = $2
 `
 
+const selectMessageByID = `
+SELECT ` + messageColumns + `
+FROM messages
+WHERE tenant_id = $1 AND id = $2
+`
+
+const selectChildren = `
+SELECT ` + messageColumns + `
+FROM messages
+WHERE tenant_id = $1 AND parent_id = $2
+ORDER BY created_at, channel
+`
+
+const markPublished = `
+UPDATE messages SET published_at = now()
+WHERE id = $1 AND published_at IS NULL
+`
+
+// updateStatus only moves forward: $3 is StatusProgression, and a status
+// not in it never matches.
+const updateStatus = `
+UPDATE messages SET status = $2
+WHERE id = $1
+AND coalesce(array_position($3::text[], status), 0) < array_position($3::text[], $2)
+`
+
 type PostgresRepository struct {
 	pool *pgxpool.Pool
 }
@@ -40,13 +67,87 @@ func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
 	return &PostgresRepository{pool: pool}
 }
 
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
 func (r *PostgresRepository) CreateMessage(ctx context.Context, msg Message) (Message, bool, error) {
+	return createMessage(ctx, r.pool, msg)
+}
+
+func (r *PostgresRepository) CreateFanout(ctx context.Context, parent Message, children []Message) (Message, []Message, bool, error) {
+	var (
+		saved     Message
+		savedKids []Message
+		duplicate bool
+	)
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		saved, duplicate, err = createMessage(ctx, tx, parent)
+		if err != nil {
+			return err
+		}
+		if duplicate {
+			savedKids, err = listChildren(ctx, tx, saved.TenantID, saved.ID)
+			return err
+		}
+		for _, child := range children {
+			child.ParentID = saved.ID
+			created, taken, err := createMessage(ctx, tx, child)
+			if err != nil {
+				return err
+			}
+			if taken {
+				return fmt.Errorf("fan-out child %q: %w", child.MessageKey, ErrKeyConflict)
+			}
+			savedKids = append(savedKids, created)
+		}
+		return nil
+	})
+	if err != nil {
+		return Message{}, nil, false, err
+	}
+	return saved, savedKids, duplicate, nil
+}
+
+func (r *PostgresRepository) GetMessage(ctx context.Context, tenantID, id string) (Message, error) {
+	msg, err := scanMessage(r.pool.QueryRow(ctx, selectMessageByID, tenantID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Message{}, ErrNotFound
+	}
+	if err != nil {
+		return Message{}, fmt.Errorf("get message: %w", err)
+	}
+	return msg, nil
+}
+
+func (r *PostgresRepository) ListChildren(ctx context.Context, tenantID, parentID string) ([]Message, error) {
+	return listChildren(ctx, r.pool, tenantID, parentID)
+}
+
+func (r *PostgresRepository) MarkPublished(ctx context.Context, id string) error {
+	if _, err := r.pool.Exec(ctx, markPublished, id); err != nil {
+		return fmt.Errorf("mark message published: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	if _, err := r.pool.Exec(ctx, updateStatus, id, status, StatusProgression); err != nil {
+		return fmt.Errorf("update message status: %w", err)
+	}
+	return nil
+}
+
+func createMessage(ctx context.Context, q querier, msg Message) (Message, bool, error) {
 	payload, err := json.Marshal(msg.Payload)
 	if err != nil {
 		return Message{}, false, err
 	}
 
-	row := r.pool.QueryRow(ctx, insertMessage,
+	row := q.QueryRow(ctx, insertMessage,
 		msg.ID,
 		msg.TenantID,
 		msg.MessageKey,
@@ -55,47 +156,57 @@ func (r *PostgresRepository) CreateMessage(ctx context.Context, msg Message) (Me
 		msg.TemplateID,
 		msg.Status,
 		msg.CreatedAt,
-	)
-
-	var (
-		id          string
-		tenantID    string
-		messageKey  string
-		channel     string
-		payloadJSON []byte
-		templateID  string
-		status      string
-		createdAt   time.Time
+		msg.ParentID,
 	)
 
 	inserted := true
-	if err := row.Scan(&id, &tenantID, &messageKey, &channel, &payloadJSON, &templateID, &status, &createdAt); err != nil {
+	saved, err := scanMessage(row)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			inserted = false
-			row = r.pool.QueryRow(ctx, selectMessage, msg.TenantID, msg.MessageKey)
-			if err := row.Scan(&id, &tenantID, &messageKey, &channel, &payloadJSON, &templateID, &status, &createdAt); err != nil {
+			saved, err = scanMessage(q.QueryRow(ctx, selectMessage, msg.TenantID, msg.MessageKey))
+			if err != nil {
 				return Message{}, false, fmt.Errorf("fetch existing message: %w", err)
 			}
 		} else {
 			return Message{}, false, fmt.Errorf("insert message: %w", err)
 		}
 	}
+	return saved, !inserted, nil
+}
 
-	var payloadMap map[string]any
-	if err := json.Unmarshal(payloadJSON, &payloadMap); err != nil {
-		return Message{}, false, err
+func listChildren(ctx context.Context, q querier, tenantID, parentID string) ([]Message, error) {
+	rows, err := q.Query(ctx, selectChildren, tenantID, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("list child messages: %w", err)
+	}
+	defer rows.Close()
+
+	var children []Message
+	for rows.Next() {
+		child, err := scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan child message: %w", err)
+		}
+		children = append(children, child)
 	}
+	return children, rows.Err()
+}
 
-	return Message{
-		ID:         id,
-		TenantID:   tenantID,
-		MessageKey: messageKey,
-		Channel:    Channel(channel),
-		Payload:    payloadMap,
-		TemplateID: templateID,
-		Status:     status,
-		CreatedAt:  createdAt,
-	}, !inserted, nil
+func scanMessage(row pgx.Row) (Message, error) {
+	var (
+		msg         Message
+		channel     string
+		payloadJSON []byte
+	)
+	if err := row.Scan(&msg.ID, &msg.TenantID, &msg.MessageKey, &channel, &payloadJSON, &msg.TemplateID, &msg.Status, &msg.CreatedAt, &msg.ParentID, &msg.Published); err != nil {
+		return Message{}, err
+	}
+	msg.Channel = Channel(channel)
+	if err := json.Unmarshal(payloadJSON, &msg.Payload); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
 }
 
 var ErrNotConfigured = errors.New("postgres repository requires a non-nil pool")
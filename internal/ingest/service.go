@@ -0,0 +1,273 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// Service is the ingestion path shared by the notify API and internal
+// producers such as campaigns: it stores each message under its idempotency
+// key and publishes it to the notifications topic.
+type Service struct {
+	repo     MessageRepository
+	producer *kafka.Writer
+}
+
+func NewService(repo MessageRepository, producer *kafka.Writer) *Service {
+	return &Service{repo: repo, producer: producer}
+}
+
+// ValidationError reports a request the caller must fix.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string { return e.Err.Error() }
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+type ChildRef struct {
+	MessageID string  `json:"message_id"`
+	Channel   Channel `json:"channel"`
+}
+
+type NotifyResult struct {
+	MessageID string
+	// Duplicate is set when the idempotency key was already used and
+	// MessageID is the original message. Nothing is published, except
+	// fan-out children an earlier attempt failed to publish.
+	Duplicate bool
+	Children  []ChildRef
+}
+
+func (s *Service) Notify(ctx context.Context, tenantID, idempotencyKey string, req NotifyRequest) (NotifyResult, error) {
+	if err := validateRequest(req); err != nil {
+		return NotifyResult{}, &ValidationError{Err: err}
+	}
+	if len(req.Channels) > 0 {
+		return s.notifyFanout(ctx, tenantID, idempotencyKey, req)
+	}
+
+	msg := Message{
+		ID:         uuid.NewString(),
+		TenantID:   tenantID,
+		MessageKey: idempotencyKey,
+		Channel:    req.Channel,
+		TemplateID: req.TemplateID,
+		Payload:    requestPayload(req, req.To),
+		Status:     "queued",
+		CreatedAt:  time.Now().UTC(),
+	}
+	if len(req.Fallback) > 0 {
+		msg.Payload["fallback"] = req.Fallback
+		if req.FallbackTimeoutSeconds > 0 {
+			msg.Payload["fallback_timeout_seconds"] = req.FallbackTimeoutSeconds
+		}
+	}
+
+	saved, duplicate, err := s.repo.CreateMessage(ctx, msg)
+	if err != nil {
+		return NotifyResult{}, err
+	}
+	if duplicate {
+		return NotifyResult{MessageID: saved.ID, Duplicate: true}, nil
+	}
+	if err := s.publish(ctx, saved, req.Priority); err != nil {
+		return NotifyResult{}, err
+	}
+	return NotifyResult{MessageID: saved.ID}, nil
+}
+
+// notifyFanout stores a ChannelMulti parent with one child per channel and
+// dispatches the children independently; each has its own message id and
+// status, and the parent's status is aggregated on read.
+func (s *Service) notifyFanout(ctx context.Context, tenantID, idempotencyKey string, req NotifyRequest) (NotifyResult, error) {
+	now := time.Now().UTC()
+	parent := Message{
+		ID:         uuid.NewString(),
+		TenantID:   tenantID,
+		MessageKey: idempotencyKey,
+		Channel:    ChannelMulti,
+		TemplateID: req.TemplateID,
+		Payload:    requestPayload(req, nil),
+		Status:     "queued",
+		CreatedAt:  now,
+	}
+	parent.Payload["channels"] = req.Channels
+	children := make([]Message, 0, len(req.Channels))
+	for _, target := range req.Channels {
+		templateID := target.TemplateID
+		if templateID == "" {
+			templateID = req.TemplateID
+		}
+		children = append(children, Message{
+			ID:         uuid.NewString(),
+			TenantID:   tenantID,
+			MessageKey: idempotencyKey + ":" + string(target.Channel),
+			Channel:    target.Channel,
+			TemplateID: templateID,
+			Payload:    requestPayload(req, target.To),
+			Status:     "queued",
+			CreatedAt:  now,
+		})
+	}
+
+	parent, children, duplicate, err := s.repo.CreateFanout(ctx, parent, children)
+	if err != nil {
+		return NotifyResult{}, err
+	}
+	result := NotifyResult{MessageID: parent.ID, Duplicate: duplicate}
+	for _, child := range children {
+		result.Children = append(result.Children, ChildRef{MessageID: child.ID, Channel: child.Channel})
+	}
+	// A retry after a partial publish still reports the duplicate but
+	// sends the children the first attempt did not get to.
+	for _, child := range children {
+		if child.Published {
+			continue
+		}
+		if err := s.publish(ctx, child, req.Priority); err != nil {
+			return NotifyResult{}, err
+		}
+		if err := s.repo.MarkPublished(ctx, child.ID); err != nil {
+			return NotifyResult{}, err
+		}
+	}
+	return result, nil
+}
+
+func requestPayload(req NotifyRequest, to map[string]any) map[string]any {
+	payload := map[string]any{
+		"to":       to,
+		"data":     req.Data,
+		"options":  req.Options,
+		"locale":   req.Locale,
+		"category": req.Category,
+		"timezone": req.Timezone,
+	}
+	if req.DigestKey != "" {
+		payload["digest_key"] = req.DigestKey
+		if req.DigestWindowSeconds > 0 {
+			payload["digest_window_seconds"] = req.DigestWindowSeconds
+		}
+		if req.DigestTemplateID != "" {
+			payload["digest_template_id"] = req.DigestTemplateID
+		}
+	}
+	return payload
+}
+
+func (s *Service) publish(ctx context.Context, msg Message, priority string) error {
+	event := map[string]any{
+		"message_id":  msg.ID,
+		"tenant_id":   msg.TenantID,
+		"channel":     msg.Channel,
+		"payload":     msg.Payload,
+		"template_id": msg.TemplateID,
+		"priority":    priority,
+		"created_at":  msg.CreatedAt,
+	}
+	if msg.ParentID != "" {
+		event["metadata"] = map[string]any{"parent_id": msg.ParentID}
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.producer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(msg.TenantID + ":" + msg.MessageKey),
+		Value: payload,
+	})
+}
+
+func validateRequest(req NotifyRequest) error {
+	if len(req.Channels) > 0 {
+		if err := validateFanout(req); err != nil {
+			return err
+		}
+	} else {
+		if req.Channel == "" {
+			return errors.New("channel is required")
+		}
+		if req.TemplateID == "" {
+			return errors.New("template_id is required")
+		}
+		if len(req.To) == 0 {
+			return errors.New("to is required")
+		}
+	}
+	switch req.Priority {
+	case "", PriorityCritical, PriorityHigh, PriorityNormal, PriorityBulk:
+	default:
+		return fmt.Errorf("unknown priority %q", req.Priority)
+	}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q", req.Timezone)
+		}
+	}
+	seen := map[Channel]bool{req.Channel: true}
+	for i, step := range req.Fallback {
+		if step.Channel == "" {
+			return fmt.Errorf("fallback[%d]: channel is required", i)
+		}
+		if seen[step.Channel] {
+			return fmt.Errorf("fallback[%d]: channel %q is already in the chain", i, step.Channel)
+		}
+		seen[step.Channel] = true
+		if len(step.To) == 0 {
+			return fmt.Errorf("fallback[%d]: to is required", i)
+		}
+	}
+	if req.FallbackTimeoutSeconds < 0 {
+		return errors.New("fallback_timeout_seconds must not be negative")
+	}
+	if req.DigestWindowSeconds < 0 {
+		return errors.New("digest_window_seconds must not be negative")
+	}
+	// Digests render {digest_key, count, items}, which the per-message
+	// template cannot.
+	if req.DigestKey != "" && req.DigestTemplateID == "" {
+		return errors.New("digest_template_id is required with digest_key")
+	}
+	if req.DigestKey != "" && req.Priority == PriorityCritical {
+		return errors.New("critical messages cannot be digested")
+	}
+	// A digest goes out under a new message id once its window closes, long
+	// after the fallback deadline, so the chain would always fall through.
+	if req.DigestKey != "" && len(req.Fallback) > 0 {
+		return errors.New("fallback cannot be combined with digest_key")
+	}
+	return nil
+}
+
+func validateFanout(req NotifyRequest) error {
+	if req.Channel != "" || len(req.To) > 0 {
+		return errors.New("channels cannot be combined with channel and to")
+	}
+	if len(req.Fallback) > 0 {
+		return errors.New("fallback is not supported with channels")
+	}
+	seen := map[Channel]bool{}
+	for i, target := range req.Channels {
+		if target.Channel == "" {
+			return fmt.Errorf("channels[%d]: channel is required", i)
+		}
+		if seen[target.Channel] {
+			return fmt.Errorf("channels[%d]: channel %q is listed twice", i, target.Channel)
+		}
+		seen[target.Channel] = true
+		if len(target.To) == 0 {
+			return fmt.Errorf("channels[%d]: to is required", i)
+		}
+		if target.TemplateID == "" && req.TemplateID == "" {
+			return fmt.Errorf("channels[%d]: template_id is required", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+// StatusProgression orders message statuses. A status only replaces one
+// earlier in the list, so late or replayed events cannot move a message
+// backwards (a "sent" arriving after "delivered" is ignored).
+var StatusProgression = []string{
+	common.StatusQueued, common.StatusDeferred, common.StatusSent,
+	common.StatusFailed, common.StatusSuppressed, common.StatusBounced,
+	common.StatusDelivered, common.StatusOpened, common.StatusClicked, common.StatusComplained,
+}
+
+func isDelivered(status string) bool {
+	switch status {
+	case "delivered", "opened", "clicked", "complained":
+		return true
+	}
+	return false
+}
+
+func isPending(status string) bool {
+	switch status {
+	case "queued", "deferred", "sent":
+		return true
+	}
+	return false
+}
+
+// AggregateStatus summarises a fan-out's children: their shared status if
+// they agree, otherwise in_progress while any is pending, then
+// partially_delivered or failed.
+func AggregateStatus(children []Message) string {
+	if len(children) == 0 {
+		return ""
+	}
+	same, delivered, pending := true, 0, false
+	for _, c := range children {
+		if c.Status != children[0].Status {
+			same = false
+		}
+		if isDelivered(c.Status) {
+			delivered++
+		}
+		if isPending(c.Status) {
+			pending = true
+		}
+	}
+	switch {
+	case same:
+		return children[0].Status
+	case delivered == len(children):
+		return "delivered"
+	case pending:
+		return "in_progress"
+	case delivered > 0:
+		return "partially_delivered"
+	}
+	return "failed"
+}
+
+type messageView struct {
+	MessageID  string        `json:"message_id"`
+	ParentID   string        `json:"parent_id,omitempty"`
+	Channel    Channel       `json:"channel"`
+	TemplateID string        `json:"template_id"`
+	Status     string        `json:"status"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Children   []messageView `json:"children,omitempty"`
+}
+
+func viewOf(msg Message) messageView {
+	return messageView{
+		MessageID:  msg.ID,
+		ParentID:   msg.ParentID,
+		Channel:    msg.Channel,
+		TemplateID: msg.TemplateID,
+		Status:     msg.Status,
+		CreatedAt:  msg.CreatedAt,
+	}
+}
+
+func (h *Handler) getMessage(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "get-message")
+	defer span.End()
+
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	msg, err := h.repo.GetMessage(ctx, tenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		h.respondErr(ctx, w, status, err)
+		return
+	}
+
+	view := viewOf(msg)
+	if msg.Channel == ChannelMulti {
+		children, err := h.repo.ListChildren(ctx, tenantID, msg.ID)
+		if err != nil {
+			h.respondErr(ctx, w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, child := range children {
+			view.Children = append(view.Children, viewOf(child))
+		}
+		view.Status = AggregateStatus(children)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(view)
+}
+
+type StatusRepository interface {
+	// UpdateStatus moves a message forward along StatusProgression.
+	UpdateStatus(ctx context.Context, id, status string) error
+}
+
+// StatusUpdater keeps messages.status current from provider.events.
+type StatusUpdater struct {
+	ReaderFactory func() *kafka.Reader
+	Repo          StatusRepository
+	Logger        zerolog.Logger
+}
+
+func (u *StatusUpdater) Run(ctx context.Context) error {
+	if u.ReaderFactory == nil || u.Repo == nil {
+		return errors.New("status updater requires a reader factory and repository")
+	}
+	reader := u.ReaderFactory()
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch event: %w", err)
+		}
+		var event struct {
+			MessageID string `json:"message_id"`
+			Status    string `json:"status"`
+		}
+		if err := json.Unmarshal(m.Value, &event); err != nil {
+			u.Logger.Warn().Err(err).Msg("failed to decode provider event")
+		} else if event.MessageID != "" && common.IsStatus(event.Status) {
+			if err := u.Repo.UpdateStatus(ctx, event.MessageID, event.Status); err != nil {
+				return err
+			}
+		}
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			return fmt.Errorf("commit event: %w", err)
+		}
+	}
+}
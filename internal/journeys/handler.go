@@ -0,0 +1,160 @@
+package journeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+type Handler struct {
+	repo   Repository
+	logger zerolog.Logger
+}
+
+func NewHandler(repo Repository, logger zerolog.Logger) *Handler {
+	return &Handler{repo: repo, logger: logger}
+}
+
+func (h *Handler) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/v1/journeys", h.list)
+	r.Post("/v1/journeys", h.create)
+	r.Get("/v1/journeys/{id}", h.get)
+	r.Post("/v1/journeys/{id}/enrollments", h.enroll)
+	r.Get("/v1/journeys/{id}/enrollments/{user_id}", h.getEnrollment)
+	return r
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	journeys, err := h.repo.ListJourneys(ctx, tenantID)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"journeys": journeys})
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var j Journey
+	if err := json.NewDecoder(r.Body).Decode(&j); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	j.TenantID = tenantID
+	if err := j.Validate(); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	saved, err := h.repo.CreateJourney(ctx, j)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(saved)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	j, err := h.repo.GetJourney(ctx, tenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(j)
+}
+
+type enrollRequest struct {
+	UserID string         `json:"user_id"`
+	Data   map[string]any `json:"data"`
+}
+
+func (h *Handler) enroll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var req enrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	if req.UserID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("user_id is required"))
+		return
+	}
+	j, err := h.repo.GetJourney(ctx, tenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	e, err := h.repo.Enroll(ctx, j.enrollment(req.UserID, req.Data))
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	triggeredEnrollments.WithLabelValues("api").Inc()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(e)
+}
+
+func (h *Handler) getEnrollment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	e, err := h.repo.GetEnrollment(ctx, tenantID, chi.URLParam(r, "id"), chi.URLParam(r, "user_id"))
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(e)
+}
+
+func statusForErr(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadyExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
+	logger := common.WithContext(ctx, h.logger)
+	logger.Error().Err(err).Int("status", status).Msg("journeys handler failed")
+	http.Error(w, err.Error(), status)
+}
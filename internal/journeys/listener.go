@@ -0,0 +1,114 @@
+package journeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/ingest"
+)
+
+var triggeredEnrollments = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "journey_enrollments_total",
+	Help: "Journey enrollments, by source",
+}, []string{"source"})
+
+// MessageLookup finds the message a provider event refers to, so a trigger
+// can enroll the user it was addressed to.
+type MessageLookup interface {
+	GetMessage(ctx context.Context, tenantID, id string) (ingest.Message, error)
+}
+
+type event struct {
+	MessageID  string `json:"message_id"`
+	TenantID   string `json:"tenant_id"`
+	Status     string `json:"status"`
+	TemplateID string `json:"template_id"`
+	Test       bool   `json:"test"`
+}
+
+// Listener consumes provider.events: every event is recorded for branch
+// steps, and events matching a journey trigger enroll the message's user.
+type Listener struct {
+	ReaderFactory func() *kafka.Reader
+	Store         Repository
+	Messages      MessageLookup
+	Logger        zerolog.Logger
+}
+
+func (l *Listener) Run(ctx context.Context) error {
+	if l.ReaderFactory == nil || l.Store == nil || l.Messages == nil {
+		return errors.New("journey listener requires a reader factory, store and message lookup")
+	}
+	reader := l.ReaderFactory()
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch message: %w", err)
+		}
+		var e event
+		if err := json.Unmarshal(m.Value, &e); err != nil {
+			l.Logger.Error().Err(err).Msg("failed to decode provider event")
+			_ = reader.CommitMessages(ctx, m)
+			continue
+		}
+		if err := l.handle(ctx, e); err != nil {
+			return err
+		}
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			return fmt.Errorf("commit message: %w", err)
+		}
+	}
+}
+
+func (l *Listener) handle(ctx context.Context, e event) error {
+	if e.MessageID == "" || e.Test {
+		return nil
+	}
+	status := e.Status
+	if !knownEvents[status] {
+		return nil
+	}
+	if err := l.Store.RecordEvent(ctx, e.MessageID, status); err != nil {
+		return err
+	}
+
+	journeys, err := l.Store.Triggered(ctx, e.TenantID, status)
+	if err != nil || len(journeys) == 0 {
+		return err
+	}
+	msg, err := l.Messages.GetMessage(ctx, e.TenantID, e.MessageID)
+	if errors.Is(err, ingest.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	to, _ := msg.Payload["to"].(map[string]any)
+	userID, _ := to["user_id"].(string)
+	if userID == "" {
+		return nil
+	}
+	for _, j := range journeys {
+		if j.Trigger.TemplateID != "" && j.Trigger.TemplateID != msg.TemplateID {
+			continue
+		}
+		_, err := l.Store.Enroll(ctx, j.enrollment(userID, map[string]any{"trigger_message_id": e.MessageID}))
+		if errors.Is(err, ErrAlreadyExists) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		triggeredEnrollments.WithLabelValues("event").Inc()
+	}
+	return nil
+}
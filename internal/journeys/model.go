@@ -0,0 +1,218 @@
+package journeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("user is already enrolled in this journey")
+)
+
+// Step types.
+const (
+	StepSend   = "send"
+	StepWait   = "wait"
+	StepBranch = "branch"
+	StepExit   = "exit"
+)
+
+// Enrollment statuses.
+const (
+	StatusActive    = "active"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Step is one node of a journey. Execution moves to Next, or to the step
+// that follows in the list when Next is empty.
+type Step struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Next string `json:"next,omitempty"`
+
+	// send: one message to the enrolled user through the ingestion path.
+	Channel    string         `json:"channel,omitempty"`
+	TemplateID string         `json:"template_id,omitempty"`
+	Data       map[string]any `json:"data,omitempty"`
+	Category   string         `json:"category,omitempty"`
+
+	// wait: pause for WaitSeconds.
+	WaitSeconds int `json:"wait_seconds,omitempty"`
+
+	// branch: wait up to WithinSeconds for Event on the message sent by the
+	// latest send step, then go to Then if it arrived or Else if not.
+	Event         string `json:"event,omitempty"`
+	WithinSeconds int    `json:"within_seconds,omitempty"`
+	Then          string `json:"then,omitempty"`
+	Else          string `json:"else,omitempty"`
+}
+
+// Trigger enrolls the user a message was addressed to (to.user_id) when a
+// provider event with Event arrives, optionally only for TemplateID.
+type Trigger struct {
+	Event      string `json:"event"`
+	TemplateID string `json:"template_id,omitempty"`
+}
+
+type Journey struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	Name      string    `json:"name"`
+	Trigger   *Trigger  `json:"trigger,omitempty"`
+	Steps     []Step    `json:"steps"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Enrollment is one user's position in a journey. A user is enrolled in a
+// journey at most once.
+type Enrollment struct {
+	ID        string         `json:"id"`
+	JourneyID string         `json:"journey_id"`
+	TenantID  string         `json:"tenant_id"`
+	UserID    string         `json:"user_id"`
+	StepID    string         `json:"step_id"`
+	Status    string         `json:"status"`
+	Reason    string         `json:"reason,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+	// LastMessageID is the message of the latest send step and Events the
+	// provider events seen for it, which branch steps test.
+	LastMessageID string   `json:"last_message_id,omitempty"`
+	Events        []string `json:"events,omitempty"`
+	// Sends counts the send steps executed so far. It numbers the send
+	// idempotency keys, so a journey that loops back to a send step sends
+	// again instead of getting the earlier message back.
+	Sends int `json:"sends"`
+	// Attempts counts consecutive failed sends; the runner retries with a
+	// growing delay and fails the enrollment after maxStepAttempts.
+	Attempts int `json:"attempts,omitempty"`
+	// WakeAt is set while the current wait or branch step is pending; an
+	// enrollment without one is due now. AwaitEvent, set by a pending
+	// branch, wakes the enrollment early when that event arrives.
+	WakeAt     *time.Time `json:"wake_at,omitempty"`
+	AwaitEvent string     `json:"await_event,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type Repository interface {
+	CreateJourney(ctx context.Context, j Journey) (Journey, error)
+	GetJourney(ctx context.Context, tenantID, id string) (Journey, error)
+	ListJourneys(ctx context.Context, tenantID string) ([]Journey, error)
+	// Triggered lists the tenant's journeys whose trigger is event.
+	Triggered(ctx context.Context, tenantID, event string) ([]Journey, error)
+
+	// Enroll returns ErrAlreadyExists if the user was enrolled before.
+	Enroll(ctx context.Context, e Enrollment) (Enrollment, error)
+	GetEnrollment(ctx context.Context, tenantID, journeyID, userID string) (Enrollment, error)
+	// RecordEvent notes a provider event on the enrollments whose last
+	// message is messageID, waking those awaiting it.
+	RecordEvent(ctx context.Context, messageID, event string) error
+	// Advance locks up to limit active enrollments due at now and saves what
+	// step returns for each, in one transaction. An enrollment whose step
+	// errors is left unchanged without holding back the others; the errors
+	// are returned joined.
+	Advance(ctx context.Context, now time.Time, limit int, step func(context.Context, Enrollment) (Enrollment, error)) (int, error)
+}
+
+// Events branch steps and triggers can name.
+var knownEvents = map[string]bool{
+	"sent": true, "delivered": true, "opened": true, "clicked": true, "bounced": true, "failed": true,
+}
+
+func (j Journey) Validate() error {
+	if j.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(j.Steps) == 0 {
+		return errors.New("at least one step is required")
+	}
+	if j.Trigger != nil && !knownEvents[j.Trigger.Event] {
+		return fmt.Errorf("trigger: unknown event %q", j.Trigger.Event)
+	}
+	ids := make(map[string]bool, len(j.Steps))
+	for i, s := range j.Steps {
+		if s.ID == "" {
+			return fmt.Errorf("steps[%d]: id is required", i)
+		}
+		if ids[s.ID] {
+			return fmt.Errorf("steps[%d]: duplicate id %q", i, s.ID)
+		}
+		ids[s.ID] = true
+	}
+	for i, s := range j.Steps {
+		switch s.Type {
+		case StepSend:
+			if s.Channel == "" || s.TemplateID == "" {
+				return fmt.Errorf("steps[%d]: send needs channel and template_id", i)
+			}
+		case StepWait:
+			if s.WaitSeconds <= 0 {
+				return fmt.Errorf("steps[%d]: wait needs wait_seconds", i)
+			}
+		case StepBranch:
+			if !knownEvents[s.Event] {
+				return fmt.Errorf("steps[%d]: unknown event %q", i, s.Event)
+			}
+			if s.WithinSeconds <= 0 {
+				return fmt.Errorf("steps[%d]: branch needs within_seconds", i)
+			}
+		case StepExit:
+		default:
+			return fmt.Errorf("steps[%d]: unknown type %q", i, s.Type)
+		}
+		for _, ref := range []string{s.Next, s.Then, s.Else} {
+			if ref != "" && !ids[ref] {
+				return fmt.Errorf("steps[%d]: unknown step %q", i, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// enrollment starts userID at the journey's first step.
+func (j Journey) enrollment(userID string, data map[string]any) Enrollment {
+	return Enrollment{
+		JourneyID: j.ID,
+		TenantID:  j.TenantID,
+		UserID:    userID,
+		StepID:    j.Steps[0].ID,
+		Status:    StatusActive,
+		Data:      data,
+	}
+}
+
+func (j Journey) step(id string) (Step, bool) {
+	for _, s := range j.Steps {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Step{}, false
+}
+
+// after returns the step following s: Next, else the next in the list. An
+// empty id ends the journey.
+func (j Journey) after(s Step) string {
+	if s.Next != "" {
+		return s.Next
+	}
+	for i, candidate := range j.Steps {
+		if candidate.ID == s.ID && i+1 < len(j.Steps) {
+			return j.Steps[i+1].ID
+		}
+	}
+	return ""
+}
+
+func hasEvent(events []string, event string) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,279 @@
+package journeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const journeyColumns = `id, tenant_id, name, trigger_json, steps_json, created_at`
+
+const insertJourney = `
+INSERT INTO journeys (id, tenant_id, name, trigger_event, trigger_json, steps_json, created_at)
+VALUES ($1,$2,$3,$4,$5,$6, now())
+RETURNING ` + journeyColumns
+
+const selectJourney = `
+SELECT ` + journeyColumns + `
+FROM journeys
+WHERE tenant_id = $1 AND id = $2
+`
+
+const selectJourneys = `
+SELECT ` + journeyColumns + `
+FROM journeys
+WHERE tenant_id = $1
+ORDER BY created_at
+`
+
+const selectTriggered = `
+SELECT ` + journeyColumns + `
+FROM journeys
+WHERE tenant_id = $1 AND trigger_event = $2
+`
+
+const enrollmentColumns = `id, journey_id, tenant_id, user_id, step_id, status, reason, data_json,
+last_message_id, events, sends, attempts, await_event, wake_at, created_at, updated_at`
+
+const insertEnrollment = `
+INSERT INTO journey_enrollments (id, journey_id, tenant_id, user_id, step_id, status, reason, data_json,
+last_message_id, events, sends, attempts, await_event, wake_at, created_at, updated_at)
+VALUES ($1,$2,$3,$4,$5,$6,'',$7,'','{}',0,0,'',NULL, now(), now())
+ON CONFLICT (journey_id, user_id) DO NOTHING
+RETURNING ` + enrollmentColumns
+
+const selectEnrollment = `
+SELECT ` + enrollmentColumns + `
+FROM journey_enrollments
+WHERE tenant_id = $1 AND journey_id = $2 AND user_id = $3
+`
+
+const recordEvent = `
+UPDATE journey_enrollments
+SET events = array_append(events, $2),
+wake_at = CASE WHEN await_event = $2 THEN now() ELSE wake_at END,
+updated_at = now()
+WHERE last_message_id = $1 AND status = 'active'
+`
+
+const claimEnrollments = `
+SELECT ` + enrollmentColumns + `
+FROM journey_enrollments
+WHERE status = 'active' AND (wake_at IS NULL OR wake_at <= $1)
+ORDER BY updated_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+const saveEnrollment = `
+UPDATE journey_enrollments
+SET step_id = $2, status = $3, reason = $4, last_message_id = $5, events = $6, sends = $7, attempts = $8, await_event = $9, wake_at = $10, updated_at = now()
+WHERE id = $1
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) CreateJourney(ctx context.Context, j Journey) (Journey, error) {
+	trigger, err := json.Marshal(j.Trigger)
+	if err != nil {
+		return Journey{}, fmt.Errorf("marshal journey trigger: %w", err)
+	}
+	steps, err := json.Marshal(j.Steps)
+	if err != nil {
+		return Journey{}, fmt.Errorf("marshal journey steps: %w", err)
+	}
+	triggerEvent := ""
+	if j.Trigger != nil {
+		triggerEvent = j.Trigger.Event
+	}
+	saved, err := scanJourney(r.pool.QueryRow(ctx, insertJourney, uuid.NewString(), j.TenantID, j.Name, triggerEvent, trigger, steps))
+	if err != nil {
+		return Journey{}, fmt.Errorf("insert journey: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresRepository) GetJourney(ctx context.Context, tenantID, id string) (Journey, error) {
+	j, err := scanJourney(r.pool.QueryRow(ctx, selectJourney, tenantID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Journey{}, ErrNotFound
+	}
+	if err != nil {
+		return Journey{}, fmt.Errorf("get journey: %w", err)
+	}
+	return j, nil
+}
+
+func (r *PostgresRepository) ListJourneys(ctx context.Context, tenantID string) ([]Journey, error) {
+	return r.queryJourneys(ctx, selectJourneys, tenantID)
+}
+
+func (r *PostgresRepository) Triggered(ctx context.Context, tenantID, event string) ([]Journey, error) {
+	return r.queryJourneys(ctx, selectTriggered, tenantID, event)
+}
+
+func (r *PostgresRepository) queryJourneys(ctx context.Context, query string, args ...any) ([]Journey, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list journeys: %w", err)
+	}
+	defer rows.Close()
+
+	var journeys []Journey
+	for rows.Next() {
+		j, err := scanJourney(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan journey: %w", err)
+		}
+		journeys = append(journeys, j)
+	}
+	return journeys, rows.Err()
+}
+
+func (r *PostgresRepository) Enroll(ctx context.Context, e Enrollment) (Enrollment, error) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("marshal enrollment data: %w", err)
+	}
+	saved, err := scanEnrollment(r.pool.QueryRow(ctx, insertEnrollment, uuid.NewString(), e.JourneyID, e.TenantID, e.UserID, e.StepID, e.Status, data))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Enrollment{}, ErrAlreadyExists
+	}
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("insert enrollment: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresRepository) GetEnrollment(ctx context.Context, tenantID, journeyID, userID string) (Enrollment, error) {
+	e, err := scanEnrollment(r.pool.QueryRow(ctx, selectEnrollment, tenantID, journeyID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Enrollment{}, ErrNotFound
+	}
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("get enrollment: %w", err)
+	}
+	return e, nil
+}
+
+func (r *PostgresRepository) RecordEvent(ctx context.Context, messageID, event string) error {
+	if _, err := r.pool.Exec(ctx, recordEvent, messageID, event); err != nil {
+		return fmt.Errorf("record journey event: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Advance(ctx context.Context, now time.Time, limit int, step func(context.Context, Enrollment) (Enrollment, error)) (int, error) {
+	var (
+		n        int
+		stepErrs []error
+	)
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, claimEnrollments, now, limit)
+		if err != nil {
+			return fmt.Errorf("claim enrollments: %w", err)
+		}
+		var due []Enrollment
+		for rows.Next() {
+			e, err := scanEnrollment(rows)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("scan enrollment: %w", err)
+			}
+			due = append(due, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// A failing enrollment is left as it was; the rest of the batch,
+		// including sends already made, is still saved.
+		for _, e := range due {
+			e, err := step(ctx, e)
+			if err != nil {
+				stepErrs = append(stepErrs, fmt.Errorf("enrollment %s: %w", e.ID, err))
+				continue
+			}
+			events := e.Events
+			if events == nil {
+				events = []string{}
+			}
+			if _, err := tx.Exec(ctx, saveEnrollment, e.ID, e.StepID, e.Status, e.Reason, e.LastMessageID, events, e.Sends, e.Attempts, e.AwaitEvent, e.WakeAt); err != nil {
+				return fmt.Errorf("save enrollment: %w", err)
+			}
+		}
+		n = len(due)
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, errors.Join(stepErrs...)
+}
+
+func scanJourney(row pgx.Row) (Journey, error) {
+	var (
+		j       Journey
+		trigger []byte
+		steps   []byte
+	)
+	if err := row.Scan(&j.ID, &j.TenantID, &j.Name, &trigger, &steps, &j.CreatedAt); err != nil {
+		return Journey{}, err
+	}
+	if len(trigger) > 0 {
+		if err := json.Unmarshal(trigger, &j.Trigger); err != nil {
+			return Journey{}, err
+		}
+	}
+	if err := json.Unmarshal(steps, &j.Steps); err != nil {
+		return Journey{}, err
+	}
+	return j, nil
+}
+
+func scanEnrollment(row pgx.Row) (Enrollment, error) {
+	var (
+		e    Enrollment
+		data []byte
+	)
+	err := row.Scan(
+		&e.ID,
+		&e.JourneyID,
+		&e.TenantID,
+		&e.UserID,
+		&e.StepID,
+		&e.Status,
+		&e.Reason,
+		&data,
+		&e.LastMessageID,
+		&e.Events,
+		&e.Sends,
+		&e.Attempts,
+		&e.AwaitEvent,
+		&e.WakeAt,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+	if err != nil {
+		return Enrollment{}, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &e.Data); err != nil {
+			return Enrollment{}, err
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,212 @@
+package journeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+
+	"github.com/example/notification-service/internal/ingest"
+)
+
+const (
+	defaultInterval  = time.Second
+	defaultBatchSize = 100
+	// maxStepsPerAdvance stops a journey whose steps loop without waiting.
+	maxStepsPerAdvance = 50
+	// maxStepAttempts fails an enrollment whose send errors this many times
+	// in a row; retryDelay grows linearly between attempts.
+	maxStepAttempts = 10
+	retryDelay      = 30 * time.Second
+)
+
+var executedSteps = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "journey_steps_total",
+	Help: "Journey steps executed, by step type",
+}, []string{"type"})
+
+// Notifier is the ingestion path journeys send through.
+type Notifier interface {
+	Notify(ctx context.Context, tenantID, idempotencyKey string, req ingest.NotifyRequest) (ingest.NotifyResult, error)
+}
+
+// Runner advances due enrollments until each reaches a wait, an unanswered
+// branch or the end of its journey. Sends use the idempotency key
+// journey:<enrollment>:<step>:<n>, n counting the enrollment's sends, so
+// re-running a step after a crash is safe and a loop sends again.
+type Runner struct {
+	Store     Repository
+	Notifier  Notifier
+	Interval  time.Duration
+	BatchSize int
+	Logger    zerolog.Logger
+}
+
+func (r *Runner) Run(ctx context.Context) error {
+	if r.Store == nil || r.Notifier == nil {
+		return errors.New("journey runner requires a store and notifier")
+	}
+	interval := r.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	batch := r.BatchSize
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		for {
+			journeys := map[string]Journey{}
+			now := time.Now().UTC()
+			n, err := r.Store.Advance(ctx, now, batch, func(ctx context.Context, e Enrollment) (Enrollment, error) {
+				j, ok := journeys[e.JourneyID]
+				if !ok {
+					var err error
+					if j, err = r.Store.GetJourney(ctx, e.TenantID, e.JourneyID); errors.Is(err, ErrNotFound) {
+						return fail(e, "journey_not_found"), nil
+					} else if err != nil {
+						// Left as it is and tried again next tick.
+						return e, fmt.Errorf("load journey: %w", err)
+					}
+					journeys[e.JourneyID] = j
+				}
+				next, err := r.advance(ctx, j, e, now)
+				if err != nil {
+					r.Logger.Warn().Err(err).Str("enrollment_id", e.ID).Int("attempt", next.Attempts+1).Msg("journey send failed")
+					return retry(next, now), nil
+				}
+				next.Attempts = 0
+				return next, nil
+			})
+			if err != nil {
+				r.Logger.Error().Err(err).Msg("failed to advance journeys")
+				break
+			}
+			if n < batch {
+				break
+			}
+		}
+	}
+}
+
+// advance executes steps from e.StepID until the enrollment has to wait.
+func (r *Runner) advance(ctx context.Context, j Journey, e Enrollment, now time.Time) (Enrollment, error) {
+	for i := 0; i < maxStepsPerAdvance; i++ {
+		if e.StepID == "" {
+			e.Status = StatusCompleted
+			return e, nil
+		}
+		s, ok := j.step(e.StepID)
+		if !ok {
+			return fail(e, "unknown_step"), nil
+		}
+
+		switch s.Type {
+		case StepSend:
+			key := fmt.Sprintf("journey:%s:%s:%d", e.ID, s.ID, e.Sends+1)
+			result, err := r.Notifier.Notify(ctx, e.TenantID, key, sendRequest(j, e, s))
+			var invalid *ingest.ValidationError
+			if errors.As(err, &invalid) {
+				r.Logger.Warn().Err(err).Str("journey_id", j.ID).Str("step_id", s.ID).Msg("journey send rejected")
+				return fail(e, "send_rejected"), nil
+			}
+			if err != nil {
+				return e, fmt.Errorf("journey send: %w", err)
+			}
+			e.LastMessageID = result.MessageID
+			e.Events = nil
+			e.Sends++
+			e.StepID = j.after(s)
+		case StepWait:
+			if e.WakeAt == nil {
+				e.WakeAt = at(now.Add(time.Duration(s.WaitSeconds) * time.Second))
+				executedSteps.WithLabelValues(s.Type).Inc()
+				return e, nil
+			}
+			if now.Before(*e.WakeAt) {
+				return e, nil
+			}
+			e.StepID = j.after(s)
+		case StepBranch:
+			switch {
+			case hasEvent(e.Events, s.Event):
+				e.StepID = or(s.Then, j.after(s))
+			case e.WakeAt == nil:
+				e.AwaitEvent = s.Event
+				e.WakeAt = at(now.Add(time.Duration(s.WithinSeconds) * time.Second))
+				executedSteps.WithLabelValues(s.Type).Inc()
+				return e, nil
+			case now.Before(*e.WakeAt):
+				return e, nil
+			default:
+				e.StepID = or(s.Else, j.after(s))
+			}
+		case StepExit:
+			e.StepID = ""
+		}
+		executedSteps.WithLabelValues(s.Type).Inc()
+		e.WakeAt = nil
+		e.AwaitEvent = ""
+	}
+	return fail(e, "step_limit"), nil
+}
+
+func sendRequest(j Journey, e Enrollment, s Step) ingest.NotifyRequest {
+	data := make(map[string]any, len(e.Data)+len(s.Data))
+	for k, v := range e.Data {
+		data[k] = v
+	}
+	for k, v := range s.Data {
+		data[k] = v
+	}
+	return ingest.NotifyRequest{
+		Channel:    ingest.Channel(s.Channel),
+		To:         map[string]any{"user_id": e.UserID},
+		TemplateID: s.TemplateID,
+		Data:       data,
+		Category:   s.Category,
+		Options:    map[string]any{"journey_id": j.ID, "journey_step": s.ID},
+	}
+}
+
+// retry records a failed send. e keeps the progress made before the error,
+// such as earlier sends, and is due again after a delay that grows with
+// each attempt until maxStepAttempts fails it. Only send steps fail, and
+// they ignore WakeAt, so it is free to hold the retry time.
+func retry(e Enrollment, now time.Time) Enrollment {
+	e.Attempts++
+	if e.Attempts >= maxStepAttempts {
+		return fail(e, "step_error")
+	}
+	e.WakeAt = at(now.Add(time.Duration(e.Attempts) * retryDelay))
+	return e
+}
+
+func fail(e Enrollment, reason string) Enrollment {
+	e.Status = StatusFailed
+	e.Reason = reason
+	return e
+}
+
+func at(t time.Time) *time.Time {
+	return &t
+}
+
+func or(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
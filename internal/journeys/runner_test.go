@@ -0,0 +1,170 @@
+package journeys
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/notification-service/internal/ingest"
+)
+
+type stubNotifier struct {
+	sent []ingest.NotifyRequest
+}
+
+func (s *stubNotifier) Notify(_ context.Context, _, key string, req ingest.NotifyRequest) (ingest.NotifyResult, error) {
+	s.sent = append(s.sent, req)
+	return ingest.NotifyResult{MessageID: key}, nil
+}
+
+// onboarding is "welcome email, wait 2 days, if not opened within a day
+// send push".
+var onboarding = Journey{
+	ID:   "j1",
+	Name: "onboarding",
+	Steps: []Step{
+		{ID: "welcome", Type: StepSend, Channel: "email", TemplateID: "welcome"},
+		{ID: "pause", Type: StepWait, WaitSeconds: 2 * 24 * 3600},
+		{ID: "opened?", Type: StepBranch, Event: "opened", WithinSeconds: 24 * 3600, Then: "done", Else: "nudge"},
+		{ID: "nudge", Type: StepSend, Channel: "push", TemplateID: "nudge"},
+		{ID: "done", Type: StepExit},
+	},
+}
+
+func TestRunnerAdvance(t *testing.T) {
+	if err := onboarding.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		events    []string
+		wantSends []string
+	}{
+		{"not opened sends push", nil, []string{"welcome", "nudge"}},
+		{"opened exits", []string{"delivered", "opened"}, []string{"welcome"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &stubNotifier{}
+			r := &Runner{Notifier: notifier}
+			e := onboarding.enrollment("u1", map[string]any{"plan": "pro"})
+			e.ID = "e1"
+
+			e, err := r.advance(context.Background(), onboarding, e, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.StepID != "pause" || e.WakeAt == nil || !e.WakeAt.Equal(start.Add(48*time.Hour)) {
+				t.Fatalf("expected to wait two days, got step %s wake %v", e.StepID, e.WakeAt)
+			}
+			if e.LastMessageID != "journey:e1:welcome:1" {
+				t.Fatalf("last message %q", e.LastMessageID)
+			}
+
+			// Nothing happens before the wait ends.
+			if e, _ = r.advance(context.Background(), onboarding, e, start.Add(time.Hour)); e.StepID != "pause" {
+				t.Fatalf("advanced early to %s", e.StepID)
+			}
+
+			e.Events = tt.events
+			e, _ = r.advance(context.Background(), onboarding, e, start.Add(48*time.Hour))
+			if len(tt.events) == 0 {
+				if e.StepID != "opened?" || e.AwaitEvent != "opened" {
+					t.Fatalf("expected to await opened, got step %s await %q", e.StepID, e.AwaitEvent)
+				}
+				e, _ = r.advance(context.Background(), onboarding, e, start.Add(72*time.Hour))
+			}
+			if e.Status != StatusCompleted {
+				t.Fatalf("status %s at step %s", e.Status, e.StepID)
+			}
+
+			var sends []string
+			for _, req := range notifier.sent {
+				sends = append(sends, req.TemplateID)
+				if req.To["user_id"] != "u1" || req.Data["plan"] != "pro" {
+					t.Fatalf("unexpected request %+v", req)
+				}
+			}
+			if len(sends) != len(tt.wantSends) {
+				t.Fatalf("sent %v, want %v", sends, tt.wantSends)
+			}
+			for i := range sends {
+				if sends[i] != tt.wantSends[i] {
+					t.Fatalf("sent %v, want %v", sends, tt.wantSends)
+				}
+			}
+		})
+	}
+}
+
+func TestRunnerLoopSendsAgain(t *testing.T) {
+	reminder := Journey{
+		ID:   "j2",
+		Name: "reminder",
+		Steps: []Step{
+			{ID: "remind", Type: StepSend, Channel: "email", TemplateID: "remind"},
+			{ID: "pause", Type: StepWait, WaitSeconds: 3600, Next: "remind"},
+		},
+	}
+	if err := reminder.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	r := &Runner{Notifier: &stubNotifier{}}
+	e := reminder.enrollment("u1", nil)
+	e.ID = "e1"
+
+	var keys []string
+	for i := 0; i < 3; i++ {
+		var err error
+		e, err = r.advance(context.Background(), reminder, e, start.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, e.LastMessageID)
+	}
+	want := []string{"journey:e1:remind:1", "journey:e1:remind:2", "journey:e1:remind:3"}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("send keys %v, want %v", keys, want)
+		}
+	}
+}
+
+func TestJourneyValidate(t *testing.T) {
+	invalid := []Journey{
+		{Steps: onboarding.Steps},
+		{Name: "empty"},
+		{Name: "dup", Steps: []Step{{ID: "a", Type: StepExit}, {ID: "a", Type: StepExit}}},
+		{Name: "send", Steps: []Step{{ID: "a", Type: StepSend, Channel: "email"}}},
+		{Name: "wait", Steps: []Step{{ID: "a", Type: StepWait}}},
+		{Name: "branch", Steps: []Step{{ID: "a", Type: StepBranch, Event: "read", WithinSeconds: 60}}},
+		{Name: "ref", Steps: []Step{{ID: "a", Type: StepExit, Next: "b"}}},
+		{Name: "trigger", Trigger: &Trigger{Event: "viewed"}, Steps: []Step{{ID: "a", Type: StepExit}}},
+	}
+	for _, j := range invalid {
+		if err := j.Validate(); err == nil {
+			t.Fatalf("journey %q: expected a validation error", j.Name)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	e := Enrollment{ID: "e1", StepID: "welcome", Status: StatusActive}
+	for i := 1; i < maxStepAttempts; i++ {
+		e = retry(e, now)
+		if e.Status != StatusActive || e.Attempts != i {
+			t.Fatalf("attempt %d: status %s attempts %d", i, e.Status, e.Attempts)
+		}
+		if want := now.Add(time.Duration(i) * retryDelay); e.WakeAt == nil || !e.WakeAt.Equal(want) {
+			t.Fatalf("attempt %d: wake %v, want %v", i, e.WakeAt, want)
+		}
+	}
+	if e = retry(e, now); e.Status != StatusFailed || e.Reason != "step_error" {
+		t.Fatalf("expected failure after %d attempts, got %s %q", maxStepAttempts, e.Status, e.Reason)
+	}
+}
@@ -0,0 +1,178 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultInterval    = time.Second
+	defaultBatchSize   = 100
+	defaultMaxAttempts = 10
+	// The first retry waits baseBackoff, doubling up to maxBackoff, so ten
+	// attempts span about four and a half hours.
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+	// claimLease outlasts a batch of concurrent sends bounded by the
+	// sender's timeout.
+	claimLease = time.Minute
+
+	DefaultDisableAfter         = 24 * time.Hour
+	DefaultDisableAfterFailures = 20
+)
+
+var (
+	deliveryAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbound_webhook_attempts_total",
+		Help: "Tenant webhook delivery attempts, by result",
+	}, []string{"result"})
+	disabledWebhooks = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbound_webhooks_disabled_total",
+		Help: "Tenant webhooks disabled after sustained delivery failures",
+	})
+)
+
+// Deliverer sends due deliveries, retrying failures with exponential
+// backoff up to MaxAttempts. A webhook whose attempts have failed
+// DisableAfterFailures times in a row over at least DisableAfter is disabled
+// until the tenant re-enables it; its pending deliveries wait until then.
+type Deliverer struct {
+	Store                Repository
+	Sender               *Sender
+	Interval             time.Duration
+	BatchSize            int
+	MaxAttempts          int
+	DisableAfter         time.Duration
+	DisableAfterFailures int
+	Logger               zerolog.Logger
+}
+
+func (d *Deliverer) Run(ctx context.Context) error {
+	if d.Store == nil || d.Sender == nil {
+		return errors.New("webhook deliverer requires a store and sender")
+	}
+	interval := d.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	batchSize := d.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		for {
+			due, err := d.Store.Claim(ctx, time.Now().UTC(), claimLease, batchSize)
+			if err != nil {
+				d.Logger.Error().Err(err).Msg("claim webhook deliveries failed")
+				break
+			}
+			var wg sync.WaitGroup
+			for _, delivery := range due {
+				wg.Add(1)
+				go func(delivery Delivery) {
+					defer wg.Done()
+					if err := d.attempt(ctx, delivery); err != nil {
+						d.Logger.Error().Err(err).Str("delivery_id", delivery.ID).Msg("record webhook delivery failed")
+					}
+				}(delivery)
+			}
+			wg.Wait()
+			if len(due) < batchSize {
+				break
+			}
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome.
+func (d *Deliverer) attempt(ctx context.Context, delivery Delivery) error {
+	status, sendErr := d.Sender.Send(ctx, delivery)
+	now := time.Now().UTC()
+	delivery = d.outcome(delivery, status, sendErr, now)
+	if err := d.Store.RecordAttempt(ctx, delivery); err != nil {
+		return err
+	}
+	result := delivery.Status
+	if result == DeliveryPending {
+		result = "retrying"
+	}
+	deliveryAttempts.WithLabelValues(result).Inc()
+	if sendErr == nil {
+		return d.Store.MarkHealthy(ctx, delivery.WebhookID)
+	}
+
+	failures, since, err := d.Store.MarkFailing(ctx, delivery.WebhookID, now)
+	if err != nil {
+		return err
+	}
+	threshold := d.DisableAfterFailures
+	if threshold <= 0 {
+		threshold = DefaultDisableAfterFailures
+	}
+	after := d.DisableAfter
+	if after <= 0 {
+		after = DefaultDisableAfter
+	}
+	if failures < threshold || now.Sub(since) < after {
+		return nil
+	}
+	reason := fmt.Sprintf("%d consecutive failed deliveries since %s; last error: %s", failures, since.Format(time.RFC3339), delivery.Error)
+	if err := d.Store.Disable(ctx, delivery.WebhookID, reason); err != nil {
+		return err
+	}
+	disabledWebhooks.Inc()
+	d.Logger.Warn().Str("webhook_id", delivery.WebhookID).Str("tenant_id", delivery.TenantID).Msg("disabled failing webhook")
+	return nil
+}
+
+// outcome applies one attempt's result to a delivery.
+func (d *Deliverer) outcome(delivery Delivery, status int, sendErr error, now time.Time) Delivery {
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	if sendErr == nil {
+		delivery.Status = DeliverySucceeded
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+		return delivery
+	}
+	delivery.Error = sendErr.Error()
+	maxAttempts := d.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = DeliveryFailed
+		delivery.NextAttemptAt = nil
+		return delivery
+	}
+	next := now.Add(backoff(delivery.Attempts))
+	delivery.Status = DeliveryPending
+	delivery.NextAttemptAt = &next
+	return delivery
+}
+
+// backoff is the wait after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
@@ -0,0 +1,163 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{9, 128 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, maxBackoff},
+		{40, maxBackoff},
+	}
+	for _, tc := range cases {
+		if got := backoff(tc.attempts); got != tc.want {
+			t.Errorf("backoff(%d) = %s, want %s", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	d := &Deliverer{MaxAttempts: 3}
+	cases := []struct {
+		name     string
+		attempts int
+		status   int
+		err      error
+		want     string
+		next     time.Duration
+	}{
+		{name: "success", attempts: 0, status: 204, want: DeliverySucceeded},
+		{name: "first failure retries", attempts: 0, status: 500, err: errors.New("webhook responded 500"), want: DeliveryPending, next: 30 * time.Second},
+		{name: "second failure backs off", attempts: 1, err: errors.New("timeout"), want: DeliveryPending, next: time.Minute},
+		{name: "last attempt fails", attempts: 2, status: 503, err: errors.New("webhook responded 503"), want: DeliveryFailed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := d.outcome(Delivery{Attempts: tc.attempts, Status: DeliveryPending}, tc.status, tc.err, now)
+			if got.Status != tc.want || got.Attempts != tc.attempts+1 || got.ResponseStatus != tc.status {
+				t.Fatalf("outcome = %+v", got)
+			}
+			if tc.next == 0 {
+				if got.NextAttemptAt != nil {
+					t.Fatalf("next attempt %s, want none", got.NextAttemptAt)
+				}
+				return
+			}
+			if got.NextAttemptAt == nil || !got.NextAttemptAt.Equal(now.Add(tc.next)) {
+				t.Fatalf("next attempt %v, want %s", got.NextAttemptAt, now.Add(tc.next))
+			}
+		})
+	}
+}
+
+func TestWebhookValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		hook Webhook
+		ok   bool
+	}{
+		{name: "valid", hook: Webhook{URL: "https://example.com/hooks", Events: []string{"delivered", "bounced"}}, ok: true},
+		{name: "wildcard", hook: Webhook{URL: "http://hooks.example.com:8080/", Events: []string{"*"}}, ok: true},
+		{name: "public ip", hook: Webhook{URL: "http://93.184.216.34/hooks", Events: []string{"*"}}, ok: true},
+		{name: "localhost", hook: Webhook{URL: "http://localhost:8080/", Events: []string{"*"}}},
+		{name: "loopback", hook: Webhook{URL: "http://127.0.0.1/", Events: []string{"*"}}},
+		{name: "metadata service", hook: Webhook{URL: "http://169.254.169.254/latest/meta-data", Events: []string{"*"}}},
+		{name: "private", hook: Webhook{URL: "http://192.168.1.10/", Events: []string{"*"}}},
+		{name: "unique local v6", hook: Webhook{URL: "http://[fd00::1]/", Events: []string{"*"}}},
+		{name: "unspecified", hook: Webhook{URL: "http://0.0.0.0:8080/", Events: []string{"*"}}},
+		{name: "relative url", hook: Webhook{URL: "/hooks", Events: []string{"delivered"}}},
+		{name: "other scheme", hook: Webhook{URL: "ftp://example.com", Events: []string{"delivered"}}},
+		{name: "no events", hook: Webhook{URL: "https://example.com"}},
+		{name: "unknown event", hook: Webhook{URL: "https://example.com", Events: []string{"read"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.hook.Validate(); (err == nil) != tc.ok {
+				t.Fatalf("Validate() = %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestSenderSignsPayload(t *testing.T) {
+	var gotSignature, gotEvent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(SignatureHeader)
+		gotEvent = r.Header.Get("X-HSNP-Event")
+		if Sign("whsec_test", body) != gotSignature {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	hook := Webhook{ID: "w1", TenantID: "t1", URL: srv.URL, Secret: "whsec_test", Events: []string{"*"}}
+	d, err := newDelivery(hook, "k", "delivered", "m1", []byte(`{"status":"delivered"}`), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := loopbackSender()
+	status, err := sender.Send(context.Background(), d)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("Send = %d, %v", status, err)
+	}
+	if gotEvent != "delivered" || len(gotSignature) != len("sha256=")+64 {
+		t.Fatalf("headers: event %q signature %q", gotEvent, gotSignature)
+	}
+
+	d.Secret = "wrong"
+	if status, err := sender.Send(context.Background(), d); err == nil || status != http.StatusUnauthorized {
+		t.Fatalf("Send with wrong secret = %d, %v", status, err)
+	}
+}
+
+func TestSenderDoesNotFollowRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	status, err := loopbackSender().Send(context.Background(), Delivery{URL: srv.URL, Payload: []byte(`{}`)})
+	if err == nil || status != http.StatusFound {
+		t.Fatalf("Send = %d, %v; want an error for the redirect", status, err)
+	}
+}
+
+func TestSenderRefusesNonPublicAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	for _, url := range []string{srv.URL, "http://169.254.169.254/latest/meta-data", "http://[::1]:9/", "http://10.0.0.1:9/"} {
+		_, err := NewSender().Send(context.Background(), Delivery{URL: url, Payload: []byte(`{}`)})
+		if !errors.Is(err, ErrDisallowedAddress) {
+			t.Errorf("Send(%s) err = %v, want ErrDisallowedAddress", url, err)
+		}
+	}
+}
+
+// loopbackSender is NewSender without the public address check, for tests
+// against httptest servers.
+func loopbackSender() *Sender {
+	s := NewSender()
+	s.Client.Transport = http.DefaultTransport
+	return s
+}
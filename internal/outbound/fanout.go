@@ -0,0 +1,89 @@
+package outbound
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+)
+
+type event struct {
+	EventID   string `json:"event_id"`
+	MessageID string `json:"message_id"`
+	TenantID  string `json:"tenant_id"`
+	Status    string `json:"status"`
+}
+
+// Fanout consumes provider.events and enqueues a delivery for every enabled
+// webhook of the event's tenant that subscribes to its status. The event is
+// forwarded as the payload's data unchanged.
+type Fanout struct {
+	ReaderFactory func() *kafka.Reader
+	Store         Repository
+	Logger        zerolog.Logger
+}
+
+func (f *Fanout) Run(ctx context.Context) error {
+	if f.ReaderFactory == nil || f.Store == nil {
+		return errors.New("webhook fanout requires a reader factory and store")
+	}
+	reader := f.ReaderFactory()
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch message: %w", err)
+		}
+		var e event
+		if err := json.Unmarshal(m.Value, &e); err != nil {
+			f.Logger.Error().Err(err).Msg("failed to decode provider event")
+			_ = reader.CommitMessages(ctx, m)
+			continue
+		}
+		// Provider events carry a stable event id, so a reprocessed event
+		// maps onto the delivery already enqueued for it; worker events
+		// are keyed by their offset.
+		key := e.EventID
+		if key == "" {
+			key = m.Topic + ":" + strconv.Itoa(m.Partition) + ":" + strconv.FormatInt(m.Offset, 10)
+		}
+		if err := f.handle(ctx, key, e, m.Value); err != nil {
+			return err
+		}
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			return fmt.Errorf("commit message: %w", err)
+		}
+	}
+}
+
+func (f *Fanout) handle(ctx context.Context, key string, e event, data json.RawMessage) error {
+	if e.TenantID == "" || e.Status == "" {
+		return nil
+	}
+	webhooks, err := f.Store.Subscribers(ctx, e.TenantID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	var deliveries []Delivery
+	for _, w := range webhooks {
+		if !w.subscribes(e.Status) {
+			continue
+		}
+		d, err := newDelivery(w, key, e.Status, e.MessageID, data, now)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return f.Store.Enqueue(ctx, deliveries)
+}
@@ -0,0 +1,253 @@
+package outbound
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+type Handler struct {
+	repo   Repository
+	sender *Sender
+	logger zerolog.Logger
+}
+
+func NewHandler(repo Repository, sender *Sender, logger zerolog.Logger) *Handler {
+	return &Handler{repo: repo, sender: sender, logger: logger}
+}
+
+func (h *Handler) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/v1/webhooks", h.list)
+	r.Post("/v1/webhooks", h.create)
+	r.Post("/v1/webhooks/test", h.test)
+	r.Get("/v1/webhooks/{id}", h.get)
+	r.Delete("/v1/webhooks/{id}", h.delete)
+	r.Post("/v1/webhooks/{id}/enable", h.enable)
+	r.Get("/v1/webhooks/{id}/deliveries", h.deliveries)
+	return r
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	webhooks, err := h.repo.ListWebhooks(ctx, tenantID)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"webhooks": webhooks})
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var hook Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	hook.TenantID = tenantID
+	if err := hook.Validate(); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	if hook.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			h.respondErr(ctx, w, http.StatusInternalServerError, err)
+			return
+		}
+		hook.Secret = secret
+	}
+	saved, err := h.repo.CreateWebhook(ctx, hook)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(saved)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	hook, err := h.repo.GetWebhook(ctx, tenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	hook.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(hook)
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	if err := h.repo.DeleteWebhook(ctx, tenantID, chi.URLParam(r, "id")); err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) enable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	hook, err := h.repo.EnableWebhook(ctx, tenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	hook.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(hook)
+}
+
+func (h *Handler) deliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	limit := defaultDeliveryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxDeliveryLimit {
+			h.respondErr(ctx, w, http.StatusBadRequest, errors.New("limit must be between 1 and 500"))
+			return
+		}
+		limit = n
+	}
+	id := chi.URLParam(r, "id")
+	if _, err := h.repo.GetWebhook(ctx, tenantID, id); err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	deliveries, err := h.repo.ListDeliveries(ctx, tenantID, id, limit)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"deliveries": deliveries})
+}
+
+type testRequest struct {
+	WebhookID string `json:"webhook_id"`
+}
+
+type testResult struct {
+	DeliveryID string `json:"delivery_id"`
+	Succeeded  bool   `json:"succeeded"`
+}
+
+// test sends a signed test event to a webhook, enabled or not, and reports
+// whether it succeeded. It does not count towards the webhook's failures.
+func (h *Handler) test(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var req testRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	if req.WebhookID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("webhook_id is required"))
+		return
+	}
+	hook, err := h.repo.GetWebhook(ctx, tenantID, req.WebhookID)
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+
+	now := time.Now().UTC()
+	data, _ := json.Marshal(map[string]string{"tenant_id": tenantID, "webhook_id": hook.ID})
+	d, err := newDelivery(hook, "", TestEvent, "", data, now)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	// Logged without a next attempt, so the deliverer never claims it.
+	d.EventKey = d.ID
+	d.NextAttemptAt = nil
+	if err := h.repo.Enqueue(ctx, []Delivery{d}); err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	status, sendErr := h.sender.Send(ctx, d)
+	d.Attempts = 1
+	d.ResponseStatus = status
+	d.Status = DeliverySucceeded
+	if sendErr != nil {
+		d.Status = DeliveryFailed
+		d.Error = sendErr.Error()
+	}
+	if err := h.repo.RecordAttempt(ctx, d); err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	// Only the outcome is returned: response statuses and connection
+	// errors would let tenants probe the hosts we can reach.
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(testResult{DeliveryID: d.ID, Succeeded: sendErr == nil})
+}
+
+func statusForErr(err error) int {
+	if errors.Is(err, ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
+	logger := common.WithContext(ctx, h.logger)
+	logger.Error().Err(err).Int("status", status).Msg("webhooks handler failed")
+	http.Error(w, err.Error(), status)
+}
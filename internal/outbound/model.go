@@ -0,0 +1,186 @@
+package outbound
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotFound = errors.New("webhook not found")
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// TestEvent is the event type of deliveries sent by POST /v1/webhooks/test.
+const TestEvent = "test"
+
+// knownEvents are the canonical statuses a webhook can subscribe to; "*"
+// subscribes to every event.
+var knownEvents = map[string]bool{
+	"*":          true,
+	"queued":     true,
+	"sent":       true,
+	"delivered":  true,
+	"opened":     true,
+	"clicked":    true,
+	"bounced":    true,
+	"failed":     true,
+	"complained": true,
+	"deferred":   true,
+}
+
+// Webhook is a tenant's subscription to message status events.
+type Webhook struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	URL      string `json:"url"`
+	// Secret keys the X-HSNP-Signature HMAC. It is generated when omitted
+	// and only returned when the webhook is created.
+	Secret  string   `json:"secret,omitempty"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+	// ConsecutiveFailures counts failed attempts since FailingSince; the
+	// webhook is disabled once both pass the deliverer's thresholds.
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	FailingSince        *time.Time `json:"failing_since,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func (w Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	// Hostnames are checked again when dialing, after they resolve.
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); (ip != nil && !publicIP(ip)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("url must point to a public host")
+	}
+	if len(w.Events) == 0 {
+		return errors.New("events is required")
+	}
+	for _, e := range w.Events {
+		if !knownEvents[e] {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+// generateSecret returns a random signing secret for a new webhook.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func (w Webhook) subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one event POSTed, or to be POSTed, to one webhook. Payload is
+// fixed when the delivery is created so retries carry the same body and
+// signature.
+type Delivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	TenantID       string          `json:"tenant_id"`
+	EventType      string          `json:"event_type"`
+	MessageID      string          `json:"message_id,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+
+	// EventKey identifies the source event so a redelivered Kafka message
+	// does not enqueue twice.
+	EventKey string `json:"-"`
+	// URL and Secret are the webhook's, loaded when the delivery is claimed.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// payload is the JSON body tenants receive.
+type payload struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// newDelivery builds a pending delivery of the event data to w.
+func newDelivery(w Webhook, eventKey, eventType, messageID string, data json.RawMessage, now time.Time) (Delivery, error) {
+	d := Delivery{
+		ID:            uuid.NewString(),
+		WebhookID:     w.ID,
+		TenantID:      w.TenantID,
+		EventType:     eventType,
+		MessageID:     messageID,
+		Status:        DeliveryPending,
+		NextAttemptAt: &now,
+		EventKey:      eventKey,
+		URL:           w.URL,
+		Secret:        w.Secret,
+	}
+	body, err := json.Marshal(payload{ID: d.ID, Type: eventType, CreatedAt: now, Data: data})
+	if err != nil {
+		return Delivery{}, fmt.Errorf("marshal webhook payload: %w", err)
+	}
+	d.Payload = body
+	return d, nil
+}
+
+type Repository interface {
+	CreateWebhook(ctx context.Context, w Webhook) (Webhook, error)
+	ListWebhooks(ctx context.Context, tenantID string) ([]Webhook, error)
+	GetWebhook(ctx context.Context, tenantID, id string) (Webhook, error)
+	DeleteWebhook(ctx context.Context, tenantID, id string) error
+	// EnableWebhook re-enables a webhook and clears its failure state.
+	EnableWebhook(ctx context.Context, tenantID, id string) (Webhook, error)
+	// Subscribers lists the tenant's enabled webhooks.
+	Subscribers(ctx context.Context, tenantID string) ([]Webhook, error)
+
+	// Enqueue stores deliveries, skipping any whose webhook already has one
+	// for the same EventKey.
+	Enqueue(ctx context.Context, deliveries []Delivery) error
+	// Claim returns up to limit pending deliveries of enabled webhooks that
+	// are due at now, hiding them from other claims until now+lease.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error)
+	// RecordAttempt saves a delivery's status, attempts, response and next
+	// attempt.
+	RecordAttempt(ctx context.Context, d Delivery) error
+	ListDeliveries(ctx context.Context, tenantID, webhookID string, limit int) ([]Delivery, error)
+
+	// MarkHealthy clears a webhook's failure state after a success.
+	MarkHealthy(ctx context.Context, webhookID string) error
+	// MarkFailing counts a failed attempt and returns the webhook's
+	// consecutive failures and when they started.
+	MarkFailing(ctx context.Context, webhookID string, now time.Time) (int, time.Time, error)
+	Disable(ctx context.Context, webhookID, reason string) error
+}
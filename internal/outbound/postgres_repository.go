@@ -0,0 +1,327 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const webhookColumns = `id, tenant_id, url, secret, events, enabled, consecutive_failures, failing_since, disabled_reason, created_at, updated_at`
+
+const insertWebhook = `
+INSERT INTO webhooks (id, tenant_id, url, secret, events, enabled, consecutive_failures, disabled_reason, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, true, 0, '', now(), now())
+RETURNING ` + webhookColumns
+
+const selectWebhooks = `
+SELECT ` + webhookColumns + `
+FROM webhooks
+WHERE tenant_id = $1
+ORDER BY created_at
+`
+
+const selectWebhook = `
+SELECT ` + webhookColumns + `
+FROM webhooks
+WHERE tenant_id = $1 AND id = $2
+`
+
+const selectSubscribers = `
+SELECT ` + webhookColumns + `
+FROM webhooks
+WHERE tenant_id = $1 AND enabled
+`
+
+const deleteWebhook = `
+DELETE FROM webhooks WHERE tenant_id = $1 AND id = $2
+`
+
+const enableWebhook = `
+UPDATE webhooks SET enabled = true, consecutive_failures = 0, failing_since = NULL, disabled_reason = '', updated_at = now()
+WHERE tenant_id = $1 AND id = $2
+RETURNING ` + webhookColumns
+
+const markHealthy = `
+UPDATE webhooks SET consecutive_failures = 0, failing_since = NULL, updated_at = now()
+WHERE id = $1 AND consecutive_failures > 0
+`
+
+const markFailing = `
+UPDATE webhooks SET consecutive_failures = consecutive_failures + 1, failing_since = COALESCE(failing_since, $2), updated_at = now()
+WHERE id = $1
+RETURNING consecutive_failures, failing_since
+`
+
+const disableWebhook = `
+UPDATE webhooks SET enabled = false, disabled_reason = $2, updated_at = now()
+WHERE id = $1
+`
+
+const deliveryColumns = `d.id, d.webhook_id, d.tenant_id, d.event_type, d.message_id, d.payload_json, d.status, d.attempts,
+d.response_status, d.error, d.next_attempt_at, d.created_at, d.updated_at`
+
+const insertDelivery = `
+INSERT INTO webhook_deliveries (
+id, webhook_id, tenant_id, event_key, event_type, message_id, payload_json, status, attempts,
+response_status, error, next_attempt_at, created_at, updated_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, now(), now())
+ON CONFLICT (webhook_id, event_key) DO NOTHING
+`
+
+// SKIP LOCKED lets several deliverer replicas claim in parallel; pushing
+// next_attempt_at out by the lease keeps a claimed row from being sent
+// twice while its request is in flight.
+const claimDeliveries = `
+UPDATE webhook_deliveries AS d SET next_attempt_at = $2, updated_at = now()
+FROM webhooks AS w
+WHERE w.id = d.webhook_id AND d.id IN (
+	SELECT pending.id
+	FROM webhook_deliveries AS pending
+	JOIN webhooks AS hook ON hook.id = pending.webhook_id
+	WHERE pending.status = 'pending' AND pending.next_attempt_at <= $1 AND hook.enabled
+	ORDER BY pending.next_attempt_at
+	LIMIT $3
+	FOR UPDATE OF pending SKIP LOCKED
+)
+RETURNING ` + deliveryColumns + `, w.url, w.secret`
+
+const updateDelivery = `
+UPDATE webhook_deliveries SET status = $2, attempts = $3, response_status = $4, error = $5, next_attempt_at = $6, updated_at = now()
+WHERE id = $1
+`
+
+const selectDeliveries = `
+SELECT ` + deliveryColumns + `
+FROM webhook_deliveries AS d
+WHERE d.tenant_id = $1 AND d.webhook_id = $2
+ORDER BY d.created_at DESC
+LIMIT $3
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) CreateWebhook(ctx context.Context, w Webhook) (Webhook, error) {
+	saved, err := scanWebhook(r.pool.QueryRow(ctx, insertWebhook, uuid.NewString(), w.TenantID, w.URL, w.Secret, w.Events))
+	if err != nil {
+		return Webhook{}, fmt.Errorf("insert webhook: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresRepository) ListWebhooks(ctx context.Context, tenantID string) ([]Webhook, error) {
+	return r.queryWebhooks(ctx, selectWebhooks, tenantID)
+}
+
+func (r *PostgresRepository) Subscribers(ctx context.Context, tenantID string) ([]Webhook, error) {
+	return r.queryWebhooks(ctx, selectSubscribers, tenantID)
+}
+
+func (r *PostgresRepository) GetWebhook(ctx context.Context, tenantID, id string) (Webhook, error) {
+	w, err := scanWebhook(r.pool.QueryRow(ctx, selectWebhook, tenantID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Webhook{}, ErrNotFound
+	}
+	if err != nil {
+		return Webhook{}, fmt.Errorf("select webhook: %w", err)
+	}
+	return w, nil
+}
+
+func (r *PostgresRepository) DeleteWebhook(ctx context.Context, tenantID, id string) error {
+	tag, err := r.pool.Exec(ctx, deleteWebhook, tenantID, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) EnableWebhook(ctx context.Context, tenantID, id string) (Webhook, error) {
+	w, err := scanWebhook(r.pool.QueryRow(ctx, enableWebhook, tenantID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Webhook{}, ErrNotFound
+	}
+	if err != nil {
+		return Webhook{}, fmt.Errorf("enable webhook: %w", err)
+	}
+	return w, nil
+}
+
+func (r *PostgresRepository) MarkHealthy(ctx context.Context, webhookID string) error {
+	if _, err := r.pool.Exec(ctx, markHealthy, webhookID); err != nil {
+		return fmt.Errorf("mark webhook healthy: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) MarkFailing(ctx context.Context, webhookID string, now time.Time) (int, time.Time, error) {
+	var (
+		failures int
+		since    time.Time
+	)
+	if err := r.pool.QueryRow(ctx, markFailing, webhookID, now).Scan(&failures, &since); err != nil {
+		return 0, time.Time{}, fmt.Errorf("mark webhook failing: %w", err)
+	}
+	return failures, since, nil
+}
+
+func (r *PostgresRepository) Disable(ctx context.Context, webhookID, reason string) error {
+	if _, err := r.pool.Exec(ctx, disableWebhook, webhookID, reason); err != nil {
+		return fmt.Errorf("disable webhook: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Enqueue(ctx context.Context, deliveries []Delivery) error {
+	batch := &pgx.Batch{}
+	for _, d := range deliveries {
+		batch.Queue(insertDelivery,
+			d.ID,
+			d.WebhookID,
+			d.TenantID,
+			d.EventKey,
+			d.EventType,
+			d.MessageID,
+			[]byte(d.Payload),
+			d.Status,
+			d.Attempts,
+			d.ResponseStatus,
+			d.Error,
+			d.NextAttemptAt,
+		)
+	}
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("insert webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	rows, err := r.pool.Query(ctx, claimDeliveries, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	var deliveries []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows, true)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *PostgresRepository) RecordAttempt(ctx context.Context, d Delivery) error {
+	if _, err := r.pool.Exec(ctx, updateDelivery, d.ID, d.Status, d.Attempts, d.ResponseStatus, d.Error, d.NextAttemptAt); err != nil {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListDeliveries(ctx context.Context, tenantID, webhookID string, limit int) ([]Delivery, error) {
+	rows, err := r.pool.Query(ctx, selectDeliveries, tenantID, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	var deliveries []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows, false)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *PostgresRepository) queryWebhooks(ctx context.Context, query, tenantID string) ([]Webhook, error) {
+	rows, err := r.pool.Query(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("select webhooks: %w", err)
+	}
+	defer rows.Close()
+	var webhooks []Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func scanWebhook(row pgx.Row) (Webhook, error) {
+	var w Webhook
+	err := row.Scan(
+		&w.ID,
+		&w.TenantID,
+		&w.URL,
+		&w.Secret,
+		&w.Events,
+		&w.Enabled,
+		&w.ConsecutiveFailures,
+		&w.FailingSince,
+		&w.DisabledReason,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	return w, err
+}
+
+// scanDelivery scans deliveryColumns, followed by the webhook's url and
+// secret when claimed is set.
+func scanDelivery(row pgx.Row, claimed bool) (Delivery, error) {
+	var (
+		d       Delivery
+		payload []byte
+	)
+	dest := []any{
+		&d.ID,
+		&d.WebhookID,
+		&d.TenantID,
+		&d.EventType,
+		&d.MessageID,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.ResponseStatus,
+		&d.Error,
+		&d.NextAttemptAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	}
+	if claimed {
+		dest = append(dest, &d.URL, &d.Secret)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return Delivery{}, err
+	}
+	d.Payload = payload
+	return d, nil
+}
@@ -0,0 +1,137 @@
+package outbound
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-HSNP-Signature"
+	defaultTimeout  = 10 * time.Second
+	// maxResponseBytes is how much of a tenant's response is read before
+	// the connection is released.
+	maxResponseBytes = 64 << 10
+)
+
+// ErrDisallowedAddress is returned for webhook hosts that are, or resolve
+// to, loopback, private, link-local or other non-public addresses.
+var ErrDisallowedAddress = errors.New("webhook host is not a public address")
+
+// nonPublicNets are special-purpose ranges not covered by the net.IP
+// predicates used in publicIP.
+var nonPublicNets = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),
+	mustCIDR("100.64.0.0/10"),
+	mustCIDR("192.0.0.0/24"),
+	mustCIDR("198.18.0.0/15"),
+	mustCIDR("240.0.0.0/4"),
+	mustCIDR("64:ff9b::/96"),
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// publicIP reports whether webhooks may be delivered to ip.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicDialer refuses connections to non-public addresses. The check runs
+// on the address actually dialed, after DNS resolution, so a host that
+// re-resolves to an internal address between validation and delivery is
+// still refused.
+func publicDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return ErrDisallowedAddress
+			}
+			return nil
+		},
+	}
+}
+
+// Sign returns the X-HSNP-Signature value for body: the hex HMAC-SHA256 of
+// the raw body keyed by the webhook secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sender POSTs signed deliveries. Redirects are not followed, so a webhook
+// cannot bounce requests to another host, and the client built by
+// NewSender only connects to public addresses.
+type Sender struct {
+	Client *http.Client
+}
+
+func NewSender() *Sender {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the webhook host, bypassing the
+	// address check.
+	transport.Proxy = nil
+	transport.DialContext = publicDialer().DialContext
+	return &Sender{Client: &http.Client{
+		Transport: transport,
+		Timeout:   defaultTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Send POSTs d to its webhook and returns the response status. Any status
+// outside 2xx is an error.
+func (s *Sender) Send(ctx context.Context, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "HSNP-Webhooks/1.0")
+	req.Header.Set(SignatureHeader, Sign(d.Secret, d.Payload))
+	req.Header.Set("X-HSNP-Event", d.EventType)
+	req.Header.Set("X-HSNP-Delivery", d.ID)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
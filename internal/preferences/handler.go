@@ -0,0 +1,168 @@
+package preferences
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+var confirmPage = template.Must(template.New("confirm").Parse(`<!doctype html>
+<html><body>
+<form method="post" action="/v1/unsubscribe?token={{.}}">
+<p>Unsubscribe from these notifications?</p>
+<button type="submit" name="List-Unsubscribe" value="One-Click">Unsubscribe</button>
+</form>
+</body></html>
+`))
+
+type Handler struct {
+	repo   Repository
+	signer *Signer
+	tracer trace.Tracer
+	logger zerolog.Logger
+}
+
+func NewHandler(repo Repository, signer *Signer, logger zerolog.Logger) *Handler {
+	return &Handler{
+		repo:   repo,
+		signer: signer,
+		tracer: otel.Tracer("preferences"),
+		logger: logger,
+	}
+}
+
+func (h *Handler) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/v1/preferences/{recipient}", h.get)
+	r.Put("/v1/preferences/{recipient}", h.update)
+	r.Get("/v1/unsubscribe", h.confirmUnsubscribe)
+	r.Post("/v1/unsubscribe", h.unsubscribe)
+	return r
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "get-preferences")
+	defer span.End()
+
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	recipient := chi.URLParam(r, "recipient")
+	prefs, err := h.repo.List(ctx, tenantID, recipient)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"recipient": recipient, "preferences": prefs})
+}
+
+type updateRequest struct {
+	Preferences []struct {
+		Category string `json:"category"`
+		Channel  string `json:"channel"`
+		OptedOut bool   `json:"opted_out"`
+	} `json:"preferences"`
+}
+
+func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "update-preferences")
+	defer span.End()
+
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var req updateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Preferences) == 0 {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("preferences are required"))
+		return
+	}
+
+	recipient := chi.URLParam(r, "recipient")
+	saved := make([]Preference, 0, len(req.Preferences))
+	for _, p := range req.Preferences {
+		pref, err := h.repo.Upsert(ctx, Preference{
+			TenantID:  tenantID,
+			Recipient: recipient,
+			Category:  orAny(p.Category),
+			Channel:   orAny(p.Channel),
+			OptedOut:  p.OptedOut,
+			Source:    "api",
+		})
+		if err != nil {
+			h.respondErr(ctx, w, http.StatusInternalServerError, err)
+			return
+		}
+		saved = append(saved, pref)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"recipient": recipient, "preferences": saved})
+}
+
+// confirmUnsubscribe never changes state: link scanners and prefetchers
+// issue GETs, so the opt-out only happens on POST.
+func (h *Handler) confirmUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if _, err := h.signer.Verify(token); err != nil {
+		h.respondErr(r.Context(), w, http.StatusBadRequest, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = confirmPage.Execute(w, token)
+}
+
+// unsubscribe handles both the RFC 8058 one-click POST sent by mailbox
+// providers and the confirmation form.
+func (h *Handler) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "unsubscribe")
+	defer span.End()
+
+	claims, err := h.signer.Verify(r.URL.Query().Get("token"))
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := h.repo.Upsert(ctx, Preference{
+		TenantID:  claims.TenantID,
+		Recipient: claims.Recipient,
+		Category:  orAny(claims.Category),
+		Channel:   orAny(claims.Channel),
+		OptedOut:  true,
+		Source:    "unsubscribe-link",
+	}); err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("You have been unsubscribed.\n"))
+}
+
+func (h *Handler) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
+	logger := common.WithContext(ctx, h.logger)
+	logger.Error().Err(err).Int("status", status).Msg("preferences handler failed")
+	http.Error(w, err.Error(), status)
+}
+
+func orAny(v string) string {
+	if v == "" {
+		return Any
+	}
+	return v
+}
@@ -0,0 +1,74 @@
+package preferences
+
+import (
+	"context"
+	"time"
+)
+
+// Any matches every category or channel in a preference record.
+const Any = "*"
+
+// Preference is a recipient's opt-in or opt-out for one category and
+// channel. Recipients are identified by their channel address.
+type Preference struct {
+	TenantID  string    `json:"tenant_id"`
+	Recipient string    `json:"recipient"`
+	Category  string    `json:"category"`
+	Channel   string    `json:"channel"`
+	OptedOut  bool      `json:"opted_out"`
+	Source    string    `json:"source,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Repository interface {
+	List(ctx context.Context, tenantID, recipient string) ([]Preference, error)
+	Upsert(ctx context.Context, pref Preference) (Preference, error)
+}
+
+// OptedOut reports whether prefs suppress a message of category on channel.
+// The most specific matching record wins: an exact category beats Any, and
+// then an exact channel beats Any. Messages without a category are only
+// affected by Any-category records.
+func OptedOut(prefs []Preference, category, channel string) bool {
+	best, bestScore := false, -1
+	for _, p := range prefs {
+		score := 0
+		switch p.Category {
+		case Any:
+		case category:
+			if category == "" {
+				continue
+			}
+			score += 2
+		default:
+			continue
+		}
+		switch p.Channel {
+		case Any:
+		case channel:
+			score++
+		default:
+			continue
+		}
+		if score > bestScore {
+			best, bestScore = p.OptedOut, score
+		}
+	}
+	return best
+}
+
+// Checker answers suppression questions for the dispatcher.
+type Checker struct {
+	Repo Repository
+}
+
+func (c *Checker) Suppressed(ctx context.Context, tenantID, recipient, category, channel string) (bool, error) {
+	if recipient == "" {
+		return false, nil
+	}
+	prefs, err := c.Repo.List(ctx, tenantID, recipient)
+	if err != nil {
+		return false, err
+	}
+	return OptedOut(prefs, category, channel), nil
+}
@@ -0,0 +1,69 @@
+package preferences
+
+import "testing"
+
+func TestOptedOut(t *testing.T) {
+	prefs := []Preference{
+		{Category: Any, Channel: Any, OptedOut: true},
+		{Category: "security", Channel: Any, OptedOut: false},
+		{Category: "marketing", Channel: "sms", OptedOut: true},
+		{Category: "marketing", Channel: Any, OptedOut: false},
+	}
+
+	cases := []struct {
+		name     string
+		prefs    []Preference
+		category string
+		channel  string
+		want     bool
+	}{
+		{name: "no preferences", prefs: nil, category: "marketing", channel: "email", want: false},
+		{name: "global opt-out", prefs: prefs[:1], category: "marketing", channel: "email", want: true},
+		{name: "uncategorised follows global", prefs: prefs, category: "", channel: "email", want: true},
+		{name: "category opt-in beats global", prefs: prefs, category: "security", channel: "email", want: false},
+		{name: "channel specific beats category", prefs: prefs, category: "marketing", channel: "sms", want: true},
+		{name: "category any channel", prefs: prefs, category: "marketing", channel: "email", want: false},
+		{name: "unrelated category", prefs: prefs[2:], category: "digest", channel: "sms", want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := OptedOut(tc.prefs, tc.category, tc.channel); got != tc.want {
+				t.Fatalf("OptedOut=%v, expected %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	signer := &Signer{Secret: []byte("s3cret"), BaseURL: "https://notify.example.com/"}
+	claims := Claims{TenantID: "t1", Recipient: "a@b.com", Category: "marketing", Channel: "email"}
+
+	token, err := signer.Token(claims)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := signer.Verify(token)
+	if err != nil || got != claims {
+		t.Fatalf("Verify=%+v, %v", got, err)
+	}
+
+	if _, err := signer.Verify(token + "x"); err == nil {
+		t.Fatalf("expected tampered token to fail")
+	}
+	other := &Signer{Secret: []byte("other")}
+	if _, err := other.Verify(token); err == nil {
+		t.Fatalf("expected token signed with another secret to fail")
+	}
+
+	headers, err := signer.Headers(claims)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if headers["List-Unsubscribe-Post"] != "List-Unsubscribe=One-Click" {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+	if want := "<https://notify.example.com/v1/unsubscribe?token="; len(headers["List-Unsubscribe"]) < len(want) || headers["List-Unsubscribe"][:len(want)] != want {
+		t.Fatalf("unexpected List-Unsubscribe: %s", headers["List-Unsubscribe"])
+	}
+}
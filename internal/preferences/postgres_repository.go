@@ -0,0 +1,71 @@
+package preferences
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const selectPreferences = `
+SELECT tenant_id, recipient, category, channel, opted_out, source, updated_at
+FROM recipient_preferences
+WHERE tenant_id = $1 AND recipient = $2
+ORDER BY category, channel
+`
+
+const upsertPreference = `
+INSERT INTO recipient_preferences (
+tenant_id,
+recipient,
+category,
+channel,
+opted_out,
+source,
+updated_at
+) VALUES ($1,$2,$3,$4,$5,$6,now())
+ON CONFLICT (tenant_id, recipient, category, channel)
+DO UPDATE SET opted_out = EXCLUDED.opted_out, source = EXCLUDED.source, updated_at = EXCLUDED.updated_at
+RETURNING updated_at
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) List(ctx context.Context, tenantID, recipient string) ([]Preference, error) {
+	rows, err := r.pool.Query(ctx, selectPreferences, tenantID, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("list preferences: %w", err)
+	}
+	defer rows.Close()
+
+	var prefs []Preference
+	for rows.Next() {
+		var p Preference
+		if err := rows.Scan(&p.TenantID, &p.Recipient, &p.Category, &p.Channel, &p.OptedOut, &p.Source, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan preference: %w", err)
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, rows.Err()
+}
+
+func (r *PostgresRepository) Upsert(ctx context.Context, pref Preference) (Preference, error) {
+	row := r.pool.QueryRow(ctx, upsertPreference,
+		pref.TenantID,
+		pref.Recipient,
+		pref.Category,
+		pref.Channel,
+		pref.OptedOut,
+		pref.Source,
+	)
+	if err := row.Scan(&pref.UpdatedAt); err != nil {
+		return Preference{}, fmt.Errorf("upsert preference: %w", err)
+	}
+	return pref, nil
+}
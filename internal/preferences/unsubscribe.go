@@ -0,0 +1,88 @@
+package preferences
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidToken = errors.New("invalid unsubscribe token")
+
+// Claims identify what an unsubscribe link opts the recipient out of.
+type Claims struct {
+	TenantID  string `json:"t"`
+	Recipient string `json:"r"`
+	Category  string `json:"c"`
+	Channel   string `json:"ch"`
+}
+
+// Signer issues and verifies HMAC-signed one-click unsubscribe links.
+type Signer struct {
+	Secret []byte
+	// BaseURL is the public origin serving /v1/unsubscribe.
+	BaseURL string
+}
+
+func (s *Signer) Token(c Claims) (string, error) {
+	if len(s.Secret) == 0 {
+		return "", errors.New("unsubscribe signer requires a secret")
+	}
+	body, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(body)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded)), nil
+}
+
+func (s *Signer) Verify(token string) (Claims, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok || len(s.Secret) == 0 {
+		return Claims{}, ErrInvalidToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.sign(encoded)) {
+		return Claims{}, ErrInvalidToken
+	}
+	body, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var c Claims
+	if err := json.Unmarshal(body, &c); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	return c, nil
+}
+
+// URL returns the unsubscribe link for c.
+func (s *Signer) URL(c Claims) (string, error) {
+	token, err := s.Token(c)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(s.BaseURL, "/") + "/v1/unsubscribe?token=" + url.QueryEscape(token), nil
+}
+
+// Headers returns the RFC 2369 List-Unsubscribe and RFC 8058
+// List-Unsubscribe-Post headers for c.
+func (s *Signer) Headers(c Claims) (map[string]string, error) {
+	link, err := s.URL(c)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"List-Unsubscribe":      "<" + link + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}, nil
+}
+
+func (s *Signer) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,83 @@
+package quiethours
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+type Handler struct {
+	repo   Repository
+	logger zerolog.Logger
+}
+
+func NewHandler(repo Repository, logger zerolog.Logger) *Handler {
+	return &Handler{repo: repo, logger: logger}
+}
+
+func (h *Handler) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/v1/quiet-hours", h.get)
+	r.Put("/v1/quiet-hours", h.replace)
+	return r
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	windows, err := h.repo.List(ctx, tenantID)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"windows": windows})
+}
+
+func (h *Handler) replace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var req struct {
+		Windows []Window `json:"windows"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	for i := range req.Windows {
+		req.Windows[i].TenantID = tenantID
+		if req.Windows[i].Channel == "" {
+			req.Windows[i].Channel = AnyChannel
+		}
+		if err := req.Windows[i].Validate(); err != nil {
+			h.respondErr(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := h.repo.Replace(ctx, tenantID, req.Windows); err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"windows": req.Windows})
+}
+
+func (h *Handler) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
+	logger := common.WithContext(ctx, h.logger)
+	logger.Error().Err(err).Int("status", status).Msg("quiet hours handler failed")
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,106 @@
+package quiethours
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AnyChannel applies a window to every channel.
+const AnyChannel = "*"
+
+// Window is a daily span of recipient-local time during which non-urgent
+// messages are held. Start and End are "HH:MM"; a window whose end is before
+// its start wraps past midnight (22:00–08:00).
+type Window struct {
+	TenantID string `json:"tenant_id"`
+	Channel  string `json:"channel"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+}
+
+type Repository interface {
+	List(ctx context.Context, tenantID string) ([]Window, error)
+	// Replace swaps the tenant's whole window set.
+	Replace(ctx context.Context, tenantID string, windows []Window) error
+}
+
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w Window) Validate() error {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return fmt.Errorf("window %s-%s is empty", w.Start, w.End)
+	}
+	return nil
+}
+
+// end returns when the window containing local ends, or false if local is
+// outside the window.
+func (w Window) end(local time.Time) (time.Time, bool) {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return time.Time{}, false
+	}
+	minute := local.Hour()*60 + local.Minute()
+	// Built from the wall clock rather than midnight plus a duration, so the
+	// end stays at End on days with a DST change.
+	endOn := func(days int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, end/60, end%60, 0, 0, local.Location())
+	}
+
+	switch {
+	case start < end && minute >= start && minute < end:
+		return endOn(0), true
+	case start > end && minute >= start:
+		return endOn(1), true
+	case start > end && minute < end:
+		return endOn(0), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// DeferUntil reports when a message on channel may be sent if now falls in
+// one of windows for a recipient in loc. Overlapping windows are chained so
+// the result is outside all of them.
+func DeferUntil(windows []Window, channel string, now time.Time, loc *time.Location) (time.Time, bool) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	deferred := false
+	// Bounded so misconfigured windows covering the whole day cannot loop.
+	for i := 0; i < len(windows)+1; i++ {
+		moved := false
+		for _, w := range windows {
+			if w.Channel != AnyChannel && w.Channel != channel {
+				continue
+			}
+			if end, ok := w.end(local); ok {
+				local, moved, deferred = end, true, true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return local.UTC(), deferred
+}
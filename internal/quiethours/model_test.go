@@ -0,0 +1,69 @@
+package quiethours
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeferUntil(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	windows := []Window{
+		{Channel: AnyChannel, Start: "22:00", End: "08:00"},
+		{Channel: "sms", Start: "08:00", End: "09:30"},
+		{Channel: "push", Start: "13:00", End: "14:00"},
+	}
+	at := func(loc *time.Location, day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, loc)
+	}
+
+	cases := []struct {
+		name      string
+		channel   string
+		now       time.Time
+		loc       *time.Location
+		wantUntil time.Time
+		wantDefer bool
+	}{
+		{name: "outside window", channel: "email", now: at(time.UTC, 10, 12, 0), loc: time.UTC},
+		{name: "before midnight", channel: "email", now: at(time.UTC, 10, 23, 15), loc: time.UTC, wantUntil: at(time.UTC, 11, 8, 0), wantDefer: true},
+		{name: "after midnight", channel: "email", now: at(time.UTC, 11, 2, 0), loc: time.UTC, wantUntil: at(time.UTC, 11, 8, 0), wantDefer: true},
+		{name: "chained sms window", channel: "sms", now: at(time.UTC, 11, 2, 0), loc: time.UTC, wantUntil: at(time.UTC, 11, 9, 30), wantDefer: true},
+		{name: "channel specific", channel: "push", now: at(time.UTC, 10, 13, 30), loc: time.UTC, wantUntil: at(time.UTC, 10, 14, 0), wantDefer: true},
+		{name: "other channel unaffected", channel: "email", now: at(time.UTC, 10, 13, 30), loc: time.UTC},
+		{name: "end is exclusive", channel: "email", now: at(time.UTC, 10, 8, 0), loc: time.UTC},
+		{name: "recipient timezone", channel: "email", now: at(time.UTC, 11, 3, 0), loc: saoPaulo, wantUntil: at(saoPaulo, 11, 8, 0), wantDefer: true},
+		{name: "dst starts overnight", channel: "email", now: at(newYork, 9, 23, 0), loc: newYork, wantUntil: at(newYork, 10, 8, 0), wantDefer: true},
+		{name: "dst day after midnight", channel: "email", now: at(newYork, 10, 4, 0), loc: newYork, wantUntil: at(newYork, 10, 8, 0), wantDefer: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			until, deferred := DeferUntil(windows, tc.channel, tc.now, tc.loc)
+			if deferred != tc.wantDefer {
+				t.Fatalf("deferred=%v, expected %v", deferred, tc.wantDefer)
+			}
+			if deferred && !until.Equal(tc.wantUntil) {
+				t.Fatalf("until=%s, expected %s", until, tc.wantUntil.UTC())
+			}
+		})
+	}
+}
+
+func TestWindowValidate(t *testing.T) {
+	if err := (Window{Start: "22:00", End: "08:00"}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := (Window{Start: "25:00", End: "08:00"}).Validate(); err == nil {
+		t.Fatalf("expected invalid time error")
+	}
+	if err := (Window{Start: "08:00", End: "08:00"}).Validate(); err == nil {
+		t.Fatalf("expected empty window error")
+	}
+}
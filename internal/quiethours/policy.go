@@ -0,0 +1,67 @@
+package quiethours
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultCacheTTL = time.Minute
+
+// Policy evaluates tenant quiet hours, caching each tenant's windows so the
+// dispatcher does not query Postgres per message.
+type Policy struct {
+	Repo Repository
+	TTL  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedWindows
+}
+
+type cachedWindows struct {
+	windows []Window
+	expires time.Time
+}
+
+// DeferUntil returns the time a message may be released when now falls in a
+// quiet window for the recipient's timezone. Unknown timezones use UTC.
+func (p *Policy) DeferUntil(ctx context.Context, tenantID, channel, timezone string, now time.Time) (time.Time, bool, error) {
+	windows, err := p.windows(ctx, tenantID, now)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if len(windows) == 0 {
+		return time.Time{}, false, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" {
+		loc = time.UTC
+	}
+	until, deferred := DeferUntil(windows, channel, now, loc)
+	return until, deferred, nil
+}
+
+func (p *Policy) windows(ctx context.Context, tenantID string, now time.Time) ([]Window, error) {
+	p.mu.Lock()
+	cached, ok := p.cache[tenantID]
+	p.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.windows, nil
+	}
+
+	windows, err := p.Repo.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	ttl := p.TTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	p.mu.Lock()
+	if p.cache == nil {
+		p.cache = map[string]cachedWindows{}
+	}
+	p.cache[tenantID] = cachedWindows{windows: windows, expires: now.Add(ttl)}
+	p.mu.Unlock()
+	return windows, nil
+}
@@ -0,0 +1,64 @@
+package quiethours
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const selectWindows = `
+SELECT tenant_id, channel, start_time, end_time
+FROM quiet_hours
+WHERE tenant_id = $1
+ORDER BY channel, start_time
+`
+
+const deleteWindows = `
+DELETE FROM quiet_hours WHERE tenant_id = $1
+`
+
+const insertWindow = `
+INSERT INTO quiet_hours (tenant_id, channel, start_time, end_time) VALUES ($1,$2,$3,$4)
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) List(ctx context.Context, tenantID string) ([]Window, error) {
+	rows, err := r.pool.Query(ctx, selectWindows, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list quiet hours: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []Window
+	for rows.Next() {
+		var w Window
+		if err := rows.Scan(&w.TenantID, &w.Channel, &w.Start, &w.End); err != nil {
+			return nil, fmt.Errorf("scan quiet hours: %w", err)
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+func (r *PostgresRepository) Replace(ctx context.Context, tenantID string, windows []Window) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, deleteWindows, tenantID); err != nil {
+			return fmt.Errorf("delete quiet hours: %w", err)
+		}
+		for _, w := range windows {
+			if _, err := tx.Exec(ctx, insertWindow, tenantID, w.Channel, w.Start, w.End); err != nil {
+				return fmt.Errorf("insert quiet hours: %w", err)
+			}
+		}
+		return nil
+	})
+}
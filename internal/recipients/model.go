@@ -0,0 +1,81 @@
+package recipients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrNotFound = errors.New("recipient not found")
+
+// Profile maps a tenant's external user id to the addresses and settings
+// used to reach that user.
+type Profile struct {
+	TenantID   string         `json:"tenant_id"`
+	UserID     string         `json:"user_id"`
+	Email      string         `json:"email,omitempty"`
+	Phone      string         `json:"phone,omitempty"`
+	PushTokens []string       `json:"push_tokens,omitempty"`
+	Locale     string         `json:"locale,omitempty"`
+	Timezone   string         `json:"timezone,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+type Repository interface {
+	Get(ctx context.Context, tenantID, userID string) (Profile, error)
+	Upsert(ctx context.Context, p Profile) (Profile, error)
+	Delete(ctx context.Context, tenantID, userID string) error
+	// List pages through a tenant's profiles ordered by user id, starting
+	// after the given id.
+	List(ctx context.Context, tenantID, after string, limit int) ([]Profile, error)
+}
+
+func (p Profile) Validate() error {
+	if p.UserID == "" {
+		return errors.New("user_id is required")
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q", p.Timezone)
+		}
+	}
+	return nil
+}
+
+// Address fills in the channel's address on a copy of to from the profile.
+// Addresses already present in to win, so callers can still override the
+// profile per message. It reports false when the channel has no address.
+func (p Profile) Address(channel string, to map[string]any) (map[string]any, bool) {
+	resolved := make(map[string]any, len(to)+1)
+	for k, v := range to {
+		resolved[k] = v
+	}
+
+	var key, value string
+	switch channel {
+	case "email":
+		key, value = "email", p.Email
+	case "sms", "whatsapp":
+		key, value = "phone", p.Phone
+	case "push":
+		key = "push_token"
+		if len(p.PushTokens) > 0 {
+			value = p.PushTokens[0]
+			if _, ok := resolved["push_tokens"]; !ok {
+				resolved["push_tokens"] = p.PushTokens
+			}
+		}
+	case "in_app":
+		key, value = "user_id", p.UserID
+	default:
+		return resolved, false
+	}
+	if current, _ := resolved[key].(string); current == "" && value != "" {
+		resolved[key] = value
+	}
+	address, _ := resolved[key].(string)
+	return resolved, address != ""
+}
@@ -0,0 +1,54 @@
+package recipients
+
+import "testing"
+
+func TestProfileAddress(t *testing.T) {
+	profile := Profile{
+		UserID:     "u1",
+		Email:      "a@b.com",
+		Phone:      "+15550100",
+		PushTokens: []string{"tok1", "tok2"},
+	}
+
+	tests := []struct {
+		name    string
+		profile Profile
+		channel string
+		to      map[string]any
+		key     string
+		want    string
+		ok      bool
+	}{
+		{"email", profile, "email", map[string]any{"user_id": "u1"}, "email", "a@b.com", true},
+		{"sms", profile, "sms", map[string]any{"user_id": "u1"}, "phone", "+15550100", true},
+		{"whatsapp", profile, "whatsapp", map[string]any{"user_id": "u1"}, "phone", "+15550100", true},
+		{"push uses first token", profile, "push", map[string]any{"user_id": "u1"}, "push_token", "tok1", true},
+		{"in_app", profile, "in_app", map[string]any{"user_id": "u1"}, "user_id", "u1", true},
+		{"explicit address wins", profile, "email", map[string]any{"user_id": "u1", "email": "override@b.com"}, "email", "override@b.com", true},
+		{"missing address", Profile{UserID: "u1"}, "sms", map[string]any{"user_id": "u1"}, "phone", "", false},
+		{"unknown channel", profile, "fax", map[string]any{"user_id": "u1"}, "fax", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.profile.Address(tt.channel, tt.to)
+			if ok != tt.ok {
+				t.Fatalf("ok=%v, want %v", ok, tt.ok)
+			}
+			if address, _ := got[tt.key].(string); address != tt.want {
+				t.Fatalf("%s=%q, want %q", tt.key, address, tt.want)
+			}
+			if got["user_id"] != "u1" {
+				t.Fatalf("user_id not preserved: %v", got)
+			}
+		})
+	}
+}
+
+func TestProfileAddressDoesNotModifyInput(t *testing.T) {
+	to := map[string]any{"user_id": "u1"}
+	Profile{UserID: "u1", Email: "a@b.com"}.Address("email", to)
+	if _, ok := to["email"]; ok {
+		t.Fatal("Address modified its input")
+	}
+}
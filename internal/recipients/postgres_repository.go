@@ -0,0 +1,133 @@
+package recipients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const profileColumns = `tenant_id, user_id, email, phone, push_tokens, locale, timezone, attributes_json, created_at, updated_at`
+
+const selectProfile = `
+SELECT ` + profileColumns + `
+FROM recipients
+WHERE tenant_id = $1 AND user_id = $2
+`
+
+const upsertProfile = `
+INSERT INTO recipients (tenant_id, user_id, email, phone, push_tokens, locale, timezone, attributes_json, created_at, updated_at)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8, now(), now())
+ON CONFLICT (tenant_id, user_id)
+DO UPDATE SET
+email = EXCLUDED.email,
+phone = EXCLUDED.phone,
+push_tokens = EXCLUDED.push_tokens,
+locale = EXCLUDED.locale,
+timezone = EXCLUDED.timezone,
+attributes_json = EXCLUDED.attributes_json,
+updated_at = now()
+RETURNING ` + profileColumns
+
+const deleteProfile = `
+DELETE FROM recipients WHERE tenant_id = $1 AND user_id = $2
+`
+
+const selectProfiles = `
+SELECT ` + profileColumns + `
+FROM recipients
+WHERE tenant_id = $1 AND user_id > $2
+ORDER BY user_id
+LIMIT $3
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, tenantID, userID string) (Profile, error) {
+	p, err := scanProfile(r.pool.QueryRow(ctx, selectProfile, tenantID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Profile{}, ErrNotFound
+	}
+	if err != nil {
+		return Profile{}, fmt.Errorf("get recipient: %w", err)
+	}
+	return p, nil
+}
+
+func (r *PostgresRepository) Upsert(ctx context.Context, p Profile) (Profile, error) {
+	attributes, err := json.Marshal(p.Attributes)
+	if err != nil {
+		return Profile{}, fmt.Errorf("marshal recipient attributes: %w", err)
+	}
+	if p.PushTokens == nil {
+		p.PushTokens = []string{}
+	}
+	saved, err := scanProfile(r.pool.QueryRow(ctx, upsertProfile,
+		p.TenantID,
+		p.UserID,
+		p.Email,
+		p.Phone,
+		p.PushTokens,
+		p.Locale,
+		p.Timezone,
+		attributes,
+	))
+	if err != nil {
+		return Profile{}, fmt.Errorf("upsert recipient: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PostgresRepository) Delete(ctx context.Context, tenantID, userID string) error {
+	tag, err := r.pool.Exec(ctx, deleteProfile, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("delete recipient: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) List(ctx context.Context, tenantID, after string, limit int) ([]Profile, error) {
+	rows, err := r.pool.Query(ctx, selectProfiles, tenantID, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []Profile
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan recipient: %w", err)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+func scanProfile(row pgx.Row) (Profile, error) {
+	var (
+		p          Profile
+		attributes []byte
+	)
+	if err := row.Scan(&p.TenantID, &p.UserID, &p.Email, &p.Phone, &p.PushTokens, &p.Locale, &p.Timezone, &attributes, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return Profile{}, err
+	}
+	if len(attributes) > 0 {
+		if err := json.Unmarshal(attributes, &p.Attributes); err != nil {
+			return Profile{}, err
+		}
+	}
+	return p, nil
+}
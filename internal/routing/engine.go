@@ -0,0 +1,109 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+)
+
+const defaultRefreshInterval = 30 * time.Second
+
+var (
+	rulesLoaded = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "routing_rules_loaded",
+		Help: "Routing rules in the active table, including defaults",
+	})
+	routingDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "routing_decisions_total",
+		Help: "Messages routed, by matching rule",
+	}, []string{"rule_id"})
+)
+
+// Engine routes messages with a table rebuilt from its sources on every
+// refresh, so rule changes take effect without a restart. Until the first
+// successful refresh, and if a refresh fails, the previous table stays in
+// use.
+type Engine struct {
+	Sources []Source
+	// Defaults are appended to the loaded rules; DefaultRules when nil.
+	Defaults        []Rule
+	Fallback        string
+	RefreshInterval time.Duration
+	Logger          zerolog.Logger
+
+	table atomic.Pointer[Table]
+}
+
+func (e *Engine) defaults() []Rule {
+	if e.Defaults == nil {
+		return DefaultRules()
+	}
+	return e.Defaults
+}
+
+func (e *Engine) current() *Table {
+	if t := e.table.Load(); t != nil {
+		return t
+	}
+	return NewTable(e.defaults(), e.Fallback)
+}
+
+func (e *Engine) Refresh(ctx context.Context) error {
+	var rules []Rule
+	for _, src := range e.Sources {
+		loaded, err := src.ListRules(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range loaded {
+			if err := r.Validate(); err != nil {
+				return fmt.Errorf("routing rule %s: %w", r.ID, err)
+			}
+		}
+		rules = append(rules, loaded...)
+	}
+	rules = append(rules, e.defaults()...)
+	e.table.Store(NewTable(rules, e.Fallback))
+	rulesLoaded.Set(float64(len(rules)))
+	return nil
+}
+
+// Run refreshes the table until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context) error {
+	interval := e.RefreshInterval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := e.Refresh(ctx); err != nil {
+				e.Logger.Error().Err(err).Msg("failed to reload routing rules, keeping previous table")
+			}
+		}
+	}
+}
+
+func (e *Engine) Route(msg Message) Decision {
+	d := e.current().Route(msg)
+	ruleID := d.RuleID
+	if !d.Matched {
+		ruleID = "fallback"
+	}
+	routingDecisions.WithLabelValues(ruleID).Inc()
+	return d
+}
+
+// Explain is a dry run of Route: nothing is recorded.
+func (e *Engine) Explain(msg Message) Explanation {
+	return e.current().Explain(msg)
+}
@@ -0,0 +1,28 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// FileSource reads rules from a JSON file of the form {"rules": [...]}. The
+// file is re-read on every refresh, so editing it reloads the rules.
+type FileSource struct {
+	Path string
+}
+
+func (f FileSource) ListRules(ctx context.Context) ([]Rule, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read routing rules: %w", err)
+	}
+	var doc struct {
+		Rules []Rule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode routing rules %s: %w", f.Path, err)
+	}
+	return doc.Rules, nil
+}
@@ -0,0 +1,135 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+type Handler struct {
+	repo   Repository
+	engine *Engine
+	logger zerolog.Logger
+}
+
+// NewHandler serves tenant rule management and explain. engine should load
+// from repo so explain reflects rule changes made through the handler.
+func NewHandler(repo Repository, engine *Engine, logger zerolog.Logger) *Handler {
+	return &Handler{repo: repo, engine: engine, logger: logger}
+}
+
+func (h *Handler) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/v1/routing/rules", h.list)
+	r.Post("/v1/routing/rules", h.create)
+	r.Delete("/v1/routing/rules/{id}", h.remove)
+	r.Post("/v1/routing/explain", h.explain)
+	return r
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	rules, err := h.repo.List(ctx, tenantID)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"rules": rules})
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var rule Rule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	// Tenants can only route their own traffic, and only to dispatch
+	// topics; global rules come from the rules file. Lanes follow the
+	// topic, so a rule never sends priorities to lanes nobody reads.
+	rule.ID = uuid.NewString()
+	rule.TenantID = tenantID
+	rule.Lanes = HasLanes(rule.Topic)
+	if err := rule.ValidateTenant(); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	created, err := h.repo.Create(ctx, rule)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	h.reload(ctx)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(created)
+}
+
+func (h *Handler) remove(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	if err := h.repo.Delete(ctx, tenantID, chi.URLParam(r, "id")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		h.respondErr(ctx, w, status, err)
+		return
+	}
+	h.reload(ctx)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// explain is a dry run: it reports which rule a message would match and why
+// the rules ahead of it did not, without publishing anything.
+func (h *Handler) explain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var msg Message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	msg.TenantID = tenantID
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.engine.Explain(msg))
+}
+
+func (h *Handler) reload(ctx context.Context) {
+	if err := h.engine.Refresh(ctx); err != nil {
+		logger := common.WithContext(ctx, h.logger)
+		logger.Warn().Err(err).Msg("failed to reload routing rules")
+	}
+}
+
+func (h *Handler) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
+	logger := common.WithContext(ctx, h.logger)
+	logger.Error().Err(err).Int("status", status).Msg("routing handler failed")
+	http.Error(w, err.Error(), status)
+}
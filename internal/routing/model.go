@@ -0,0 +1,212 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+// Any matches every value of a rule field. An empty field means the same.
+const Any = "*"
+
+// DefaultFallback receives messages no rule matches.
+const DefaultFallback = "dlq.notifications"
+
+// TenantTopicPrefix is the only topic family tenant rules may route to;
+// other topics (notifications, provider.events, ...) are internal.
+const TenantTopicPrefix = "dispatch."
+
+var ErrNotFound = errors.New("routing rule not found")
+
+// Rule selects a destination topic for the messages it matches. Rules are
+// evaluated in ascending Position and the first match wins; Metadata
+// entries must all equal the message's metadata values.
+type Rule struct {
+	ID         string            `json:"id"`
+	TenantID   string            `json:"tenant_id"`
+	Channel    string            `json:"channel"`
+	TemplateID string            `json:"template_id"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Topic      string            `json:"topic"`
+	// Lanes sends non-normal priorities to the topic's .critical, .high and
+	// .bulk lane topics. Only set it for topics whose consumer reads them.
+	Lanes     bool      `json:"lanes,omitempty"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Message is the part of a notification that rules can match on.
+type Message struct {
+	TenantID   string         `json:"tenant_id"`
+	Channel    string         `json:"channel"`
+	TemplateID string         `json:"template_id"`
+	Priority   string         `json:"priority,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+}
+
+// Source supplies rules to an Engine.
+type Source interface {
+	ListRules(ctx context.Context) ([]Rule, error)
+}
+
+type Repository interface {
+	Source
+	List(ctx context.Context, tenantID string) ([]Rule, error)
+	Create(ctx context.Context, rule Rule) (Rule, error)
+	Delete(ctx context.Context, tenantID, id string) error
+}
+
+// defaultPosition puts the built-in channel rules after anything configured.
+const defaultPosition = 1000
+
+// laneTopics are the base topics whose workers consume priority lanes.
+var laneTopics = map[string]bool{"dispatch.email": true}
+
+// HasLanes reports whether topic's consumer also reads its priority lanes,
+// i.e. whether rules routing to it should set Lanes.
+func HasLanes(topic string) bool {
+	return laneTopics[topic]
+}
+
+// DefaultRules reproduces the historical one-topic-per-channel routing.
+func DefaultRules() []Rule {
+	topics := []struct{ channel, topic string }{
+		{"email", "dispatch.email"},
+		{"sms", "dispatch.sms"},
+		{"push", "dispatch.push"},
+		{"whatsapp", "dispatch.wa"},
+		{"in_app", "dispatch.inapp"},
+	}
+	rules := make([]Rule, 0, len(topics))
+	for _, t := range topics {
+		rules = append(rules, Rule{ID: "default-" + t.channel, Channel: t.channel, Topic: t.topic, Lanes: HasLanes(t.topic), Position: defaultPosition})
+	}
+	return rules
+}
+
+func (r Rule) Validate() error {
+	if r.Topic == "" {
+		return errors.New("topic is required")
+	}
+	return nil
+}
+
+// ValidateTenant validates a rule created through the API, which may only
+// route to channel dispatch topics.
+func (r Rule) ValidateTenant() error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(r.Topic, TenantTopicPrefix) || len(r.Topic) == len(TenantTopicPrefix) {
+		return fmt.Errorf("topic must be a %s* topic", TenantTopicPrefix)
+	}
+	return nil
+}
+
+// topic is r's destination for msg.
+func (r Rule) topic(msg Message) string {
+	if !r.Lanes {
+		return r.Topic
+	}
+	return common.PriorityTopic(r.Topic, msg.Priority)
+}
+
+// mismatch returns why r does not match msg, or "" when it does.
+func (r Rule) mismatch(msg Message) string {
+	if !matches(r.TenantID, msg.TenantID) {
+		return fmt.Sprintf("tenant_id %q does not match %q", msg.TenantID, r.TenantID)
+	}
+	if !matches(r.Channel, msg.Channel) {
+		return fmt.Sprintf("channel %q does not match %q", msg.Channel, r.Channel)
+	}
+	if !matches(r.TemplateID, msg.TemplateID) {
+		return fmt.Sprintf("template_id %q does not match %q", msg.TemplateID, r.TemplateID)
+	}
+	keys := make([]string, 0, len(r.Metadata))
+	for k := range r.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, ok := msg.Metadata[k]
+		if !ok {
+			return fmt.Sprintf("metadata %q is missing", k)
+		}
+		if got := fmt.Sprint(v); !matches(r.Metadata[k], got) {
+			return fmt.Sprintf("metadata %q value %q does not match %q", k, got, r.Metadata[k])
+		}
+	}
+	return ""
+}
+
+func matches(want, got string) bool {
+	return want == "" || want == Any || want == got
+}
+
+// Decision is where a message goes and which rule sent it there.
+type Decision struct {
+	RuleID string `json:"rule_id,omitempty"`
+	Topic  string `json:"topic"`
+	// Matched is false when no rule matched and Topic is the fallback.
+	Matched bool `json:"matched"`
+}
+
+// Evaluation records one rule's verdict during Explain.
+type Evaluation struct {
+	RuleID   string `json:"rule_id"`
+	Topic    string `json:"topic"`
+	Matched  bool   `json:"matched"`
+	Mismatch string `json:"mismatch,omitempty"`
+}
+
+type Explanation struct {
+	Decision
+	Evaluated []Evaluation `json:"evaluated"`
+}
+
+// Table is an immutable, ordered rule set.
+type Table struct {
+	rules    []Rule
+	fallback string
+}
+
+func NewTable(rules []Rule, fallback string) *Table {
+	sorted := append([]Rule(nil), rules...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+	if fallback == "" {
+		fallback = DefaultFallback
+	}
+	return &Table{rules: sorted, fallback: fallback}
+}
+
+// Route returns the first matching rule's topic, on the message's priority
+// lane when the rule has lanes, or the fallback topic.
+func (t *Table) Route(msg Message) Decision {
+	for _, r := range t.rules {
+		if r.mismatch(msg) == "" {
+			return Decision{RuleID: r.ID, Topic: r.topic(msg), Matched: true}
+		}
+	}
+	return Decision{Topic: t.fallback}
+}
+
+// Explain evaluates every rule in order, stopping at the first match, and
+// reports why each one before it did not match.
+func (t *Table) Explain(msg Message) Explanation {
+	var exp Explanation
+	for _, r := range t.rules {
+		reason := r.mismatch(msg)
+		exp.Evaluated = append(exp.Evaluated, Evaluation{RuleID: r.ID, Topic: r.Topic, Matched: reason == "", Mismatch: reason})
+		if reason == "" {
+			exp.Decision = Decision{RuleID: r.ID, Topic: r.topic(msg), Matched: true}
+			return exp
+		}
+	}
+	exp.Decision = Decision{Topic: t.fallback}
+	return exp
+}
@@ -0,0 +1,141 @@
+package routing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTableRoute(t *testing.T) {
+	rules := append([]Rule{
+		{ID: "vip", TenantID: "t1", Channel: "email", Metadata: map[string]string{"tier": "vip"}, Topic: "dispatch.email.vip", Position: 10},
+		{ID: "otp", TemplateID: "otp", Channel: "sms", Topic: "dispatch.sms.otp", Position: 20},
+		{ID: "t2-email", TenantID: "t2", Channel: "email", Topic: "dispatch.email.t2", Position: 30},
+	}, DefaultRules()...)
+	table := NewTable(rules, "")
+
+	tests := []struct {
+		name  string
+		msg   Message
+		rule  string
+		topic string
+	}{
+		{"default channel", Message{TenantID: "t1", Channel: "email"}, "default-email", "dispatch.email"},
+		{"metadata match", Message{TenantID: "t1", Channel: "email", Metadata: map[string]any{"tier": "vip"}}, "vip", "dispatch.email.vip"},
+		{"metadata mismatch", Message{TenantID: "t1", Channel: "email", Metadata: map[string]any{"tier": "free"}}, "default-email", "dispatch.email"},
+		{"other tenant", Message{TenantID: "t3", Channel: "email", Metadata: map[string]any{"tier": "vip"}}, "default-email", "dispatch.email"},
+		{"template match", Message{TenantID: "t3", Channel: "sms", TemplateID: "otp"}, "otp", "dispatch.sms.otp"},
+		{"tenant rule", Message{TenantID: "t2", Channel: "email"}, "t2-email", "dispatch.email.t2"},
+		{"priority lane", Message{TenantID: "t1", Channel: "email", Priority: "critical"}, "default-email", "dispatch.email.critical"},
+		{"no lanes", Message{TenantID: "t1", Channel: "push", Priority: "critical"}, "default-push", "dispatch.push"},
+		{"rule without lanes", Message{TenantID: "t1", Channel: "email", Priority: "bulk", Metadata: map[string]any{"tier": "vip"}}, "vip", "dispatch.email.vip"},
+		{"fallback", Message{TenantID: "t1", Channel: "fax"}, "", DefaultFallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := table.Route(tt.msg)
+			if got.RuleID != tt.rule || got.Topic != tt.topic {
+				t.Fatalf("got %s -> %s, want %s -> %s", got.RuleID, got.Topic, tt.rule, tt.topic)
+			}
+			if got.Matched != (tt.rule != "") {
+				t.Fatalf("matched=%v for rule %q", got.Matched, tt.rule)
+			}
+		})
+	}
+}
+
+func TestTableExplain(t *testing.T) {
+	table := NewTable(append([]Rule{
+		{ID: "vip", Channel: "email", Metadata: map[string]string{"tier": "vip"}, Topic: "dispatch.email.vip"},
+	}, DefaultRules()...), "")
+
+	exp := table.Explain(Message{TenantID: "t1", Channel: "email"})
+	if exp.RuleID != "default-email" {
+		t.Fatalf("expected default-email, got %q", exp.RuleID)
+	}
+	if len(exp.Evaluated) != 2 {
+		t.Fatalf("expected evaluation to stop at the match, got %d entries", len(exp.Evaluated))
+	}
+	if exp.Evaluated[0].Matched || exp.Evaluated[0].Mismatch != `metadata "tier" is missing` {
+		t.Fatalf("unexpected first evaluation %+v", exp.Evaluated[0])
+	}
+}
+
+func TestEngineReloadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	write := func(body string) {
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engine := &Engine{Sources: []Source{FileSource{Path: path}}}
+	msg := Message{TenantID: "t1", Channel: "email"}
+
+	write(`{"rules":[{"id":"a","channel":"email","topic":"email.a"}]}`)
+	if err := engine.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if got := engine.Route(msg).Topic; got != "email.a" {
+		t.Fatalf("got %s", got)
+	}
+
+	write(`{"rules":[{"id":"b","channel":"email","topic":"email.b"}]}`)
+	if err := engine.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if got := engine.Route(msg).Topic; got != "email.b" {
+		t.Fatalf("got %s after reload", got)
+	}
+
+	write(`{"rules":[{"id":"c","channel":"email"}]}`)
+	if err := engine.Refresh(context.Background()); err == nil {
+		t.Fatal("expected invalid rule to fail refresh")
+	}
+	if got := engine.Route(msg).Topic; got != "email.b" {
+		t.Fatalf("failed reload replaced table: got %s", got)
+	}
+}
+
+func TestRuleValidateTenant(t *testing.T) {
+	tests := []struct {
+		topic string
+		ok    bool
+	}{
+		{"dispatch.email.vip", true},
+		{"dispatch.sms", true},
+		{"", false},
+		{"dispatch.", false},
+		{"notifications", false},
+		{"provider.events", false},
+		{"dlq.notifications", false},
+	}
+	for _, tt := range tests {
+		err := Rule{Channel: "email", Topic: tt.topic}.ValidateTenant()
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateTenant(%q) err = %v, want ok=%v", tt.topic, err, tt.ok)
+		}
+	}
+}
+
+func TestHasLanes(t *testing.T) {
+	tests := map[string]bool{
+		"dispatch.email":     true,
+		"dispatch.email.vip": false,
+		"dispatch.sms":       false,
+	}
+	for topic, want := range tests {
+		rule := Rule{Channel: "email", Topic: topic, Lanes: HasLanes(topic)}
+		if rule.Lanes != want {
+			t.Errorf("HasLanes(%q) = %v, want %v", topic, rule.Lanes, want)
+		}
+		expected := topic
+		if want {
+			expected = topic + ".critical"
+		}
+		if got := rule.topic(Message{Channel: "email", Priority: "critical"}); got != expected {
+			t.Errorf("critical on %q routed to %q, want %q", topic, got, expected)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const ruleColumns = `id, tenant_id, channel, template_id, metadata_json, topic, position, created_at`
+
+const selectAllRules = `
+SELECT ` + ruleColumns + `
+FROM routing_rules
+ORDER BY position, created_at
+`
+
+const selectTenantRules = `
+SELECT ` + ruleColumns + `
+FROM routing_rules
+WHERE tenant_id = $1
+ORDER BY position, created_at
+`
+
+const insertRule = `
+INSERT INTO routing_rules (id, tenant_id, channel, template_id, metadata_json, topic, position, created_at)
+VALUES ($1,$2,$3,$4,$5,$6,$7, now())
+RETURNING ` + ruleColumns
+
+const deleteRule = `
+DELETE FROM routing_rules WHERE tenant_id = $1 AND id = $2
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) ListRules(ctx context.Context) ([]Rule, error) {
+	rows, err := r.pool.Query(ctx, selectAllRules)
+	if err != nil {
+		return nil, fmt.Errorf("list routing rules: %w", err)
+	}
+	return collectRules(rows)
+}
+
+func (r *PostgresRepository) List(ctx context.Context, tenantID string) ([]Rule, error) {
+	rows, err := r.pool.Query(ctx, selectTenantRules, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list routing rules: %w", err)
+	}
+	return collectRules(rows)
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, rule Rule) (Rule, error) {
+	metadata, err := json.Marshal(rule.Metadata)
+	if err != nil {
+		return Rule{}, fmt.Errorf("marshal rule metadata: %w", err)
+	}
+	row := r.pool.QueryRow(ctx, insertRule, rule.ID, rule.TenantID, rule.Channel, rule.TemplateID, metadata, rule.Topic, rule.Position)
+	created, err := scanRule(row)
+	if err != nil {
+		return Rule{}, fmt.Errorf("insert routing rule: %w", err)
+	}
+	return created, nil
+}
+
+func (r *PostgresRepository) Delete(ctx context.Context, tenantID, id string) error {
+	tag, err := r.pool.Exec(ctx, deleteRule, tenantID, id)
+	if err != nil {
+		return fmt.Errorf("delete routing rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func collectRules(rows pgx.Rows) ([]Rule, error) {
+	defer rows.Close()
+	var rules []Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan routing rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func scanRule(row pgx.Row) (Rule, error) {
+	var rule Rule
+	var metadata []byte
+	if err := row.Scan(&rule.ID, &rule.TenantID, &rule.Channel, &rule.TemplateID, &metadata, &rule.Topic, &rule.Position, &rule.CreatedAt); err != nil {
+		return Rule{}, err
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &rule.Metadata); err != nil {
+			return Rule{}, err
+		}
+	}
+	return rule, nil
+}
@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// Deferred is a Kafka message held back until NotBefore and then published
+// unchanged to Topic.
+type Deferred struct {
+	ID        string
+	TenantID  string
+	MessageID string
+	Topic     string
+	Key       []byte
+	Value     []byte
+	NotBefore time.Time
+	Reason    string
+	CreatedAt time.Time
+}
+
+type Store interface {
+	Schedule(ctx context.Context, d Deferred) error
+	// Release passes up to limit due messages to publish and deletes them
+	// only if publish succeeds, so a crash re-delivers rather than loses.
+	Release(ctx context.Context, now time.Time, limit int, publish func(context.Context, []Deferred) error) (int, error)
+}
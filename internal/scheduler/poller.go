@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	defaultInterval  = time.Second
+	defaultBatchSize = 500
+)
+
+var releasedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "scheduler_released_total",
+	Help: "Deferred messages republished after their release time",
+}, []string{"topic"})
+
+// Poller republishes deferred messages once they are due.
+type Poller struct {
+	Store         Store
+	WriterFactory func(topic string) *kafka.Writer
+	Interval      time.Duration
+	BatchSize     int
+	Logger        zerolog.Logger
+}
+
+func (p *Poller) Run(ctx context.Context) error {
+	if p.Store == nil || p.WriterFactory == nil {
+		return errors.New("poller requires a store and writer factory")
+	}
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	batch := p.BatchSize
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		// Keep draining while full batches come back so a backlog clears
+		// faster than one batch per tick.
+		for {
+			n, err := p.Store.Release(ctx, time.Now().UTC(), batch, p.publish)
+			if err != nil {
+				p.Logger.Error().Err(err).Msg("failed to release deferred messages")
+				break
+			}
+			if n < batch {
+				break
+			}
+		}
+	}
+}
+
+func (p *Poller) publish(ctx context.Context, due []Deferred) error {
+	byTopic := map[string][]kafka.Message{}
+	for _, d := range due {
+		byTopic[d.Topic] = append(byTopic[d.Topic], kafka.Message{Key: d.Key, Value: d.Value})
+	}
+	for topic, msgs := range byTopic {
+		if err := p.WriterFactory(topic).WriteMessages(ctx, msgs...); err != nil {
+			return err
+		}
+		releasedMessages.WithLabelValues(topic).Add(float64(len(msgs)))
+	}
+	return nil
+}
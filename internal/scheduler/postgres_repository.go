@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const insertDeferred = `
+INSERT INTO deferred_messages (
+id,
+tenant_id,
+message_id,
+topic,
+message_key,
+message_value,
+not_before,
+reason,
+created_at
+) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,now())
+`
+
+// SKIP LOCKED lets several dispatcher replicas release in parallel without
+// publishing the same row twice.
+const selectDue = `
+SELECT id, tenant_id, message_id, topic, message_key, message_value, not_before, reason, created_at
+FROM deferred_messages
+WHERE not_before <= $1
+ORDER BY not_before
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+const deleteDeferred = `
+DELETE FROM deferred_messages WHERE id = ANY($1)
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) Schedule(ctx context.Context, d Deferred) error {
+	if _, err := r.pool.Exec(ctx, insertDeferred,
+		d.ID,
+		d.TenantID,
+		d.MessageID,
+		d.Topic,
+		d.Key,
+		d.Value,
+		d.NotBefore,
+		d.Reason,
+	); err != nil {
+		return fmt.Errorf("insert deferred message: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Release(ctx context.Context, now time.Time, limit int, publish func(context.Context, []Deferred) error) (int, error) {
+	released := 0
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, selectDue, now, limit)
+		if err != nil {
+			return fmt.Errorf("select due messages: %w", err)
+		}
+		var (
+			due []Deferred
+			ids []string
+		)
+		for rows.Next() {
+			var d Deferred
+			if err := rows.Scan(&d.ID, &d.TenantID, &d.MessageID, &d.Topic, &d.Key, &d.Value, &d.NotBefore, &d.Reason, &d.CreatedAt); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan deferred message: %w", err)
+			}
+			due = append(due, d)
+			ids = append(ids, d.ID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		if err := publish(ctx, due); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, deleteDeferred, ids); err != nil {
+			return fmt.Errorf("delete released messages: %w", err)
+		}
+		released = len(due)
+		return nil
+	})
+	return released, err
+}
@@ -0,0 +1,48 @@
+package suppression
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("suppression not found")
+
+const (
+	ReasonHardBounce = "hard_bounce"
+	ReasonSoftBounce = "soft_bounce"
+	ReasonComplaint  = "complaint"
+	ReasonManual     = "manual"
+)
+
+// DefaultSoftBounceThreshold is how many soft bounces suppress an address.
+const DefaultSoftBounceThreshold = 3
+
+type Entry struct {
+	TenantID        string    `json:"tenant_id"`
+	Address         string    `json:"address"`
+	Reason          string    `json:"reason"`
+	SoftBounceCount int       `json:"soft_bounce_count"`
+	Suppressed      bool      `json:"suppressed"`
+	LastEventAt     time.Time `json:"last_event_at"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Repository stores per-tenant address suppressions. Soft bounces are
+// counted on the same row and only suppress once they reach the threshold;
+// a delivery in between resets the count, so only consecutive ones add up.
+type Repository interface {
+	Suppress(ctx context.Context, tenantID, address, reason string) (Entry, error)
+	// RecordSoftBounce counts one soft bounce; repeating the address's last
+	// eventID does not count again.
+	RecordSoftBounce(ctx context.Context, tenantID, address, eventID string, threshold int) (Entry, error)
+	ResetSoftBounces(ctx context.Context, tenantID, address string) error
+	Remove(ctx context.Context, tenantID, address string) error
+	IsSuppressed(ctx context.Context, tenantID, address string) (bool, error)
+	List(ctx context.Context, tenantID string, limit int) ([]Entry, error)
+}
+
+func NormalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
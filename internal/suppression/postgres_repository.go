@@ -0,0 +1,143 @@
+package suppression
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const entryColumns = `tenant_id, address, reason, soft_bounce_count, suppressed, last_event_at, created_at`
+
+const upsertSuppression = `
+INSERT INTO suppressions (tenant_id, address, reason, soft_bounce_count, suppressed, last_event_at, created_at)
+VALUES ($1, $2, $3, 0, true, now(), now())
+ON CONFLICT (tenant_id, address)
+DO UPDATE SET reason = EXCLUDED.reason, suppressed = true, last_event_at = now()
+RETURNING ` + entryColumns
+
+// upsertSoftBounce skips the update when $4 is the last soft bounce already
+// counted, so the row comes back from the fallback select instead.
+const upsertSoftBounce = `
+INSERT INTO suppressions (tenant_id, address, reason, soft_bounce_count, suppressed, last_event_id, last_event_at, created_at)
+VALUES ($1, $2, 'soft_bounce', 1, 1 >= $3, $4, now(), now())
+ON CONFLICT (tenant_id, address)
+DO UPDATE SET
+soft_bounce_count = suppressions.soft_bounce_count + 1,
+suppressed = suppressions.suppressed OR suppressions.soft_bounce_count + 1 >= $3,
+last_event_id = EXCLUDED.last_event_id,
+last_event_at = now()
+WHERE suppressions.last_event_id IS DISTINCT FROM EXCLUDED.last_event_id
+RETURNING ` + entryColumns
+
+const selectSuppression = `
+SELECT ` + entryColumns + `
+FROM suppressions
+WHERE tenant_id = $1 AND address = $2
+`
+
+// resetSoftBounces leaves suppressed rows alone: a delivery does not lift a
+// suppression, it only clears the streak that had not reached it.
+const resetSoftBounces = `
+UPDATE suppressions SET soft_bounce_count = 0
+WHERE tenant_id = $1 AND address = $2 AND NOT suppressed AND soft_bounce_count > 0
+`
+
+const deleteSuppression = `
+DELETE FROM suppressions WHERE tenant_id = $1 AND address = $2
+`
+
+const selectSuppressed = `
+SELECT suppressed FROM suppressions WHERE tenant_id = $1 AND address = $2
+`
+
+const selectSuppressions = `
+SELECT ` + entryColumns + `
+FROM suppressions
+WHERE tenant_id = $1
+ORDER BY last_event_at DESC
+LIMIT $2
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) Suppress(ctx context.Context, tenantID, address, reason string) (Entry, error) {
+	entry, err := scanEntry(r.pool.QueryRow(ctx, upsertSuppression, tenantID, NormalizeAddress(address), reason))
+	if err != nil {
+		return Entry{}, fmt.Errorf("upsert suppression: %w", err)
+	}
+	return entry, nil
+}
+
+func (r *PostgresRepository) RecordSoftBounce(ctx context.Context, tenantID, address, eventID string, threshold int) (Entry, error) {
+	address = NormalizeAddress(address)
+	entry, err := scanEntry(r.pool.QueryRow(ctx, upsertSoftBounce, tenantID, address, threshold, eventID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		entry, err = scanEntry(r.pool.QueryRow(ctx, selectSuppression, tenantID, address))
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("record soft bounce: %w", err)
+	}
+	return entry, nil
+}
+
+func (r *PostgresRepository) ResetSoftBounces(ctx context.Context, tenantID, address string) error {
+	if _, err := r.pool.Exec(ctx, resetSoftBounces, tenantID, NormalizeAddress(address)); err != nil {
+		return fmt.Errorf("reset soft bounces: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Remove(ctx context.Context, tenantID, address string) error {
+	tag, err := r.pool.Exec(ctx, deleteSuppression, tenantID, NormalizeAddress(address))
+	if err != nil {
+		return fmt.Errorf("delete suppression: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) IsSuppressed(ctx context.Context, tenantID, address string) (bool, error) {
+	var suppressed bool
+	if err := r.pool.QueryRow(ctx, selectSuppressed, tenantID, NormalizeAddress(address)).Scan(&suppressed); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("check suppression: %w", err)
+	}
+	return suppressed, nil
+}
+
+func (r *PostgresRepository) List(ctx context.Context, tenantID string, limit int) ([]Entry, error) {
+	rows, err := r.pool.Query(ctx, selectSuppressions, tenantID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan suppression: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func scanEntry(row pgx.Row) (Entry, error) {
+	var e Entry
+	err := row.Scan(&e.TenantID, &e.Address, &e.Reason, &e.SoftBounceCount, &e.Suppressed, &e.LastEventAt, &e.CreatedAt)
+	return e, err
+}
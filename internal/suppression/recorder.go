@@ -0,0 +1,69 @@
+package suppression
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+var recordedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "suppression_events_total",
+	Help: "Bounce and complaint events applied to the suppression list",
+}, []string{"reason"})
+
+// Recorder feeds normalized provider events into the suppression list.
+type Recorder struct {
+	Repo                Repository
+	SoftBounceThreshold int
+}
+
+// Classify maps a canonical event status and bounce type to a suppression
+// reason, or "" when the event does not affect suppression.
+func Classify(status, bounceType string) string {
+	switch status {
+	case common.StatusBounced:
+		if bounceType == "soft" {
+			return ReasonSoftBounce
+		}
+		return ReasonHardBounce
+	case common.StatusComplained:
+		return ReasonComplaint
+	default:
+		return ""
+	}
+}
+
+// Record applies one event. Hard bounces and complaints suppress the address
+// immediately; soft bounces only once the threshold is reached without a
+// delivery in between. eventID identifies the provider event, so a
+// redelivered soft bounce is only counted once.
+func (r *Recorder) Record(ctx context.Context, tenantID, address, eventID, status, bounceType string) error {
+	if tenantID == "" || address == "" {
+		return nil
+	}
+	if status == common.StatusDelivered {
+		return r.Repo.ResetSoftBounces(ctx, tenantID, address)
+	}
+	reason := Classify(status, bounceType)
+	switch reason {
+	case "":
+		return nil
+	case ReasonSoftBounce:
+		threshold := r.SoftBounceThreshold
+		if threshold <= 0 {
+			threshold = DefaultSoftBounceThreshold
+		}
+		if _, err := r.Repo.RecordSoftBounce(ctx, tenantID, address, eventID, threshold); err != nil {
+			return err
+		}
+	default:
+		if _, err := r.Repo.Suppress(ctx, tenantID, address, reason); err != nil {
+			return err
+		}
+	}
+	recordedEvents.WithLabelValues(reason).Inc()
+	return nil
+}
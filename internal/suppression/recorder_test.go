@@ -0,0 +1,94 @@
+package suppression
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeRepo struct {
+	Repository
+	suppressed  map[string]string
+	softBounces map[string]int
+	lastEvent   map[string]string
+	threshold   int
+}
+
+func (f *fakeRepo) Suppress(_ context.Context, _, address, reason string) (Entry, error) {
+	f.suppressed[address] = reason
+	return Entry{Address: address, Reason: reason, Suppressed: true}, nil
+}
+
+func (f *fakeRepo) RecordSoftBounce(_ context.Context, _, address, eventID string, threshold int) (Entry, error) {
+	if f.lastEvent[address] == eventID {
+		return Entry{Address: address, SoftBounceCount: f.softBounces[address]}, nil
+	}
+	f.lastEvent[address] = eventID
+	f.softBounces[address]++
+	f.threshold = threshold
+	return Entry{Address: address, SoftBounceCount: f.softBounces[address]}, nil
+}
+
+func (f *fakeRepo) ResetSoftBounces(_ context.Context, _, address string) error {
+	delete(f.softBounces, address)
+	return nil
+}
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		status     string
+		bounceType string
+		want       string
+	}{
+		{status: "bounced", bounceType: "hard", want: ReasonHardBounce},
+		{status: "bounced", bounceType: "", want: ReasonHardBounce},
+		{status: "bounced", bounceType: "soft", want: ReasonSoftBounce},
+		{status: "complained", want: ReasonComplaint},
+		{status: "delivered", want: ""},
+		{status: "spamreport", want: ""},
+	}
+	for _, tc := range cases {
+		if got := Classify(tc.status, tc.bounceType); got != tc.want {
+			t.Fatalf("Classify(%q, %q)=%q, expected %q", tc.status, tc.bounceType, got, tc.want)
+		}
+	}
+}
+
+func TestRecorderRecord(t *testing.T) {
+	repo := &fakeRepo{suppressed: map[string]string{}, softBounces: map[string]int{}, lastEvent: map[string]string{}}
+	rec := &Recorder{Repo: repo}
+	ctx := context.Background()
+
+	steps := []struct {
+		address, eventID, status, bounceType string
+	}{
+		{"hard@x.com", "e1", "bounced", "hard"},
+		{"angry@x.com", "e2", "complained", ""},
+		{"soft@x.com", "e3", "bounced", "soft"},
+		{"soft@x.com", "e4", "bounced", "soft"},
+		{"soft@x.com", "e4", "bounced", "soft"}, // redelivered by the provider
+		{"flaky@x.com", "e5", "bounced", "soft"},
+		{"flaky@x.com", "e6", "bounced", "soft"},
+		{"flaky@x.com", "e7", "delivered", ""},
+		{"flaky@x.com", "e8", "bounced", "soft"},
+		{"ok@x.com", "e9", "delivered", ""},
+		{"", "e10", "bounced", "hard"},
+	}
+	for _, s := range steps {
+		if err := rec.Record(ctx, "t1", s.address, s.eventID, s.status, s.bounceType); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if repo.suppressed["hard@x.com"] != ReasonHardBounce || repo.suppressed["angry@x.com"] != ReasonComplaint {
+		t.Fatalf("unexpected suppressions: %v", repo.suppressed)
+	}
+	if len(repo.suppressed) != 2 {
+		t.Fatalf("expected 2 immediate suppressions, got %v", repo.suppressed)
+	}
+	if repo.softBounces["flaky@x.com"] != 1 {
+		t.Fatalf("expected delivery to reset the soft bounce count, got %v", repo.softBounces)
+	}
+	if repo.softBounces["soft@x.com"] != 2 || repo.threshold != DefaultSoftBounceThreshold {
+		t.Fatalf("unexpected soft bounces: %v threshold=%d", repo.softBounces, repo.threshold)
+	}
+}
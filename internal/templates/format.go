@@ -0,0 +1,163 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type localeFormat struct {
+	decimal string
+	group   string
+	date    string
+	// currencyAfter places the symbol after the amount ("10,00 €").
+	currencyAfter bool
+}
+
+var localeFormats = map[string]localeFormat{
+	"en":    {decimal: ".", group: ",", date: "Jan 2, 2006"},
+	"en-GB": {decimal: ".", group: ",", date: "2 Jan 2006"},
+	"en-IN": {decimal: ".", group: ",", date: "2 Jan 2006"},
+	"pt":    {decimal: ",", group: ".", date: "02/01/2006"},
+	"pt-BR": {decimal: ",", group: ".", date: "02/01/2006"},
+	"pt-PT": {decimal: ",", group: " ", date: "02/01/2006", currencyAfter: true},
+	"es":    {decimal: ",", group: ".", date: "02/01/2006", currencyAfter: true},
+	"es-MX": {decimal: ".", group: ",", date: "02/01/2006"},
+	"fr":    {decimal: ",", group: " ", date: "02/01/2006", currencyAfter: true},
+	"de":    {decimal: ",", group: ".", date: "02.01.2006", currencyAfter: true},
+	"it":    {decimal: ",", group: ".", date: "02/01/2006", currencyAfter: true},
+	"nl":    {decimal: ",", group: ".", date: "02-01-2006"},
+	"ja":    {decimal: ".", group: ",", date: "2006/01/02"},
+	"hi":    {decimal: ".", group: ",", date: "2/1/2006"},
+	"zh":    {decimal: ".", group: ",", date: "2006/01/02"},
+}
+
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"BRL": "R$",
+	"JPY": "¥",
+	"INR": "₹",
+	"MXN": "$",
+	"CNY": "¥",
+}
+
+// zeroDecimalCurrencies have no minor unit.
+var zeroDecimalCurrencies = map[string]bool{"JPY": true}
+
+func formatFor(locale string) localeFormat {
+	for _, l := range FallbackChain(locale, DefaultLocale) {
+		if f, ok := localeFormats[l]; ok {
+			return f
+		}
+	}
+	return localeFormats[DefaultLocale]
+}
+
+// FuncMap returns the locale-aware helpers available inside templates:
+//
+//	{{formatDate .created_at}}
+//	{{formatNumber .count 0}}
+//	{{formatCurrency .amount "EUR"}}
+func FuncMap(locale string) template.FuncMap {
+	f := formatFor(locale)
+	return template.FuncMap{
+		"formatDate": func(v any) (string, error) {
+			t, err := toTime(v)
+			if err != nil {
+				return "", err
+			}
+			return t.Format(f.date), nil
+		},
+		"formatNumber": func(v any, decimals int) (string, error) {
+			n, err := toFloat(v)
+			if err != nil {
+				return "", err
+			}
+			return f.number(n, decimals), nil
+		},
+		"formatCurrency": func(v any, code string) (string, error) {
+			n, err := toFloat(v)
+			if err != nil {
+				return "", err
+			}
+			code = strings.ToUpper(code)
+			decimals := 2
+			if zeroDecimalCurrencies[code] {
+				decimals = 0
+			}
+			symbol, ok := currencySymbols[code]
+			if !ok {
+				symbol = code
+			}
+			amount := f.number(math.Abs(n), decimals)
+			sign := ""
+			if n < 0 {
+				sign = "-"
+			}
+			if f.currencyAfter {
+				return sign + amount + " " + symbol, nil
+			}
+			return sign + symbol + amount, nil
+		},
+	}
+}
+
+func (f localeFormat) number(n float64, decimals int) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+	raw := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(raw, ".")
+
+	var b strings.Builder
+	if n < 0 {
+		b.WriteByte('-')
+	}
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(f.group)
+		}
+		b.WriteRune(r)
+	}
+	if fracPart != "" {
+		b.WriteString(f.decimal)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("cannot format %T as a number", v)
+	}
+}
+
+func toTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+			return parsed, nil
+		}
+		return time.Parse("2006-01-02", t)
+	default:
+		return time.Time{}, fmt.Errorf("cannot format %T as a date", v)
+	}
+}
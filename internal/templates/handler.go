@@ -0,0 +1,232 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+type Handler struct {
+	repo      Repository
+	service   *Service
+	producer  *kafka.Writer
+	allowList []string
+	tracer    trace.Tracer
+	logger    zerolog.Logger
+}
+
+// NewHandler builds the template API. producer publishes test sends to the
+// email dispatch topic and may be nil to disable test-send; allowList holds
+// the exact addresses or exact "@domain" entries test sends may target.
+func NewHandler(service *Service, producer *kafka.Writer, allowList []string, logger zerolog.Logger) *Handler {
+	return &Handler{
+		repo:      service.Store,
+		service:   service,
+		producer:  producer,
+		allowList: allowList,
+		tracer:    otel.Tracer("templates"),
+		logger:    logger,
+	}
+}
+
+func (h *Handler) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Post("/v1/templates", h.create)
+	r.Get("/v1/templates/{id}", h.get)
+	r.Get("/v1/templates/{id}/versions", h.listVersions)
+	r.Post("/v1/templates/{id}/versions", h.createVersion)
+	r.Post("/v1/templates/{id}/preview", h.preview)
+	r.Post("/v1/templates/{id}/test-send", h.testSend)
+	return r
+}
+
+type templateRequest struct {
+	ID       string         `json:"id"`
+	Channel  string         `json:"channel"`
+	Name     string         `json:"name"`
+	Locale   string         `json:"locale"`
+	Subject  string         `json:"subject"`
+	HTML     string         `json:"html"`
+	Text     string         `json:"text"`
+	Variants []Variant      `json:"variants"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "create-template")
+	defer span.End()
+
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var req templateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ID == "" {
+		req.ID = uuid.NewString()
+	} else if existing, err := h.repo.ListVersions(ctx, tenantID, req.ID); err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	} else if len(existing) > 0 {
+		h.respondErr(ctx, w, http.StatusConflict, errors.New("template already exists"))
+		return
+	}
+	h.saveVersion(ctx, w, tenantID, req)
+}
+
+func (h *Handler) createVersion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "create-template-version")
+	defer span.End()
+
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var req templateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	req.ID = chi.URLParam(r, "id")
+
+	existing, err := h.repo.ListVersions(ctx, tenantID, req.ID)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(existing) == 0 {
+		h.respondErr(ctx, w, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	// Channel and name carry over from an existing version unless overridden;
+	// a new locale starts its own version sequence.
+	current := existing[0]
+	if req.Channel == "" {
+		req.Channel = current.Channel
+	}
+	if req.Name == "" {
+		req.Name = current.Name
+	}
+	h.saveVersion(ctx, w, tenantID, req)
+}
+
+func (h *Handler) saveVersion(ctx context.Context, w http.ResponseWriter, tenantID string, req templateRequest) {
+	tpl := Template{
+		ID:       req.ID,
+		TenantID: tenantID,
+		Channel:  req.Channel,
+		Name:     req.Name,
+		Locale:   NormalizeLocale(req.Locale),
+		Subject:  req.Subject,
+		HTML:     req.HTML,
+		Text:     req.Text,
+		Variants: req.Variants,
+		Metadata: req.Metadata,
+	}
+	if tpl.Channel == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("channel is required"))
+		return
+	}
+	if tpl.Locale == "" {
+		tpl.Locale = DefaultLocale
+	}
+	if err := Validate(tpl); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	saved, err := h.repo.CreateVersion(ctx, tpl)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(saved)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "get-template")
+	defer span.End()
+
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			h.respondErr(ctx, w, http.StatusBadRequest, errors.New("version must be a positive integer"))
+			return
+		}
+		version = parsed
+	}
+	locale := NormalizeLocale(r.URL.Query().Get("locale"))
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	tpl, err := h.repo.Get(ctx, tenantID, chi.URLParam(r, "id"), locale, version)
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tpl)
+}
+
+func (h *Handler) listVersions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "list-template-versions")
+	defer span.End()
+
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	versions, err := h.repo.ListVersions(ctx, tenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(versions) == 0 {
+		h.respondErr(ctx, w, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"versions": versions})
+}
+
+func (h *Handler) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
+	logger := common.WithContext(ctx, h.logger)
+	logger.Error().Err(err).Int("status", status).Msg("templates handler failed")
+	http.Error(w, err.Error(), status)
+}
+
+func statusForErr(err error) int {
+	var missing *MissingVariableError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &missing):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
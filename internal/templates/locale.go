@@ -0,0 +1,55 @@
+package templates
+
+import "strings"
+
+const DefaultLocale = "en"
+
+// NormalizeLocale canonicalises a BCP 47 style tag so "pt_br" and "PT-br"
+// both become "pt-BR".
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(strings.ReplaceAll(locale, "_", "-"))
+	if locale == "" {
+		return ""
+	}
+	parts := strings.Split(locale, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// FallbackChain returns the locales to try in order, dropping one subtag at
+// a time and ending with fallback: pt-BR → pt → en.
+func FallbackChain(locale, fallback string) []string {
+	fallback = NormalizeLocale(fallback)
+	if fallback == "" {
+		fallback = DefaultLocale
+	}
+	var chain []string
+	seen := map[string]bool{}
+	add := func(l string) {
+		if l != "" && !seen[l] {
+			seen[l] = true
+			chain = append(chain, l)
+		}
+	}
+	locale = NormalizeLocale(locale)
+	for locale != "" {
+		add(locale)
+		idx := strings.LastIndex(locale, "-")
+		if idx < 0 {
+			break
+		}
+		locale = locale[:idx]
+	}
+	add(fallback)
+	return chain
+}
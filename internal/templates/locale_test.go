@@ -0,0 +1,64 @@
+package templates
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFallbackChain(t *testing.T) {
+	cases := []struct {
+		locale   string
+		fallback string
+		want     []string
+	}{
+		{locale: "pt-BR", fallback: "en", want: []string{"pt-BR", "pt", "en"}},
+		{locale: "pt_br", fallback: "", want: []string{"pt-BR", "pt", "en"}},
+		{locale: "zh-hant-TW", fallback: "en", want: []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}},
+		{locale: "en-GB", fallback: "en", want: []string{"en-GB", "en"}},
+		{locale: "", fallback: "fr", want: []string{"fr"}},
+	}
+
+	for _, tc := range cases {
+		if got := FallbackChain(tc.locale, tc.fallback); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("FallbackChain(%q, %q)=%v, expected %v", tc.locale, tc.fallback, got, tc.want)
+		}
+	}
+}
+
+func TestRenderLocaleFormatting(t *testing.T) {
+	tpl := Template{
+		Locale: "de-AT",
+		Text:   `{{formatCurrency .amount "EUR"}} / {{formatNumber .count 0}} / {{formatDate .due}}`,
+	}
+	data := map[string]any{"amount": 1234.5, "count": 1000000, "due": "2024-03-09T10:00:00Z"}
+
+	got, err := Render(tpl, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "1.234,50 € / 1.000.000 / 09.03.2024"; got.Text != want {
+		t.Fatalf("text=%q, expected %q", got.Text, want)
+	}
+
+	tpl.Locale = "en"
+	tpl.Text = `{{formatCurrency .amount "JPY"}} {{formatCurrency .neg "USD"}}`
+	got, err = Render(tpl, map[string]any{"amount": 1500, "neg": -2.5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "¥1,500 -$2.50"; got.Text != want {
+		t.Fatalf("text=%q, expected %q", got.Text, want)
+	}
+
+	tpl.Text = `{{formatCurrency .amount "BRL"}}`
+	for locale, want := range map[string]string{"pt": "R$1.234,50", "pt-BR": "R$1.234,50", "pt-PT": "1 234,50 R$"} {
+		tpl.Locale = locale
+		got, err = Render(tpl, map[string]any{"amount": 1234.5})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Text != want {
+			t.Fatalf("%s: text=%q, expected %q", locale, got.Text, want)
+		}
+	}
+}
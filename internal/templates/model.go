@@ -0,0 +1,56 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("template not found")
+
+type Template struct {
+	ID        string         `json:"id"`
+	TenantID  string         `json:"tenant_id"`
+	Channel   string         `json:"channel"`
+	Name      string         `json:"name"`
+	Locale    string         `json:"locale"`
+	Version   int            `json:"version"`
+	Subject   string         `json:"subject"`
+	HTML      string         `json:"html"`
+	Text      string         `json:"text"`
+	Variants  []Variant      `json:"variants,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Rendered is the output of executing a template version against message data.
+type Rendered struct {
+	TemplateID string `json:"template_id"`
+	Version    int    `json:"version"`
+	Locale     string `json:"locale,omitempty"`
+	Variant    string `json:"variant,omitempty"`
+	Subject    string `json:"subject,omitempty"`
+	HTML       string `json:"html,omitempty"`
+	Text       string `json:"text,omitempty"`
+}
+
+type RenderRequest struct {
+	TenantID   string
+	TemplateID string
+	// Version pins a specific template version; zero renders the latest.
+	Version int
+	// Locale selects the variant; see FallbackChain for how it degrades.
+	Locale string
+	// Recipient is the stable key used to assign an A/B variant.
+	Recipient string
+	Data      map[string]any
+}
+
+// Repository stores immutable template versions. Each locale variant of a
+// template is versioned independently; CreateVersion assigns the next
+// version number for the (id, locale) pair.
+type Repository interface {
+	CreateVersion(ctx context.Context, tpl Template) (Template, error)
+	Get(ctx context.Context, tenantID, id, locale string, version int) (Template, error)
+	ListVersions(ctx context.Context, tenantID, id string) ([]Template, error)
+}
@@ -0,0 +1,171 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// insertTemplateVersion relies on the unique (tenant_id, id, locale,
+// version) key: a create racing another for the same next version inserts
+// nothing and CreateVersion retries with a fresh MAX.
+const insertTemplateVersion = `
+INSERT INTO templates (
+id,
+tenant_id,
+channel,
+name,
+locale,
+version,
+subject,
+html_body,
+text_body,
+variants_json,
+metadata_json,
+created_at
+)
+SELECT $1, $2, $3, $4, $5, COALESCE(MAX(version), 0) + 1, $6, $7, $8, $9, $10, now()
+FROM templates
+WHERE tenant_id = $2 AND id = $1 AND locale = $5
+ON CONFLICT (tenant_id, id, locale, version) DO NOTHING
+RETURNING version, created_at
+`
+
+// maxVersionAttempts bounds the retries of a contended CreateVersion.
+const maxVersionAttempts = 5
+
+const selectTemplateColumns = `
+SELECT id, tenant_id, channel, name, locale, version, subject, html_body, text_body, variants_json, metadata_json, created_at
+FROM templates
+`
+
+const selectTemplateVersion = selectTemplateColumns + `
+WHERE tenant_id = $1 AND id = $2 AND locale = $3 AND version = $4
+`
+
+const selectLatestTemplate = selectTemplateColumns + `
+WHERE tenant_id = $1 AND id = $2 AND locale = $3
+ORDER BY version DESC
+LIMIT 1
+`
+
+const selectTemplateVersions = selectTemplateColumns + `
+WHERE tenant_id = $1 AND id = $2
+ORDER BY locale, version DESC
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) CreateVersion(ctx context.Context, tpl Template) (Template, error) {
+	variants, err := json.Marshal(tpl.Variants)
+	if err != nil {
+		return Template{}, err
+	}
+	metadata, err := json.Marshal(tpl.Metadata)
+	if err != nil {
+		return Template{}, err
+	}
+	for attempt := 0; attempt < maxVersionAttempts; attempt++ {
+		row := r.pool.QueryRow(ctx, insertTemplateVersion,
+			tpl.ID,
+			tpl.TenantID,
+			tpl.Channel,
+			tpl.Name,
+			tpl.Locale,
+			tpl.Subject,
+			tpl.HTML,
+			tpl.Text,
+			variants,
+			metadata,
+		)
+		err := row.Scan(&tpl.Version, &tpl.CreatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return Template{}, fmt.Errorf("insert template version: %w", err)
+		}
+		return tpl, nil
+	}
+	return Template{}, fmt.Errorf("insert template version: still contended after %d attempts", maxVersionAttempts)
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, tenantID, id, locale string, version int) (Template, error) {
+	var row pgx.Row
+	if version > 0 {
+		row = r.pool.QueryRow(ctx, selectTemplateVersion, tenantID, id, locale, version)
+	} else {
+		row = r.pool.QueryRow(ctx, selectLatestTemplate, tenantID, id, locale)
+	}
+	tpl, err := scanTemplate(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Template{}, ErrNotFound
+		}
+		return Template{}, fmt.Errorf("fetch template: %w", err)
+	}
+	return tpl, nil
+}
+
+func (r *PostgresRepository) ListVersions(ctx context.Context, tenantID, id string) ([]Template, error) {
+	rows, err := r.pool.Query(ctx, selectTemplateVersions, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("list template versions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Template
+	for rows.Next() {
+		tpl, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan template: %w", err)
+		}
+		out = append(out, tpl)
+	}
+	return out, rows.Err()
+}
+
+func scanTemplate(row pgx.Row) (Template, error) {
+	var (
+		tpl          Template
+		variantsJSON []byte
+		metadataJSON []byte
+	)
+	if err := row.Scan(
+		&tpl.ID,
+		&tpl.TenantID,
+		&tpl.Channel,
+		&tpl.Name,
+		&tpl.Locale,
+		&tpl.Version,
+		&tpl.Subject,
+		&tpl.HTML,
+		&tpl.Text,
+		&variantsJSON,
+		&metadataJSON,
+		&tpl.CreatedAt,
+	); err != nil {
+		return Template{}, err
+	}
+	if len(variantsJSON) > 0 {
+		if err := json.Unmarshal(variantsJSON, &tpl.Variants); err != nil {
+			return Template{}, err
+		}
+	}
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &tpl.Metadata); err != nil {
+			return Template{}, err
+		}
+	}
+	return tpl, nil
+}
@@ -0,0 +1,193 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type previewRequest struct {
+	Version int    `json:"version"`
+	Locale  string `json:"locale"`
+	// Variant previews a named A/B variant; Recipient previews whichever
+	// variant that recipient would be assigned.
+	Variant   string         `json:"variant"`
+	Recipient string         `json:"recipient"`
+	Data      map[string]any `json:"data"`
+}
+
+type previewResponse struct {
+	Rendered
+	Channel string   `json:"channel"`
+	SMS     *SMSInfo `json:"sms,omitempty"`
+}
+
+func (h *Handler) preview(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "preview-template")
+	defer span.End()
+
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var req previewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+
+	tpl, err := h.service.Resolve(ctx, tenantID, chi.URLParam(r, "id"), req.Locale, req.Version)
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	variant, ok := Variant{}, false
+	if req.Variant != "" {
+		for _, v := range tpl.Variants {
+			if v.Name == req.Variant {
+				variant, ok = v, true
+			}
+		}
+		if !ok {
+			h.respondErr(ctx, w, http.StatusNotFound, errors.New("variant not found"))
+			return
+		}
+	} else if req.Recipient != "" {
+		variant, ok = AssignVariant(tpl.ID, req.Recipient, tpl.Variants)
+	}
+	if ok {
+		tpl = tpl.WithVariant(variant)
+	}
+	rendered, err := Render(tpl, req.Data)
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	rendered.Variant = variant.Name
+
+	resp := previewResponse{Rendered: rendered, Channel: tpl.Channel}
+	if tpl.Channel == "sms" || tpl.Channel == "whatsapp" {
+		info := SMSSegments(rendered.Text)
+		resp.SMS = &info
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+type testSendRequest struct {
+	To      string         `json:"to"`
+	Version int            `json:"version"`
+	Locale  string         `json:"locale"`
+	Data    map[string]any `json:"data"`
+}
+
+// testSend renders the template up front so authors get errors synchronously,
+// then publishes to the email dispatch topic so the message takes the same
+// worker and provider path as production traffic, flagged as a test.
+func (h *Handler) testSend(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "test-send-template")
+	defer span.End()
+
+	if h.producer == nil {
+		h.respondErr(ctx, w, http.StatusServiceUnavailable, errors.New("test-send is not configured"))
+		return
+	}
+	tenantID := r.Header.Get("x-tenant-id")
+	if tenantID == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("missing x-tenant-id header"))
+		return
+	}
+	var req testSendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	req.To = strings.TrimSpace(req.To)
+	if req.To == "" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("to is required"))
+		return
+	}
+	if !allowed(h.allowList, req.To) {
+		h.respondErr(ctx, w, http.StatusForbidden, errors.New("recipient is not on the test-send allow-list"))
+		return
+	}
+
+	tpl, err := h.service.Resolve(ctx, tenantID, chi.URLParam(r, "id"), req.Locale, req.Version)
+	if err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+	if tpl.Channel != "email" {
+		h.respondErr(ctx, w, http.StatusBadRequest, errors.New("test-send only supports email templates"))
+		return
+	}
+	if _, err := Render(tpl, req.Data); err != nil {
+		h.respondErr(ctx, w, statusForErr(err), err)
+		return
+	}
+
+	messageID := uuid.NewString()
+	span.SetAttributes(attribute.String("message.id", messageID))
+	event := map[string]any{
+		"message_id": messageID,
+		"tenant_id":  tenantID,
+		"channel":    tpl.Channel,
+		"payload": map[string]any{
+			"to":               map[string]any{"email": req.To},
+			"data":             req.Data,
+			"locale":           tpl.Locale,
+			"template_version": tpl.Version,
+		},
+		"template_id": tpl.ID,
+		"created_at":  time.Now().UTC(),
+		"metadata":    map[string]any{"test": true},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := h.producer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(tenantID + ":" + messageID),
+		Value: payload,
+	}); err != nil {
+		h.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]any{"message_id": messageID, "version": tpl.Version, "locale": tpl.Locale})
+}
+
+// allowed matches an address against exact entries and "@domain" entries.
+// Only a single bare address is accepted, so what is checked is exactly what
+// the worker sends to, and a domain entry matches the domain after the last
+// "@" exactly, not as a suffix.
+func allowed(allowList []string, address string) bool {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Name != "" || parsed.Address != address {
+		return false
+	}
+	address = strings.ToLower(address)
+	domain := address[strings.LastIndex(address, "@"):]
+	for _, entry := range allowList {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == address || entry == domain {
+			return true
+		}
+	}
+	return false
+}
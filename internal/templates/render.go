@@ -0,0 +1,155 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"regexp"
+	texttemplate "text/template"
+)
+
+// MissingVariableError reports a template variable that was referenced but
+// not supplied in the render data.
+type MissingVariableError struct {
+	Part string
+	Name string
+}
+
+func (e *MissingVariableError) Error() string {
+	return fmt.Sprintf("template %s references missing variable %q", e.Part, e.Name)
+}
+
+var missingKeyPattern = regexp.MustCompile(`map has no entry for key "([^"]+)"`)
+
+type Service struct {
+	Store Repository
+	// DefaultLocale terminates every fallback chain; DefaultLocale is used
+	// when empty.
+	DefaultLocale string
+}
+
+func NewService(store Repository) *Service {
+	return &Service{Store: store, DefaultLocale: DefaultLocale}
+}
+
+// Resolve finds the template variant for the requested locale, walking the
+// fallback chain (pt-BR → pt → default) until a variant exists. Locales are
+// versioned independently, so a pinned version only selects the requested
+// locale; fallback locales render their latest version.
+func (s *Service) Resolve(ctx context.Context, tenantID, templateID, locale string, version int) (Template, error) {
+	if s.Store == nil {
+		return Template{}, errors.New("template service requires a store")
+	}
+	for i, candidate := range FallbackChain(locale, s.DefaultLocale) {
+		pinned := version
+		if i > 0 {
+			pinned = 0
+		}
+		tpl, err := s.Store.Get(ctx, tenantID, templateID, candidate, pinned)
+		if err == nil {
+			return tpl, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return Template{}, err
+		}
+	}
+	return Template{}, ErrNotFound
+}
+
+func (s *Service) Render(ctx context.Context, req RenderRequest) (Rendered, error) {
+	tpl, err := s.Resolve(ctx, req.TenantID, req.TemplateID, req.Locale, req.Version)
+	if err != nil {
+		return Rendered{}, err
+	}
+	variant := ""
+	if v, ok := AssignVariant(tpl.ID, req.Recipient, tpl.Variants); ok {
+		tpl = tpl.WithVariant(v)
+		variant = v.Name
+	}
+	out, err := Render(tpl, req.Data)
+	if err != nil {
+		return Rendered{}, err
+	}
+	out.Variant = variant
+	return out, nil
+}
+
+// Render executes the subject, html and text bodies of tpl. Subject and text
+// use text/template; html uses html/template so data is escaped. Any variable
+// referenced by the template but absent from data fails the render. The
+// formatting helpers from FuncMap follow tpl.Locale.
+func Render(tpl Template, data map[string]any) (Rendered, error) {
+	if data == nil {
+		data = map[string]any{}
+	}
+	out := Rendered{TemplateID: tpl.ID, Version: tpl.Version, Locale: tpl.Locale}
+	funcs := FuncMap(tpl.Locale)
+
+	var err error
+	if out.Subject, err = executeText("subject", tpl.Subject, funcs, data); err != nil {
+		return Rendered{}, err
+	}
+	if out.Text, err = executeText("text", tpl.Text, funcs, data); err != nil {
+		return Rendered{}, err
+	}
+	if out.HTML, err = executeHTML("html", tpl.HTML, funcs, data); err != nil {
+		return Rendered{}, err
+	}
+	return out, nil
+}
+
+// Validate parses every part of tpl without executing it.
+func Validate(tpl Template) error {
+	if tpl.Subject == "" && tpl.Text == "" && tpl.HTML == "" {
+		return errors.New("template requires at least one of subject, html or text")
+	}
+	funcs := FuncMap(tpl.Locale)
+	for part, body := range map[string]string{"subject": tpl.Subject, "text": tpl.Text} {
+		if _, err := texttemplate.New(part).Funcs(funcs).Parse(body); err != nil {
+			return fmt.Errorf("parse %s: %w", part, err)
+		}
+	}
+	if _, err := htmltemplate.New("html").Funcs(htmltemplate.FuncMap(funcs)).Parse(tpl.HTML); err != nil {
+		return fmt.Errorf("parse html: %w", err)
+	}
+	return validateVariants(tpl)
+}
+
+func executeText(part, body string, funcs texttemplate.FuncMap, data map[string]any) (string, error) {
+	if body == "" {
+		return "", nil
+	}
+	t, err := texttemplate.New(part).Funcs(funcs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("parse %s: %w", part, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", renderErr(part, err)
+	}
+	return buf.String(), nil
+}
+
+func executeHTML(part, body string, funcs texttemplate.FuncMap, data map[string]any) (string, error) {
+	if body == "" {
+		return "", nil
+	}
+	t, err := htmltemplate.New(part).Funcs(htmltemplate.FuncMap(funcs)).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("parse %s: %w", part, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", renderErr(part, err)
+	}
+	return buf.String(), nil
+}
+
+func renderErr(part string, err error) error {
+	if m := missingKeyPattern.FindStringSubmatch(err.Error()); m != nil {
+		return &MissingVariableError{Part: part, Name: m[1]}
+	}
+	return fmt.Errorf("render %s: %w", part, err)
+}
@@ -0,0 +1,193 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tpl := Template{
+		ID:      "welcome",
+		Version: 3,
+		Subject: "Welcome, {{.name}}",
+		HTML:    "<p>Hello {{.name}}</p>",
+		Text:    "Hello {{.name}}",
+	}
+
+	got, err := Render(tpl, map[string]any{"name": "<Ada>"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Subject != "Welcome, <Ada>" {
+		t.Fatalf("subject=%q", got.Subject)
+	}
+	if got.HTML != "<p>Hello &lt;Ada&gt;</p>" {
+		t.Fatalf("html=%q", got.HTML)
+	}
+	if got.Text != "Hello <Ada>" {
+		t.Fatalf("text=%q", got.Text)
+	}
+	if got.TemplateID != "welcome" || got.Version != 3 {
+		t.Fatalf("unexpected version info: %+v", got)
+	}
+}
+
+func TestRenderMissingVariable(t *testing.T) {
+	tpl := Template{Subject: "Hi", HTML: "<p>{{.user.name}}</p>"}
+
+	_, err := Render(tpl, map[string]any{"user": map[string]any{}})
+	var missing *MissingVariableError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected MissingVariableError, got %v", err)
+	}
+	if missing.Part != "html" || missing.Name != "name" {
+		t.Fatalf("unexpected error details: %+v", missing)
+	}
+
+	if _, err := Render(tpl, nil); !errors.As(err, &missing) {
+		t.Fatalf("expected MissingVariableError for nil data, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(Template{}); err == nil {
+		t.Fatalf("expected error for empty template")
+	}
+	if err := Validate(Template{Text: "{{.name"}); err == nil {
+		t.Fatalf("expected parse error")
+	}
+	if err := Validate(Template{Subject: "{{.name}}"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceRenderAssignsVariant(t *testing.T) {
+	tpl := Template{
+		ID:      "promo",
+		Locale:  "en",
+		Version: 1,
+		Subject: "Base",
+		Text:    "Body",
+		Variants: []Variant{
+			{Name: "A", Weight: 1, Subject: "Subject A"},
+			{Name: "B", Weight: 1, Subject: "Subject B"},
+		},
+	}
+	svc := NewService(staticStore{tpl})
+
+	seen := map[string]int{}
+	for _, recipient := range []string{"a@x.com", "b@x.com", "c@x.com", "d@x.com", "e@x.com", "f@x.com", "g@x.com", "h@x.com"} {
+		req := RenderRequest{TemplateID: "promo", Recipient: recipient}
+		first, err := svc.Render(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		again, _ := svc.Render(context.Background(), req)
+		if first.Variant != again.Variant {
+			t.Fatalf("assignment for %s is not stable", recipient)
+		}
+		if first.Subject != "Subject "+first.Variant || first.Text != "Body" {
+			t.Fatalf("variant not applied: %+v", first)
+		}
+		seen[first.Variant]++
+	}
+	if seen["A"] == 0 || seen["B"] == 0 {
+		t.Fatalf("expected both variants to be assigned, got %v", seen)
+	}
+}
+
+func TestResolvePinnedVersion(t *testing.T) {
+	store := versionStore{
+		"pt-BR": {1, 2},
+		"en":    {1, 2, 3, 4},
+	}
+	svc := NewService(store)
+
+	tests := []struct {
+		name       string
+		locale     string
+		version    int
+		wantLocale string
+		wantVer    int
+		wantErr    bool
+	}{
+		{name: "pinned requested locale", locale: "pt-BR", version: 2, wantLocale: "pt-BR", wantVer: 2},
+		{name: "latest", locale: "pt-BR", wantLocale: "pt-BR", wantVer: 2},
+		{name: "fallback ignores pin", locale: "de", version: 2, wantLocale: "en", wantVer: 4},
+		{name: "missing pinned version", locale: "pt-BR", version: 3, wantLocale: "en", wantVer: 4},
+		{name: "pinned default locale", locale: "en", version: 3, wantLocale: "en", wantVer: 3},
+		{name: "unknown pinned default", locale: "en", version: 9, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := svc.Resolve(context.Background(), "acme", "welcome", tt.locale, tt.version)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", tpl)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tpl.Locale != tt.wantLocale || tpl.Version != tt.wantVer {
+				t.Fatalf("resolved %s v%d, expected %s v%d", tpl.Locale, tpl.Version, tt.wantLocale, tt.wantVer)
+			}
+		})
+	}
+}
+
+func TestAssignVariantSkipsZeroWeight(t *testing.T) {
+	variants := []Variant{{Name: "off", Weight: 0}, {Name: "on", Weight: 5}}
+	for _, r := range []string{"1", "2", "3"} {
+		if v, ok := AssignVariant("tpl", r, variants); !ok || v.Name != "on" {
+			t.Fatalf("expected variant on, got %+v", v)
+		}
+	}
+	if _, ok := AssignVariant("tpl", "1", nil); ok {
+		t.Fatalf("expected no assignment without variants")
+	}
+}
+
+type staticStore struct{ tpl Template }
+
+func (s staticStore) CreateVersion(context.Context, Template) (Template, error) { return s.tpl, nil }
+
+func (s staticStore) Get(_ context.Context, _, id, locale string, _ int) (Template, error) {
+	if id != s.tpl.ID || locale != s.tpl.Locale {
+		return Template{}, ErrNotFound
+	}
+	return s.tpl, nil
+}
+
+func (s staticStore) ListVersions(context.Context, string, string) ([]Template, error) {
+	return []Template{s.tpl}, nil
+}
+
+// versionStore holds the version numbers stored for each locale.
+type versionStore map[string][]int
+
+func (s versionStore) CreateVersion(_ context.Context, tpl Template) (Template, error) {
+	return tpl, nil
+}
+
+func (s versionStore) Get(_ context.Context, _, id, locale string, version int) (Template, error) {
+	versions := s[locale]
+	if len(versions) == 0 {
+		return Template{}, ErrNotFound
+	}
+	if version == 0 {
+		version = versions[len(versions)-1]
+	}
+	for _, v := range versions {
+		if v == version {
+			return Template{ID: id, Locale: locale, Version: v}, nil
+		}
+	}
+	return Template{}, ErrNotFound
+}
+
+func (s versionStore) ListVersions(context.Context, string, string) ([]Template, error) {
+	return nil, nil
+}
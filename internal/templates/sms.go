@@ -0,0 +1,77 @@
+package templates
+
+const (
+	gsmSingleSegment  = 160
+	gsmMultiSegment   = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+)
+
+// gsmBasic is the GSM 03.38 default alphabet; gsmExtended characters are
+// sent with an escape prefix and cost two septets.
+const (
+	gsmBasic    = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsmExtended = "^{}\\[~]|€\f"
+)
+
+var (
+	gsmBasicSet    = runeSet(gsmBasic)
+	gsmExtendedSet = runeSet(gsmExtended)
+)
+
+type SMSInfo struct {
+	Encoding string `json:"encoding"`
+	// Units counts septets for GSM-7 or UTF-16 code units for UCS-2.
+	Units    int `json:"units"`
+	Segments int `json:"segments"`
+}
+
+// SMSSegments estimates how many concatenated SMS parts text will be sent as.
+func SMSSegments(text string) SMSInfo {
+	if text == "" {
+		return SMSInfo{Encoding: "GSM-7"}
+	}
+	septets, gsm := gsmLength(text)
+	if gsm {
+		return SMSInfo{Encoding: "GSM-7", Units: septets, Segments: segments(septets, gsmSingleSegment, gsmMultiSegment)}
+	}
+	units := 0
+	for _, r := range text {
+		if r > 0xFFFF {
+			units += 2
+		} else {
+			units++
+		}
+	}
+	return SMSInfo{Encoding: "UCS-2", Units: units, Segments: segments(units, ucs2SingleSegment, ucs2MultiSegment)}
+}
+
+func gsmLength(text string) (int, bool) {
+	n := 0
+	for _, r := range text {
+		switch {
+		case gsmBasicSet[r]:
+			n++
+		case gsmExtendedSet[r]:
+			n += 2
+		default:
+			return 0, false
+		}
+	}
+	return n, true
+}
+
+func segments(units, single, multi int) int {
+	if units <= single {
+		return 1
+	}
+	return (units + multi - 1) / multi
+}
+
+func runeSet(s string) map[rune]bool {
+	set := make(map[rune]bool, len(s))
+	for _, r := range s {
+		set[r] = true
+	}
+	return set
+}
@@ -0,0 +1,57 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSMSSegments(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want SMSInfo
+	}{
+		{name: "empty", text: "", want: SMSInfo{Encoding: "GSM-7"}},
+		{name: "single gsm", text: "Your code is 1234", want: SMSInfo{Encoding: "GSM-7", Units: 17, Segments: 1}},
+		{name: "gsm limit", text: strings.Repeat("a", 160), want: SMSInfo{Encoding: "GSM-7", Units: 160, Segments: 1}},
+		{name: "gsm multipart", text: strings.Repeat("a", 161), want: SMSInfo{Encoding: "GSM-7", Units: 161, Segments: 2}},
+		{name: "extended chars", text: "€10 {ok}", want: SMSInfo{Encoding: "GSM-7", Units: 11, Segments: 1}},
+		{name: "ucs2", text: "Olá ✓", want: SMSInfo{Encoding: "UCS-2", Units: 5, Segments: 1}},
+		{name: "ucs2 multipart", text: strings.Repeat("✓", 71), want: SMSInfo{Encoding: "UCS-2", Units: 71, Segments: 2}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SMSSegments(tc.text); got != tc.want {
+				t.Fatalf("SMSSegments(%q)=%+v, expected %+v", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	list := []string{"qa@example.com", "@staff.example.com"}
+	cases := []struct {
+		address string
+		want    bool
+	}{
+		{address: "QA@example.com", want: true},
+		{address: "dev@staff.example.com", want: true},
+		{address: "someone@example.com"},
+		{address: "dev@evilstaff.example.com"},
+		{address: "dev@x.staff.example.com"},
+		{address: `"a@staff.example.com"@evil.com`},
+		{address: "evil@evil.com, dev@staff.example.com"},
+		{address: "Dev <dev@staff.example.com>"},
+		{address: "dev@staff.example.com (dev)"},
+		{address: "not an address"},
+	}
+	for _, tc := range cases {
+		if got := allowed(list, tc.address); got != tc.want {
+			t.Fatalf("allowed(%q)=%v, expected %v", tc.address, got, tc.want)
+		}
+	}
+	if allowed(nil, "qa@example.com") {
+		t.Fatalf("empty allow-list must reject")
+	}
+}
@@ -0,0 +1,81 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+// Variant overrides parts of a template version for an A/B experiment. Empty
+// parts inherit the base template's content.
+type Variant struct {
+	Name    string `json:"name"`
+	Weight  int    `json:"weight"`
+	Subject string `json:"subject,omitempty"`
+	HTML    string `json:"html,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+// AssignVariant picks a variant for recipient by weight. The choice is a
+// stable hash of the template id and recipient, so the same recipient always
+// lands in the same variant for a given template.
+func AssignVariant(templateID, recipient string, variants []Variant) (Variant, bool) {
+	total := 0
+	for _, v := range variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+	if total == 0 {
+		return Variant{}, false
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(templateID + ":" + recipient))
+	bucket := int(h.Sum64() % uint64(total))
+	for _, v := range variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if bucket < v.Weight {
+			return v, true
+		}
+		bucket -= v.Weight
+	}
+	return Variant{}, false
+}
+
+// WithVariant returns tpl with v's non-empty parts applied.
+func (tpl Template) WithVariant(v Variant) Template {
+	if v.Subject != "" {
+		tpl.Subject = v.Subject
+	}
+	if v.HTML != "" {
+		tpl.HTML = v.HTML
+	}
+	if v.Text != "" {
+		tpl.Text = v.Text
+	}
+	tpl.Variants = nil
+	return tpl
+}
+
+func validateVariants(tpl Template) error {
+	seen := map[string]bool{}
+	for _, v := range tpl.Variants {
+		if v.Name == "" {
+			return errors.New("variant name is required")
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate variant %q", v.Name)
+		}
+		seen[v.Name] = true
+		if v.Weight <= 0 {
+			return fmt.Errorf("variant %q requires a positive weight", v.Name)
+		}
+		if err := Validate(tpl.WithVariant(v)); err != nil {
+			return fmt.Errorf("variant %q: %w", v.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package throttle
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// AnyChannel applies a limit to a tenant's combined traffic.
+const AnyChannel = "*"
+
+// Limit caps a tenant's send rate, optionally for a single channel.
+type Limit struct {
+	TenantID  string
+	Channel   string
+	PerMinute int
+	Burst     int
+}
+
+type Repository interface {
+	ListLimits(ctx context.Context) ([]Limit, error)
+}
+
+// bucket is a token bucket that may go into debt: a reservation beyond the
+// available tokens is still granted, with the wait until it is covered. That
+// spreads deferred excess evenly instead of releasing it all at once.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+	burst  float64
+}
+
+func newBucket(l Limit, replicas int, now time.Time) *bucket {
+	b := &bucket{last: now}
+	b.configure(l, replicas)
+	b.tokens = b.burst
+	return b
+}
+
+// configure sets the bucket to one replica's share of l.
+func (b *bucket) configure(l Limit, replicas int) {
+	if replicas < 1 {
+		replicas = 1
+	}
+	b.rate = float64(l.PerMinute) / 60 / float64(replicas)
+	b.burst = float64(l.Burst) / float64(replicas)
+	if b.burst < 1 {
+		b.burst = math.Max(1, b.rate)
+	}
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *bucket) reserve(now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,40 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const selectLimits = `
+SELECT tenant_id, channel, per_minute, burst
+FROM rate_limits
+WHERE per_minute > 0
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) ListLimits(ctx context.Context) ([]Limit, error) {
+	rows, err := r.pool.Query(ctx, selectLimits)
+	if err != nil {
+		return nil, fmt.Errorf("list rate limits: %w", err)
+	}
+	defer rows.Close()
+
+	var limits []Limit
+	for rows.Next() {
+		var l Limit
+		if err := rows.Scan(&l.TenantID, &l.Channel, &l.PerMinute, &l.Burst); err != nil {
+			return nil, fmt.Errorf("scan rate limit: %w", err)
+		}
+		limits = append(limits, l)
+	}
+	return limits, rows.Err()
+}
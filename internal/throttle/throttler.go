@@ -0,0 +1,129 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+)
+
+const defaultRefreshInterval = 30 * time.Second
+
+var (
+	limitGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "throttle_limit_per_minute",
+		Help: "Configured send-rate cap per tenant and channel",
+	}, []string{"tenant_id", "channel"})
+	throttleDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "throttle_decisions_total",
+		Help: "Dispatch-time throttle decisions per tenant and channel",
+	}, []string{"tenant_id", "channel", "decision"})
+)
+
+// Throttler enforces tenant-wide and per-channel token buckets. Limits are
+// loaded from the repository and refreshed by Run; tenants without a limit
+// are not throttled. Buckets are per process, so each of Replicas dispatcher
+// replicas enforces 1/Replicas of every limit; together they stay within
+// the cap as long as a tenant's traffic spreads evenly over the partitions.
+type Throttler struct {
+	Repo            Repository
+	RefreshInterval time.Duration
+	// Replicas is the number of dispatcher replicas sharing the limits;
+	// zero means one.
+	Replicas int
+	Logger   zerolog.Logger
+
+	mu      sync.Mutex
+	limits  map[string]Limit
+	buckets map[string]*bucket
+}
+
+func key(tenantID, channel string) string {
+	return tenantID + "|" + channel
+}
+
+// Refresh reloads limits, reconfiguring existing buckets in place so a
+// change does not reset accumulated debt.
+func (t *Throttler) Refresh(ctx context.Context) error {
+	limits, err := t.Repo.ListLimits(ctx)
+	if err != nil {
+		return err
+	}
+	next := make(map[string]Limit, len(limits))
+	for _, l := range limits {
+		next[key(l.TenantID, l.Channel)] = l
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k, old := range t.limits {
+		if _, ok := next[k]; !ok {
+			delete(t.buckets, k)
+			limitGauge.DeleteLabelValues(old.TenantID, old.Channel)
+		}
+	}
+	for k, l := range next {
+		if b, ok := t.buckets[k]; ok {
+			b.configure(l, t.Replicas)
+		}
+		limitGauge.WithLabelValues(l.TenantID, l.Channel).Set(float64(l.PerMinute))
+	}
+	t.limits = next
+	return nil
+}
+
+// Run refreshes limits until ctx is cancelled.
+func (t *Throttler) Run(ctx context.Context) error {
+	interval := t.RefreshInterval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := t.Refresh(ctx); err != nil {
+				t.Logger.Error().Err(err).Msg("failed to refresh rate limits")
+			}
+		}
+	}
+}
+
+// Reserve takes a send slot for tenantID on channel and returns how long the
+// send must wait for it. Zero means send now.
+func (t *Throttler) Reserve(tenantID, channel string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var wait time.Duration
+	for _, k := range []string{key(tenantID, AnyChannel), key(tenantID, channel)} {
+		l, ok := t.limits[k]
+		if !ok {
+			continue
+		}
+		b, ok := t.buckets[k]
+		if !ok {
+			if t.buckets == nil {
+				t.buckets = map[string]*bucket{}
+			}
+			b = newBucket(l, t.Replicas, now)
+			t.buckets[k] = b
+		}
+		if d := b.reserve(now); d > wait {
+			wait = d
+		}
+	}
+
+	decision := "allowed"
+	if wait > 0 {
+		decision = "deferred"
+	}
+	throttleDecisions.WithLabelValues(tenantID, channel, decision).Inc()
+	return wait
+}
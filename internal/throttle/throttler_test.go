@@ -0,0 +1,93 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type staticRepo []Limit
+
+func (r staticRepo) ListLimits(context.Context) ([]Limit, error) {
+	return r, nil
+}
+
+func TestReserve(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		limits   []Limit
+		replicas int
+		channel  string
+		sends    int
+		want     []time.Duration
+	}{
+		{
+			name:    "no limit",
+			channel: "email",
+			sends:   3,
+			want:    []time.Duration{0, 0, 0},
+		},
+		{
+			name:    "burst then spaced",
+			limits:  []Limit{{TenantID: "t1", Channel: "email", PerMinute: 60, Burst: 2}},
+			channel: "email",
+			sends:   4,
+			want:    []time.Duration{0, 0, time.Second, 2 * time.Second},
+		},
+		{
+			name:    "other channel unaffected",
+			limits:  []Limit{{TenantID: "t1", Channel: "sms", PerMinute: 60, Burst: 1}},
+			channel: "email",
+			sends:   2,
+			want:    []time.Duration{0, 0},
+		},
+		{
+			name: "tenant cap is stricter",
+			limits: []Limit{
+				{TenantID: "t1", Channel: AnyChannel, PerMinute: 30, Burst: 1},
+				{TenantID: "t1", Channel: "email", PerMinute: 600, Burst: 10},
+			},
+			channel: "email",
+			sends:   2,
+			want:    []time.Duration{0, 2 * time.Second},
+		},
+		{
+			name:     "replica share",
+			limits:   []Limit{{TenantID: "t1", Channel: "email", PerMinute: 60, Burst: 2}},
+			replicas: 2,
+			channel:  "email",
+			sends:    3,
+			want:     []time.Duration{0, 2 * time.Second, 4 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := &Throttler{Repo: staticRepo(tt.limits), Replicas: tt.replicas}
+			if err := th.Refresh(context.Background()); err != nil {
+				t.Fatalf("refresh: %v", err)
+			}
+			for i := 0; i < tt.sends; i++ {
+				if got := th.Reserve("t1", tt.channel, now); got != tt.want[i] {
+					t.Fatalf("send %d: got wait %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReserveRefills(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	th := &Throttler{Repo: staticRepo{{TenantID: "t1", Channel: "email", PerMinute: 60, Burst: 1}}}
+	if err := th.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if got := th.Reserve("t1", "email", now); got != 0 {
+		t.Fatalf("first send waited %v", got)
+	}
+	if got := th.Reserve("t1", "email", now.Add(time.Second)); got != 0 {
+		t.Fatalf("send after refill waited %v", got)
+	}
+}
@@ -0,0 +1,47 @@
+package tracking
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// botAgents are User-Agent fragments of crawlers, link scanners and HTTP
+// libraries. Mail image proxies such as GoogleImageProxy fetch on a real
+// open and are not listed.
+var botAgents = []string{
+	"bot", "crawler", "spider", "slurp", "preview", "scanner",
+	"facebookexternalhit", "headlesschrome", "phantomjs",
+	"barracuda", "mimecast", "proofpoint", "symantec", "trendmicro",
+	"curl/", "wget/", "python-requests", "go-http-client", "java/", "okhttp",
+}
+
+// scanDelay is how soon after sending a click is taken to come from a
+// security gateway following every link rather than from the recipient.
+const scanDelay = 5 * time.Second
+
+// botReason explains why a hit looks automated, or returns "" when it
+// looks like a person.
+func botReason(r *http.Request, claims Claims, now time.Time) string {
+	if r.Method == http.MethodHead {
+		return "head"
+	}
+	for _, h := range []string{"Purpose", "Sec-Purpose", "X-Purpose", "X-Moz"} {
+		if strings.Contains(strings.ToLower(r.Header.Get(h)), "prefetch") {
+			return "prefetch"
+		}
+	}
+	agent := strings.ToLower(r.UserAgent())
+	if agent == "" {
+		return "no_user_agent"
+	}
+	for _, fragment := range botAgents {
+		if strings.Contains(agent, fragment) {
+			return "user_agent"
+		}
+	}
+	if claims.URL != "" && claims.IssuedAt > 0 && now.Sub(claims.issued()) < scanDelay {
+		return "too_fast"
+	}
+	return ""
+}
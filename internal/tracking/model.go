@@ -0,0 +1,26 @@
+package tracking
+
+import (
+	"context"
+	"time"
+)
+
+// Settings is a tenant's tracking configuration. Tracking is on until a
+// tenant opts out.
+type Settings struct {
+	TenantID  string    `json:"tenant_id"`
+	Opens     bool      `json:"opens"`
+	Clicks    bool      `json:"clicks"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultSettings applies to tenants that never changed their settings.
+func DefaultSettings(tenantID string) Settings {
+	return Settings{TenantID: tenantID, Opens: true, Clicks: true}
+}
+
+type Repository interface {
+	// Get returns DefaultSettings for tenants without stored settings.
+	Get(ctx context.Context, tenantID string) (Settings, error)
+	Put(ctx context.Context, s Settings) (Settings, error)
+}
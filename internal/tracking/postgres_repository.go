@@ -0,0 +1,53 @@
+package tracking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const selectSettings = `
+SELECT tenant_id, opens_enabled, clicks_enabled, updated_at
+FROM tracking_settings
+WHERE tenant_id = $1
+`
+
+const upsertSettings = `
+INSERT INTO tracking_settings (tenant_id, opens_enabled, clicks_enabled, updated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (tenant_id)
+DO UPDATE SET opens_enabled = EXCLUDED.opens_enabled, clicks_enabled = EXCLUDED.clicks_enabled, updated_at = now()
+RETURNING tenant_id, opens_enabled, clicks_enabled, updated_at
+`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, tenantID string) (Settings, error) {
+	var s Settings
+	err := r.pool.QueryRow(ctx, selectSettings, tenantID).Scan(&s.TenantID, &s.Opens, &s.Clicks, &s.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DefaultSettings(tenantID), nil
+	}
+	if err != nil {
+		return Settings{}, fmt.Errorf("select tracking settings: %w", err)
+	}
+	return s, nil
+}
+
+func (r *PostgresRepository) Put(ctx context.Context, s Settings) (Settings, error) {
+	var saved Settings
+	err := r.pool.QueryRow(ctx, upsertSettings, s.TenantID, s.Opens, s.Clicks).Scan(&saved.TenantID, &saved.Opens, &saved.Clicks, &saved.UpdatedAt)
+	if err != nil {
+		return Settings{}, fmt.Errorf("upsert tracking settings: %w", err)
+	}
+	return saved, nil
+}
@@ -0,0 +1,97 @@
+package tracking
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// anchorHref matches the href attribute of an <a> tag, double or single
+// quoted.
+var anchorHref = regexp.MustCompile(`(?is)(<a\b[^>]*?\bhref\s*=\s*)(?:"([^"]*)"|'([^']*)')`)
+
+var bodyClose = regexp.MustCompile(`(?i)</body\s*>`)
+
+// Instrumenter adds open and click tracking to rendered email HTML,
+// honouring each tenant's settings.
+type Instrumenter struct {
+	Signer   *Signer
+	Settings Repository
+	Now      func() time.Time
+}
+
+// Instrument rewrites the links of html to signed click redirects and
+// appends an open pixel, as far as the tenant's settings allow.
+func (i *Instrumenter) Instrument(ctx context.Context, tenantID, messageID, html string) (string, error) {
+	settings := DefaultSettings(tenantID)
+	if i.Settings != nil {
+		var err error
+		if settings, err = i.Settings.Get(ctx, tenantID); err != nil {
+			return "", err
+		}
+	}
+	now := time.Now
+	if i.Now != nil {
+		now = i.Now
+	}
+	claims := Claims{TenantID: tenantID, MessageID: messageID, IssuedAt: now().Unix()}
+	return rewrite(html, i.Signer, claims, settings)
+}
+
+// rewrite does the work of Instrument for fixed claims and settings.
+func rewrite(body string, signer *Signer, claims Claims, settings Settings) (string, error) {
+	if settings.Clicks {
+		var rewriteErr error
+		body = anchorHref.ReplaceAllStringFunc(body, func(tag string) string {
+			m := anchorHref.FindStringSubmatch(tag)
+			href := m[2]
+			if strings.HasPrefix(tag[len(m[1]):], "'") {
+				href = m[3]
+			}
+			target := html.UnescapeString(strings.TrimSpace(href))
+			if !trackable(target, signer.BaseURL) {
+				return tag
+			}
+			c := claims
+			c.URL = target
+			link, err := signer.ClickURL(c)
+			if err != nil {
+				rewriteErr = err
+				return tag
+			}
+			return m[1] + `"` + html.EscapeString(link) + `"`
+		})
+		if rewriteErr != nil {
+			return "", rewriteErr
+		}
+	}
+	if settings.Opens {
+		pixelURL, err := signer.OpenURL(claims)
+		if err != nil {
+			return "", err
+		}
+		pixel := `<img src="` + html.EscapeString(pixelURL) + `" width="1" height="1" alt="" style="display:none;border:0">`
+		if loc := bodyClose.FindStringIndex(body); loc != nil {
+			body = body[:loc[0]] + pixel + body[loc[0]:]
+		} else {
+			body += pixel
+		}
+	}
+	return body, nil
+}
+
+// trackable reports whether a link should go through the click redirect:
+// only absolute web links, and never unsubscribe links or links already
+// pointing at the tracking service.
+func trackable(link, base string) bool {
+	lower := strings.ToLower(link)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return false
+	}
+	if strings.Contains(lower, "/v1/unsubscribe") {
+		return false
+	}
+	return base == "" || !strings.HasPrefix(link, strings.TrimRight(base, "/")+"/t/")
+}
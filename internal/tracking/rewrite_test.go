@@ -0,0 +1,110 @@
+package tracking
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRewrite(t *testing.T) {
+	signer := &Signer{Secret: []byte("secret"), BaseURL: "https://t.example.com"}
+	claims := Claims{TenantID: "t1", MessageID: "m1", IssuedAt: 1700000000}
+	body := `<html><body>` +
+		`<a href="https://shop.example.com/p?a=1&amp;b=2">Shop</a>` +
+		`<a class="x" href='http://example.com/'>Home</a>` +
+		`<a href="mailto:help@example.com">Mail</a>` +
+		`<a href="https://api.example.com/v1/unsubscribe?token=abc">Unsubscribe</a>` +
+		`<a href="#top">Top</a>` +
+		`</body></html>`
+
+	cases := []struct {
+		name     string
+		settings Settings
+		links    int
+		pixel    bool
+	}{
+		{name: "all", settings: DefaultSettings("t1"), links: 2, pixel: true},
+		{name: "opens only", settings: Settings{Opens: true}, pixel: true},
+		{name: "clicks only", settings: Settings{Clicks: true}, links: 2},
+		{name: "opted out", settings: Settings{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := rewrite(body, signer, claims, tc.settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := strings.Count(got, `href="https://t.example.com/t/c/`); n != tc.links {
+				t.Fatalf("%d tracked links, want %d:\n%s", n, tc.links, got)
+			}
+			if strings.Contains(got, "/t/o/") != tc.pixel {
+				t.Fatalf("pixel present = %v, want %v:\n%s", !tc.pixel, tc.pixel, got)
+			}
+			if tc.pixel && !strings.Contains(got, `style="display:none;border:0"></body>`) {
+				t.Fatalf("pixel not placed before </body>:\n%s", got)
+			}
+			for _, kept := range []string{"mailto:help@example.com", "/v1/unsubscribe?token=abc", `href="#top"`} {
+				if !strings.Contains(got, kept) {
+					t.Fatalf("%s was rewritten:\n%s", kept, got)
+				}
+			}
+		})
+	}
+
+	// The click token carries the unescaped destination.
+	got, _ := rewrite(`<a href="https://shop.example.com/p?a=1&amp;b=2">Shop</a>`, signer, claims, Settings{Clicks: true})
+	token := strings.TrimSuffix(strings.SplitN(got, "/t/c/", 2)[1], `">Shop</a>`)
+	c, err := signer.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.URL != "https://shop.example.com/p?a=1&b=2" || c.MessageID != "m1" {
+		t.Fatalf("claims = %+v", c)
+	}
+	if _, err := signer.Verify(token + "x"); err == nil {
+		t.Fatal("tampered token verified")
+	}
+}
+
+func TestBotReason(t *testing.T) {
+	now := time.Unix(1700000100, 0)
+	const browser = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko)"
+	cases := []struct {
+		name   string
+		method string
+		agent  string
+		header string
+		claims Claims
+		want   string
+	}{
+		{name: "browser open", agent: browser, claims: Claims{IssuedAt: 1700000099}},
+		{name: "gmail image proxy", agent: "Mozilla/5.0 (Windows NT 5.1; rv:11.0) Gecko Firefox/11.0 (via ggpht.com GoogleImageProxy)"},
+		{name: "browser click", agent: browser, claims: Claims{URL: "https://x", IssuedAt: 1700000000}},
+		{name: "head", method: "HEAD", agent: browser, want: "head"},
+		{name: "prefetch", agent: browser, header: "prefetch", want: "prefetch"},
+		{name: "no agent", want: "no_user_agent"},
+		{name: "crawler", agent: "Mozilla/5.0 (compatible; Googlebot/2.1)", want: "user_agent"},
+		{name: "library", agent: "python-requests/2.31", want: "user_agent"},
+		{name: "scanner click right after send", agent: browser, claims: Claims{URL: "https://x", IssuedAt: 1700000098}, want: "too_fast"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = "GET"
+			}
+			r := httptest.NewRequest(method, "/t/o/x", nil)
+			r.Header.Del("User-Agent")
+			if tc.agent != "" {
+				r.Header.Set("User-Agent", tc.agent)
+			}
+			if tc.header != "" {
+				r.Header.Set("Sec-Purpose", tc.header)
+			}
+			if got := botReason(r, tc.claims, now); got != tc.want {
+				t.Fatalf("botReason = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
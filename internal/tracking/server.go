@@ -0,0 +1,116 @@
+package tracking
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/webhook"
+)
+
+var hits = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "tracking_hits_total",
+	Help: "Open and click tracking hits, by kind and result",
+}, []string{"kind", "result"})
+
+// pixel is a transparent 1x1 GIF.
+var pixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// Server serves tracking pixels and click redirects and publishes the
+// opens and clicks that look human to provider.events, in the same shape
+// as provider webhook events.
+type Server struct {
+	Signer   *Signer
+	Producer *kafka.Writer
+	Logger   zerolog.Logger
+}
+
+func (s *Server) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/t/o/{token}", s.open)
+	r.Head("/t/o/{token}", s.open)
+	r.Get("/t/c/{token}", s.click)
+	r.Head("/t/c/{token}", s.click)
+	return r
+}
+
+// open always answers with the pixel so a bad token never shows a broken
+// image.
+func (s *Server) open(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, private")
+	w.Header().Set("Expires", "0")
+	defer func() { _, _ = w.Write(pixel) }()
+
+	claims, err := s.Signer.Verify(strings.TrimSuffix(chi.URLParam(r, "token"), ".gif"))
+	if err != nil || claims.URL != "" {
+		hits.WithLabelValues("open", "invalid").Inc()
+		return
+	}
+	s.record(r, claims, webhook.StatusOpened, "open")
+}
+
+func (s *Server) click(w http.ResponseWriter, r *http.Request) {
+	claims, err := s.Signer.Verify(chi.URLParam(r, "token"))
+	if err != nil || claims.URL == "" {
+		hits.WithLabelValues("click", "invalid").Inc()
+		http.NotFound(w, r)
+		return
+	}
+	s.record(r, claims, webhook.StatusClicked, "click")
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, claims.URL, http.StatusFound)
+}
+
+// record publishes the hit unless it looks automated. Publishing failures
+// are logged rather than surfaced: the recipient still gets the pixel or
+// the redirect.
+func (s *Server) record(r *http.Request, claims Claims, status, kind string) {
+	now := time.Now().UTC()
+	if reason := botReason(r, claims, now); reason != "" {
+		hits.WithLabelValues(kind, "bot_"+reason).Inc()
+		return
+	}
+	meta := map[string]any{"user_agent": r.UserAgent()}
+	if claims.URL != "" {
+		meta["url"] = claims.URL
+	}
+	event := webhook.NormalizedEvent{
+		MessageID: claims.MessageID,
+		TenantID:  claims.TenantID,
+		Provider:  "tracking",
+		Channel:   "email",
+		Status:    status,
+		Occurred:  now,
+		Meta:      meta,
+	}
+	ctx := r.Context()
+	logger := common.WithContext(ctx, s.Logger)
+	value, err := json.Marshal(event)
+	if err != nil {
+		logger.Error().Err(err).Msg("marshal tracking event")
+		return
+	}
+	// The hit is recorded even if the client goes away mid-request.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.Producer.WriteMessages(ctx, kafka.Message{Key: []byte(claims.MessageID), Value: value}); err != nil {
+		hits.WithLabelValues(kind, "error").Inc()
+		logger.Error().Err(err).Str("message_id", claims.MessageID).Msg("publish tracking event")
+		return
+	}
+	hits.WithLabelValues(kind, "recorded").Inc()
+}
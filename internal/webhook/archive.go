@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RawCallback is a provider request as received, kept so events can be
+// re-derived when a normalizer changes.
+type RawCallback struct {
+	ID         string
+	Provider   string
+	Headers    http.Header
+	Query      string
+	Body       []byte
+	ReceivedAt time.Time
+}
+
+// Archive stores raw callbacks.
+type Archive interface {
+	Store(ctx context.Context, cb RawCallback) error
+	// List returns callbacks received in [from, to) after the (afterTime,
+	// afterID) cursor, oldest first. An empty provider matches all.
+	List(ctx context.Context, provider string, from, to time.Time, afterTime time.Time, afterID string, limit int) ([]RawCallback, error)
+}
+
+// unarchivedHeaders carry credentials rather than callback content.
+var unarchivedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+func rawCallback(provider string, r *http.Request, body []byte, received time.Time) RawCallback {
+	headers := r.Header.Clone()
+	for _, h := range unarchivedHeaders {
+		headers.Del(h)
+	}
+	return RawCallback{
+		ID:         uuid.NewString(),
+		Provider:   provider,
+		Headers:    headers,
+		Query:      r.URL.RawQuery,
+		Body:       body,
+		ReceivedAt: received,
+	}
+}
+
+const insertRawCallback = `
+INSERT INTO webhook_archive (id, provider, headers_json, query, body, received_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+const selectRawCallbacks = `
+SELECT id, provider, headers_json, query, body, received_at
+FROM webhook_archive
+WHERE ($1 = '' OR provider = $1)
+AND received_at >= $2 AND received_at < $3
+AND (received_at, id) > ($4, $5)
+ORDER BY received_at, id
+LIMIT $6
+`
+
+type PostgresArchive struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresArchive(pool *pgxpool.Pool) *PostgresArchive {
+	return &PostgresArchive{pool: pool}
+}
+
+func (a *PostgresArchive) Store(ctx context.Context, cb RawCallback) error {
+	headers, err := json.Marshal(cb.Headers)
+	if err != nil {
+		return fmt.Errorf("marshal headers: %w", err)
+	}
+	if _, err := a.pool.Exec(ctx, insertRawCallback, cb.ID, cb.Provider, headers, cb.Query, cb.Body, cb.ReceivedAt); err != nil {
+		return fmt.Errorf("insert raw callback: %w", err)
+	}
+	return nil
+}
+
+func (a *PostgresArchive) List(ctx context.Context, provider string, from, to time.Time, afterTime time.Time, afterID string, limit int) ([]RawCallback, error) {
+	if afterID == "" {
+		afterID = uuid.Nil.String()
+	}
+	rows, err := a.pool.Query(ctx, selectRawCallbacks, provider, from, to, afterTime, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list raw callbacks: %w", err)
+	}
+	defer rows.Close()
+
+	var callbacks []RawCallback
+	for rows.Next() {
+		var (
+			cb      RawCallback
+			headers []byte
+		)
+		if err := rows.Scan(&cb.ID, &cb.Provider, &headers, &cb.Query, &cb.Body, &cb.ReceivedAt); err != nil {
+			return nil, fmt.Errorf("scan raw callback: %w", err)
+		}
+		if err := json.Unmarshal(headers, &cb.Headers); err != nil {
+			return nil, fmt.Errorf("decode headers of %s: %w", cb.ID, err)
+		}
+		callbacks = append(callbacks, cb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate raw callbacks: %w", err)
+	}
+	return callbacks, nil
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// decodeJSON accepts a single JSON event or an array of events.
+func decodeJSON(body []byte) (Callback, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return Callback{}, errors.New("empty body")
+	}
+	if trimmed[0] == '[' {
+		var events []map[string]any
+		if err := json.Unmarshal(trimmed, &events); err != nil {
+			return Callback{}, fmt.Errorf("decode event batch: %w", err)
+		}
+		return Callback{Events: events}, nil
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(trimmed, &payload); err != nil {
+		return Callback{}, fmt.Errorf("decode event: %w", err)
+	}
+	return Callback{Events: []map[string]any{payload}}, nil
+}
+
+// decodeSNS unwraps an SNS envelope, whose Notification message holds the
+// event itself; other bodies are decoded as plain JSON events.
+func decodeSNS(body []byte) (Callback, error) {
+	cb, err := decodeJSON(body)
+	if err != nil || len(cb.Events) != 1 {
+		return cb, err
+	}
+	if _, ok := cb.Events[0]["TopicArn"]; !ok {
+		return cb, nil
+	}
+
+	var envelope snsEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return Callback{}, fmt.Errorf("decode SNS envelope: %w", err)
+	}
+	switch envelope.Type {
+	case "Notification":
+		var event map[string]any
+		if err := json.Unmarshal([]byte(envelope.Message), &event); err != nil {
+			return Callback{}, fmt.Errorf("decode SNS notification message: %w", err)
+		}
+		return Callback{Events: []map[string]any{event}, SNSType: envelope.Type, TopicARN: envelope.TopicArn}, nil
+	case "SubscriptionConfirmation":
+		return Callback{SNSType: envelope.Type, SubscribeURL: envelope.SubscribeURL, TopicARN: envelope.TopicArn}, nil
+	case "UnsubscribeConfirmation":
+		return Callback{SNSType: envelope.Type, TopicARN: envelope.TopicArn}, nil
+	default:
+		return Callback{}, fmt.Errorf("unsupported SNS message type %q", envelope.Type)
+	}
+}
+
+// confirmSubscription visits the SubscribeURL of an SNS confirmation. Only
+// SNS endpoints are followed so a forged confirmation cannot make the
+// service fetch arbitrary URLs.
+func confirmSubscription(ctx context.Context, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !snsCertHost.MatchString(u.Hostname()) {
+		return fmt.Errorf("untrusted SNS subscribe URL %q", subscribeURL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, subscribeURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("confirm SNS subscription: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
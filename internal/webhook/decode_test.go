@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestDecodeSNS(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		events       int
+		snsType      string
+		subscribeURL string
+		wantErr      bool
+	}{
+		{"single event", `{"event":"delivered","message_id":"m1"}`, 1, "", "", false},
+		{"sendgrid batch", `[{"event":"delivered"},{"event":"open"}]`, 2, "", "", false},
+		{"empty batch", `[]`, 0, "", "", false},
+		{
+			"sns notification",
+			`{"Type":"Notification","TopicArn":"arn","Message":"{\"eventType\":\"Delivery\",\"mail\":{\"messageId\":\"m1\"}}"}`,
+			1, "Notification", "", false,
+		},
+		{
+			"sns subscription confirmation",
+			`{"Type":"SubscriptionConfirmation","TopicArn":"arn","SubscribeURL":"https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription"}`,
+			0, "SubscriptionConfirmation", "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription", false,
+		},
+		{"sns unsubscribe", `{"Type":"UnsubscribeConfirmation","TopicArn":"arn"}`, 0, "UnsubscribeConfirmation", "", false},
+		{"sns message not json", `{"Type":"Notification","TopicArn":"arn","Message":"hello"}`, 0, "", "", true},
+		{"unknown sns type", `{"Type":"Other","TopicArn":"arn"}`, 0, "", "", true},
+		{"empty body", ` `, 0, "", "", true},
+		{"batch of non-objects", `[1,2]`, 0, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb, err := decodeSNS([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err=%v, wantErr %v", err, tt.wantErr)
+			}
+			if len(cb.Events) != tt.events || cb.SNSType != tt.snsType || cb.SubscribeURL != tt.subscribeURL {
+				t.Fatalf("unexpected callback %+v", cb)
+			}
+		})
+	}
+}
+
+func TestNormalizeSESNotification(t *testing.T) {
+	cb, err := decodeSNS([]byte(`{"Type":"Notification","TopicArn":"arn","Message":"{\"eventType\":\"Delivery\",\"mail\":{\"messageId\":\"ses-1\",\"destination\":[\"a@b.com\"],\"tags\":{\"message_id\":[\"m1\"],\"tenant_id\":[\"t1\"]}}}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := (&SES{}).Normalize(cb.Events[0], time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.MessageID != "m1" || event.TenantID != "t1" || event.Status != StatusDelivered || event.Recipient != "a@b.com" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
+
+func TestSubscriptionConfirmationTopics(t *testing.T) {
+	const allowed = "arn:aws:sns:us-east-1:123456789012:ses-events"
+	tests := []struct {
+		name      string
+		topic     string
+		status    int
+		confirmed bool
+	}{
+		{"allowed topic", allowed, http.StatusOK, true},
+		{"foreign topic", "arn:aws:sns:us-east-1:999999999999:ses-events", http.StatusForbidden, false},
+		{"no topic", "", http.StatusForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			confirmed := false
+			s := &Server{
+				Normalizers:      NewRegistry(&SES{Verifier: Unverified{}}),
+				AllowedTopicARNs: []string{allowed},
+				ConfirmSubscription: func(context.Context, string) error {
+					confirmed = true
+					return nil
+				},
+				Logger: zerolog.Nop(),
+			}
+			body := `{"Type":"SubscriptionConfirmation","TopicArn":"` + tt.topic + `","SubscribeURL":"https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription"}`
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/providers/ses/events", strings.NewReader(body)))
+			if rec.Code != tt.status || confirmed != tt.confirmed {
+				t.Fatalf("status=%d confirmed=%v, want %d/%v", rec.Code, confirmed, tt.status, tt.confirmed)
+			}
+		})
+	}
+}
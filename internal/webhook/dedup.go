@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// DefaultDedupTTL covers the retry windows of the supported providers.
+	DefaultDedupTTL        = 24 * time.Hour
+	defaultDedupMaxEntries = 1_000_000
+)
+
+var duplicateEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "webhook_duplicate_events_total",
+	Help: "Provider webhook events dropped as retries of already published events",
+}, []string{"provider"})
+
+// DedupStore remembers published event keys for a while.
+type DedupStore interface {
+	Seen(ctx context.Context, key string) (bool, error)
+	// Mark records keys as published until ttl passes.
+	Mark(ctx context.Context, keys []string, ttl time.Duration) error
+}
+
+// EventIdentifier is implemented by normalizers whose payloads carry a
+// provider event id that stays the same across retries.
+type EventIdentifier interface {
+	EventID(payload map[string]any) string
+}
+
+func (n *SendGrid) EventID(payload map[string]any) string {
+	return stringField(payload, "sg_event_id")
+}
+
+func (n *Mailgun) EventID(payload map[string]any) string {
+	return stringField(payload, "id")
+}
+
+// eventKey identifies a payload by provider event id, or by a hash of its
+// content when the provider sends none. encoding/json sorts map keys, so
+// equal payloads hash equally.
+func eventKey(n Normalizer, payload map[string]any) string {
+	if identifier, ok := n.(EventIdentifier); ok {
+		if id := identifier.EventID(payload); id != "" {
+			return n.Provider() + ":" + id
+		}
+	}
+	body, _ := json.Marshal(payload)
+	sum := sha256.Sum256(body)
+	return n.Provider() + ":sha256:" + hex.EncodeToString(sum[:])
+}
+
+// MemoryDedup is an in-process DedupStore holding at most MaxEntries keys,
+// evicting the oldest first. Each replica has its own, so a retry reaching
+// another replica is not caught.
+type MemoryDedup struct {
+	MaxEntries int
+	Now        func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type dedupEntry struct {
+	key     string
+	expires time.Time
+}
+
+func (m *MemoryDedup) Seen(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if !now(m.Now).Before(el.Value.(*dedupEntry).expires) {
+		m.order.Remove(el)
+		delete(m.entries, key)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (m *MemoryDedup) Mark(_ context.Context, keys []string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries == nil {
+		m.entries = map[string]*list.Element{}
+		m.order = list.New()
+	}
+	max := m.MaxEntries
+	if max <= 0 {
+		max = defaultDedupMaxEntries
+	}
+	expires := now(m.Now).Add(ttl)
+	for _, key := range keys {
+		if el, ok := m.entries[key]; ok {
+			el.Value.(*dedupEntry).expires = expires
+			m.order.MoveToBack(el)
+			continue
+		}
+		m.entries[key] = m.order.PushBack(&dedupEntry{key: key, expires: expires})
+		for m.order.Len() > max {
+			oldest := m.order.Front()
+			m.order.Remove(oldest)
+			delete(m.entries, oldest.Value.(*dedupEntry).key)
+		}
+	}
+	return nil
+}
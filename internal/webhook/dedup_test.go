@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEventKey(t *testing.T) {
+	cases := []struct {
+		name       string
+		normalizer Normalizer
+		a, b       map[string]any
+		same       bool
+	}{
+		{
+			name:       "sendgrid event id",
+			normalizer: &SendGrid{},
+			a:          map[string]any{"sg_event_id": "e1", "event": "delivered", "timestamp": 1},
+			b:          map[string]any{"sg_event_id": "e1", "event": "delivered", "timestamp": 2},
+			same:       true,
+		},
+		{
+			name:       "sendgrid distinct ids",
+			normalizer: &SendGrid{},
+			a:          map[string]any{"sg_event_id": "e1", "event": "delivered"},
+			b:          map[string]any{"sg_event_id": "e2", "event": "delivered"},
+		},
+		{
+			name:       "content hash ignores key order",
+			normalizer: &Postmark{},
+			a:          map[string]any{"RecordType": "Delivery", "MessageID": "m1"},
+			b:          map[string]any{"MessageID": "m1", "RecordType": "Delivery"},
+			same:       true,
+		},
+		{
+			name:       "content hash differs by status",
+			normalizer: &Twilio{},
+			a:          map[string]any{"MessageSid": "SM1", "MessageStatus": "sent"},
+			b:          map[string]any{"MessageSid": "SM1", "MessageStatus": "delivered"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := eventKey(tc.normalizer, tc.a), eventKey(tc.normalizer, tc.b)
+			if (a == b) != tc.same {
+				t.Fatalf("keys %q and %q: same = %v, want %v", a, b, a == b, tc.same)
+			}
+		})
+	}
+}
+
+func TestMemoryDedup(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Unix(1700000000, 0)
+	store := &MemoryDedup{MaxEntries: 2, Now: func() time.Time { return clock }}
+
+	if err := store.Mark(ctx, []string{"a", "b"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if seen, _ := store.Seen(ctx, "a"); !seen {
+		t.Fatal("a not seen after Mark")
+	}
+
+	// A third key evicts the oldest.
+	_ = store.Mark(ctx, []string{"c"}, time.Minute)
+	if seen, _ := store.Seen(ctx, "a"); seen {
+		t.Fatal("a seen after eviction")
+	}
+	if seen, _ := store.Seen(ctx, "c"); !seen {
+		t.Fatal("c not seen after Mark")
+	}
+
+	clock = clock.Add(time.Minute)
+	if seen, _ := store.Seen(ctx, "b"); seen {
+		t.Fatal("b seen after its TTL")
+	}
+}
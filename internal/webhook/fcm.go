@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FCM normalizes push receipts. FCM reports nothing back to the sender, so
+// the mobile SDK posts delivered/opened receipts (singly or batched),
+// signed with the tenant's receipt secret.
+type FCM struct {
+	Verifier Verifier
+}
+
+func (n *FCM) Provider() string { return "fcm" }
+
+func (n *FCM) Verify(r *http.Request, body []byte) error {
+	return verify(n.Verifier, r, body)
+}
+
+func (n *FCM) Decode(_ *http.Request, body []byte) (Callback, error) {
+	return decodeJSON(body)
+}
+
+// fcmStatuses maps receipt events; dismissed notifications are ignored.
+var fcmStatuses = map[string]string{
+	"delivered": StatusDelivered,
+	"opened":    StatusOpened,
+	"clicked":   StatusClicked,
+}
+
+func (n *FCM) Normalize(payload map[string]any, received time.Time) (NormalizedEvent, error) {
+	messageID := stringField(payload, "message_id")
+	if messageID == "" {
+		return NormalizedEvent{}, errors.New("fcm message_id missing")
+	}
+	raw := stringField(payload, "event")
+	if raw == "" {
+		return NormalizedEvent{}, errors.New("fcm event missing")
+	}
+	status, ok := fcmStatuses[strings.ToLower(raw)]
+	if !ok {
+		return NormalizedEvent{}, errIgnoredEvent
+	}
+	event := NormalizedEvent{
+		MessageID: messageID,
+		TenantID:  stringField(payload, "tenant_id"),
+		Provider:  "fcm",
+		Channel:   "push",
+		Status:    status,
+		Recipient: stringField(payload, "token"),
+		Occurred:  received,
+		Meta:      payload,
+	}
+	if t, ok := timestamp(payload["timestamp"]); ok {
+		event.Occurred = t
+	}
+	return event, nil
+}
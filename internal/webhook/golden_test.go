@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+type goldenResult struct {
+	Event   *NormalizedEvent `json:"event,omitempty"`
+	Ignored bool             `json:"ignored,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type goldenOutput struct {
+	DecodeError  string         `json:"decode_error,omitempty"`
+	SNSType      string         `json:"sns_type,omitempty"`
+	SubscribeURL string         `json:"subscribe_url,omitempty"`
+	Results      []goldenResult `json:"results,omitempty"`
+}
+
+// TestNormalizersGolden runs every captured sample under testdata/<provider>
+// through its normalizer and compares the result with the .golden file next
+// to it. A sample's optional .query file holds the callback URL's query.
+// Run with -update to rewrite the golden files.
+func TestNormalizersGolden(t *testing.T) {
+	registry := NewRegistry(&SES{}, &SendGrid{}, &Twilio{}, &Mailgun{}, &Postmark{}, &FCM{}, &WhatsApp{})
+	received := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	for _, provider := range registry.Providers() {
+		normalizer, _ := registry.Lookup(provider)
+		inputs, err := filepath.Glob(filepath.Join("testdata", provider, "*.input"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(inputs) == 0 {
+			t.Errorf("no samples for provider %s", provider)
+		}
+		for _, input := range inputs {
+			name := strings.TrimSuffix(input, ".input")
+			t.Run(provider+"/"+filepath.Base(name), func(t *testing.T) {
+				body, err := os.ReadFile(input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				target := "/v1/providers/" + provider + "/events"
+				if query, err := os.ReadFile(name + ".query"); err == nil {
+					target += "?" + strings.TrimSpace(string(query))
+				}
+				r := httptest.NewRequest("POST", target, bytes.NewReader(body))
+
+				var out goldenOutput
+				cb, err := normalizer.Decode(r, bytes.TrimSpace(body))
+				if err != nil {
+					out.DecodeError = err.Error()
+				}
+				out.SNSType, out.SubscribeURL = cb.SNSType, cb.SubscribeURL
+				for _, payload := range cb.Events {
+					event, err := normalizer.Normalize(payload, received)
+					switch {
+					case errors.Is(err, errIgnoredEvent):
+						out.Results = append(out.Results, goldenResult{Ignored: true})
+					case err != nil:
+						out.Results = append(out.Results, goldenResult{Error: err.Error()})
+					default:
+						out.Results = append(out.Results, goldenResult{Event: &event})
+					}
+				}
+
+				got, err := json.MarshalIndent(out, "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, '\n')
+				golden := name + ".golden"
+				if *update {
+					if err := os.WriteFile(golden, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("read golden file (run with -update to create it): %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("%s mismatch\ngot:\n%s\nwant:\n%s", golden, got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+
+	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/preferences"
+)
+
+// SMS keywords carriers require senders to honour.
+const (
+	KeywordStop  = "STOP"
+	KeywordStart = "START"
+	KeywordHelp  = "HELP"
+)
+
+var smsKeywords = map[string]string{
+	"STOP":        KeywordStop,
+	"STOPALL":     KeywordStop,
+	"UNSUBSCRIBE": KeywordStop,
+	"CANCEL":      KeywordStop,
+	"END":         KeywordStop,
+	"QUIT":        KeywordStop,
+	"START":       KeywordStart,
+	"UNSTOP":      KeywordStart,
+	"YES":         KeywordStart,
+	"HELP":        KeywordHelp,
+	"INFO":        KeywordHelp,
+}
+
+// smsKeyword returns the keyword an SMS body consists of, if any. Only a
+// body that is the keyword alone counts, so "please don't stop" does not
+// opt anyone out.
+func smsKeyword(text string) string {
+	word := strings.ToUpper(strings.Trim(strings.TrimSpace(text), ".!"))
+	return smsKeywords[word]
+}
+
+// InboundMessage is a reply or other message sent to a tenant's number or
+// address, published to inbound.messages.
+type InboundMessage struct {
+	// ID is the provider's message id: the Twilio MessageSid or the email
+	// Message-ID header.
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	Provider string `json:"provider"`
+	Channel  string `json:"channel"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Subject  string `json:"subject,omitempty"`
+	Text     string `json:"text"`
+	HTML     string `json:"html,omitempty"`
+	// InReplyTo is the Message-ID an email replies to.
+	InReplyTo string `json:"in_reply_to,omitempty"`
+	// Keyword is STOP, START or HELP when an SMS consists of one.
+	Keyword  string         `json:"keyword,omitempty"`
+	Received time.Time      `json:"received_at"`
+	Meta     map[string]any `json:"meta,omitempty"`
+}
+
+// InboundParser decodes one provider's inbound message callbacks. The
+// callback URL configured at the provider carries the tenant_id query
+// parameter.
+type InboundParser interface {
+	Provider() string
+	Verify(r *http.Request, body []byte) error
+	ParseInbound(r *http.Request, body []byte, received time.Time) (InboundMessage, error)
+}
+
+func inboundTenant(r *http.Request) (string, error) {
+	tenant := r.URL.Query().Get("tenant_id")
+	if tenant == "" {
+		return "", errors.New("tenant_id query parameter missing")
+	}
+	return tenant, nil
+}
+
+// TwilioInbound parses incoming SMS and MMS webhooks. It shares the status
+// callbacks' signature scheme.
+type TwilioInbound struct {
+	Verifier Verifier
+}
+
+func (p *TwilioInbound) Provider() string { return "twilio" }
+
+func (p *TwilioInbound) Verify(r *http.Request, body []byte) error {
+	return verify(p.Verifier, r, body)
+}
+
+func (p *TwilioInbound) ParseInbound(r *http.Request, body []byte, received time.Time) (InboundMessage, error) {
+	tenant, err := inboundTenant(r)
+	if err != nil {
+		return InboundMessage{}, err
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return InboundMessage{}, fmt.Errorf("decode twilio form: %w", err)
+	}
+	sid := form.Get("MessageSid")
+	if sid == "" {
+		sid = form.Get("SmsSid")
+	}
+	if sid == "" || form.Get("From") == "" {
+		return InboundMessage{}, errors.New("twilio MessageSid or From missing")
+	}
+	msg := InboundMessage{
+		ID:       sid,
+		TenantID: tenant,
+		Provider: "twilio",
+		Channel:  "sms",
+		From:     form.Get("From"),
+		To:       form.Get("To"),
+		Text:     form.Get("Body"),
+		Received: received,
+	}
+	if n, _ := strconv.Atoi(form.Get("NumMedia")); n > 0 {
+		media := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			if u := form.Get("MediaUrl" + strconv.Itoa(i)); u != "" {
+				media = append(media, u)
+			}
+		}
+		msg.Meta = map[string]any{"media_urls": media}
+	}
+	return msg, nil
+}
+
+// Acknowledge answers with empty TwiML so Twilio sends no reply of its own
+// beyond its built-in opt-out handling.
+func (p *TwilioInbound) Acknowledge(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("<Response></Response>"))
+}
+
+// maxInboundFormMemory bounds the parse form held in memory; larger
+// attachments spill to temporary files.
+const maxInboundFormMemory = 8 << 20
+
+// SendGridInbound parses Inbound Parse webhooks: multipart forms carrying
+// the parsed message fields. Attachments are not forwarded; their metadata
+// is kept in Meta.
+type SendGridInbound struct {
+	Verifier Verifier
+}
+
+func (p *SendGridInbound) Provider() string { return "sendgrid" }
+
+func (p *SendGridInbound) Verify(r *http.Request, body []byte) error {
+	return verify(p.Verifier, r, body)
+}
+
+func (p *SendGridInbound) ParseInbound(r *http.Request, body []byte, received time.Time) (InboundMessage, error) {
+	tenant, err := inboundTenant(r)
+	if err != nil {
+		return InboundMessage{}, err
+	}
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return InboundMessage{}, errors.New("sendgrid inbound parse body must be multipart/form-data")
+	}
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(maxInboundFormMemory)
+	if err != nil {
+		return InboundMessage{}, fmt.Errorf("decode sendgrid inbound form: %w", err)
+	}
+	defer form.RemoveAll()
+	field := func(name string) string {
+		if v := form.Value[name]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	from, err := mail.ParseAddress(field("from"))
+	if err != nil {
+		return InboundMessage{}, fmt.Errorf("sendgrid inbound from: %w", err)
+	}
+	msg := InboundMessage{
+		TenantID: tenant,
+		Provider: "sendgrid",
+		Channel:  "email",
+		From:     from.Address,
+		To:       field("to"),
+		Subject:  field("subject"),
+		Text:     field("text"),
+		HTML:     field("html"),
+		Received: received,
+	}
+	if to, err := mail.ParseAddressList(field("to")); err == nil && len(to) > 0 {
+		msg.To = to[0].Address
+	}
+	// The raw header block gives the ids needed to thread the reply.
+	if headers := field("headers"); headers != "" {
+		if m, err := mail.ReadMessage(strings.NewReader(strings.TrimRight(headers, "\r\n") + "\r\n\r\n")); err == nil {
+			msg.ID = strings.Trim(m.Header.Get("Message-Id"), "<>")
+			msg.InReplyTo = strings.Trim(m.Header.Get("In-Reply-To"), "<>")
+		}
+	}
+	if msg.ID == "" {
+		return InboundMessage{}, errors.New("sendgrid inbound Message-ID header missing")
+	}
+
+	meta := map[string]any{}
+	if n, _ := strconv.Atoi(field("attachments")); n > 0 {
+		meta["attachments"] = n
+		var info map[string]any
+		if err := json.Unmarshal([]byte(field("attachment-info")), &info); err == nil {
+			meta["attachment_info"] = info
+		}
+	}
+	if spf := field("SPF"); spf != "" {
+		meta["spf"] = spf
+	}
+	if len(meta) > 0 {
+		msg.Meta = meta
+	}
+	return msg, nil
+}
+
+// maxInboundBytes bounds inbound bodies; emails carry their attachments.
+const maxInboundBytes = 30 << 20
+
+var inboundCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "webhook_inbound_messages_total",
+	Help: "Inbound messages received, by provider and keyword",
+}, []string{"provider", "keyword"})
+
+func (s *Server) inbound(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("webhook").Start(r.Context(), "ingest-inbound")
+	defer span.End()
+
+	provider := chi.URLParam(r, "provider")
+	parser, ok := s.Inbound[provider]
+	if !ok {
+		s.respondErr(ctx, w, http.StatusNotFound, fmt.Errorf("unsupported inbound provider %q", provider))
+		return
+	}
+	received := time.Now().UTC()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInboundBytes))
+	if err != nil {
+		s.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+	if err := parser.Verify(r, body); err != nil {
+		s.respondErr(ctx, w, http.StatusUnauthorized, err)
+		return
+	}
+	msg, err := parser.ParseInbound(r, body, received)
+	if err != nil {
+		s.respondErr(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+
+	key := provider + ":inbound:" + msg.ID
+	if s.Dedup != nil {
+		seen, err := s.Dedup.Seen(ctx, key)
+		if err != nil {
+			s.respondErr(ctx, w, http.StatusInternalServerError, err)
+			return
+		}
+		if seen {
+			duplicateEvents.WithLabelValues(provider).Inc()
+			acknowledge(parser, w)
+			return
+		}
+	}
+
+	if msg.Channel == "sms" {
+		msg.Keyword = smsKeyword(msg.Text)
+	}
+	if err := s.applyKeyword(ctx, msg); err != nil {
+		s.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	value, err := json.Marshal(msg)
+	if err != nil {
+		s.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	// Keyed by sender so a conversation stays ordered.
+	if err := s.InboundProducer.WriteMessages(ctx, kafka.Message{Key: []byte(msg.TenantID + ":" + msg.From), Value: value}); err != nil {
+		s.respondErr(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+	if s.Dedup != nil {
+		ttl := s.DedupTTL
+		if ttl <= 0 {
+			ttl = DefaultDedupTTL
+		}
+		if err := s.Dedup.Mark(ctx, []string{key}, ttl); err != nil {
+			logger := common.WithContext(ctx, s.Logger)
+			logger.Warn().Err(err).Msg("failed to record inbound message")
+		}
+	}
+	inboundCounter.WithLabelValues(provider, strings.ToLower(msg.Keyword)).Inc()
+	acknowledge(parser, w)
+}
+
+// applyKeyword records STOP and START as an SMS opt-out or opt-in of the
+// sender across every category. HELP is left to the tenant.
+func (s *Server) applyKeyword(ctx context.Context, msg InboundMessage) error {
+	if s.Preferences == nil || (msg.Keyword != KeywordStop && msg.Keyword != KeywordStart) {
+		return nil
+	}
+	_, err := s.Preferences.Upsert(ctx, preferences.Preference{
+		TenantID:  msg.TenantID,
+		Recipient: msg.From,
+		Category:  preferences.Any,
+		Channel:   msg.Channel,
+		OptedOut:  msg.Keyword == KeywordStop,
+		Source:    "sms_keyword",
+	})
+	if err != nil {
+		return fmt.Errorf("apply %s keyword: %w", msg.Keyword, err)
+	}
+	s.Logger.Info().Str("tenant_id", msg.TenantID).Str("keyword", msg.Keyword).Msg("applied sms keyword")
+	return nil
+}
+
+// acknowledge answers a processed inbound callback, in the provider's own
+// format when it expects one.
+func acknowledge(parser InboundParser, w http.ResponseWriter) {
+	if a, ok := parser.(interface{ Acknowledge(http.ResponseWriter) }); ok {
+		a.Acknowledge(w)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMSKeyword(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"STOP", KeywordStop},
+		{"  stop\n", KeywordStop},
+		{"Unsubscribe.", KeywordStop},
+		{"quit!", KeywordStop},
+		{"Start", KeywordStart},
+		{"unstop", KeywordStart},
+		{"help", KeywordHelp},
+		{"INFO", KeywordHelp},
+		{"please don't stop", ""},
+		{"stop it", ""},
+		{"", ""},
+	}
+	for _, tc := range cases {
+		if got := smsKeyword(tc.text); got != tc.want {
+			t.Errorf("smsKeyword(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestTwilioInbound(t *testing.T) {
+	received := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	body := "MessageSid=SM123&From=%2B15551230000&To=%2B15559870000&Body=STOP&NumMedia=1&MediaUrl0=https%3A%2F%2Fapi.twilio.com%2Fmedia%2F1"
+	cases := []struct {
+		name    string
+		target  string
+		body    string
+		wantErr bool
+	}{
+		{name: "sms", target: "/v1/providers/twilio/inbound?tenant_id=t1", body: body},
+		{name: "no tenant", target: "/v1/providers/twilio/inbound", body: body, wantErr: true},
+		{name: "no sid", target: "/v1/providers/twilio/inbound?tenant_id=t1", body: "From=%2B15551230000&Body=hi", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tc.target, strings.NewReader(tc.body))
+			msg, err := (&TwilioInbound{}).ParseInbound(r, []byte(tc.body), received)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ParseInbound = %+v, want error", msg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg.ID != "SM123" || msg.TenantID != "t1" || msg.From != "+15551230000" || msg.To != "+15559870000" || msg.Text != "STOP" || msg.Channel != "sms" {
+				t.Fatalf("ParseInbound = %+v", msg)
+			}
+			if media, _ := msg.Meta["media_urls"].([]string); len(media) != 1 {
+				t.Fatalf("media = %v", msg.Meta)
+			}
+		})
+	}
+}
+
+func TestSendGridInbound(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for key, value := range map[string]string{
+		"from":        "Ana Silva <ana@example.com>",
+		"to":          "Support <support@tenant.example>",
+		"subject":     "Re: Your order",
+		"text":        "Thanks, that worked.",
+		"headers":     "Message-ID: <reply-1@mail.example.com>\nIn-Reply-To: <msg-42@hsnp.example>\nSubject: Re: Your order\n",
+		"attachments": "1",
+		"SPF":         "pass",
+	} {
+		if err := form.WriteField(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	part, err := form.CreateFormFile("attachment1", "receipt.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write([]byte("%PDF-1.4"))
+	_ = form.Close()
+
+	r := httptest.NewRequest("POST", "/v1/providers/sendgrid/inbound?tenant_id=t1", bytes.NewReader(body.Bytes()))
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	msg, err := (&SendGridInbound{}).ParseInbound(r, body.Bytes(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.ID != "reply-1@mail.example.com" || msg.InReplyTo != "msg-42@hsnp.example" {
+		t.Fatalf("ids = %q, %q", msg.ID, msg.InReplyTo)
+	}
+	if msg.From != "ana@example.com" || msg.To != "support@tenant.example" || msg.Subject != "Re: Your order" || msg.Channel != "email" {
+		t.Fatalf("ParseInbound = %+v", msg)
+	}
+	if msg.Meta["attachments"] != 1 || msg.Meta["spf"] != "pass" {
+		t.Fatalf("meta = %v", msg.Meta)
+	}
+
+	r = httptest.NewRequest("POST", "/v1/providers/sendgrid/inbound?tenant_id=t1", strings.NewReader("{}"))
+	r.Header.Set("Content-Type", "application/json")
+	if _, err := (&SendGridInbound{}).ParseInbound(r, []byte("{}"), time.Now()); err == nil {
+		t.Fatal("ParseInbound accepted a JSON body")
+	}
+}
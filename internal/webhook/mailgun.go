@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Mailgun normalizes Mailgun webhooks, which post one event per request
+// with its signature in the body.
+type Mailgun struct {
+	Verifier Verifier
+}
+
+func (n *Mailgun) Provider() string { return "mailgun" }
+
+func (n *Mailgun) Verify(r *http.Request, body []byte) error {
+	return verify(n.Verifier, r, body)
+}
+
+type mailgunBody struct {
+	Signature struct {
+		Timestamp string `json:"timestamp"`
+		Token     string `json:"token"`
+		Signature string `json:"signature"`
+	} `json:"signature"`
+	EventData map[string]any `json:"event-data"`
+}
+
+func (n *Mailgun) Decode(_ *http.Request, body []byte) (Callback, error) {
+	var b mailgunBody
+	if err := json.Unmarshal(body, &b); err != nil {
+		return Callback{}, fmt.Errorf("decode mailgun event: %w", err)
+	}
+	if b.EventData == nil {
+		return Callback{}, errors.New("mailgun event-data missing")
+	}
+	return Callback{Events: []map[string]any{b.EventData}}, nil
+}
+
+var mailgunStatuses = map[string]string{
+	"accepted":   StatusSent,
+	"delivered":  StatusDelivered,
+	"opened":     StatusOpened,
+	"clicked":    StatusClicked,
+	"complained": StatusComplained,
+	"rejected":   StatusFailed,
+}
+
+func (n *Mailgun) Normalize(payload map[string]any, received time.Time) (NormalizedEvent, error) {
+	variables, _ := payload["user-variables"].(map[string]any)
+	message, _ := payload["message"].(map[string]any)
+	headers, _ := message["headers"].(map[string]any)
+	messageID := stringField(variables, "message_id")
+	if messageID == "" {
+		messageID = stringField(headers, "message-id")
+	}
+	if messageID == "" {
+		return NormalizedEvent{}, errors.New("mailgun message id missing")
+	}
+	raw, _ := payload["event"].(string)
+	if raw == "" {
+		return NormalizedEvent{}, errors.New("mailgun event missing")
+	}
+
+	event := NormalizedEvent{
+		MessageID: messageID,
+		TenantID:  stringField(variables, "tenant_id"),
+		Provider:  "mailgun",
+		Channel:   "email",
+		Recipient: stringField(payload, "recipient"),
+		Occurred:  received,
+		Meta:      payload,
+	}
+	if t, ok := timestamp(payload["timestamp"]); ok {
+		event.Occurred = t
+	}
+	if raw == "failed" {
+		// Temporary failures are retried by Mailgun; permanent ones bounce.
+		if severity, _ := payload["severity"].(string); severity == "temporary" {
+			event.Status, event.BounceType = StatusDeferred, "soft"
+		} else {
+			event.Status, event.BounceType = StatusBounced, "hard"
+		}
+		event.BounceSubType = stringField(payload, "reason")
+		status, _ := payload["delivery-status"].(map[string]any)
+		if code, ok := status["code"].(float64); ok {
+			event.ReasonCode = strconv.Itoa(int(code))
+		}
+		event.Reason = stringField(status, "description", "message")
+		return event, nil
+	}
+	status, ok := mailgunStatuses[raw]
+	if !ok {
+		return NormalizedEvent{}, errIgnoredEvent
+	}
+	event.Status = status
+	return event, nil
+}
+
+// MailgunVerifier checks the body signature: a hex HMAC-SHA256 of timestamp
+// and token keyed by the webhook signing key, with a fresh timestamp.
+type MailgunVerifier struct {
+	SigningKey string
+	MaxSkew    time.Duration
+	Now        func() time.Time
+}
+
+func (v *MailgunVerifier) Verify(_ *http.Request, body []byte) error {
+	var b mailgunBody
+	if err := json.Unmarshal(body, &b); err != nil || b.Signature.Signature == "" {
+		return fmt.Errorf("%w: missing mailgun signature", ErrUnauthenticated)
+	}
+	seconds, err := strconv.ParseInt(b.Signature.Timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid mailgun timestamp", ErrUnauthenticated)
+	}
+	maxSkew := v.MaxSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultSendGridMaxSkew
+	}
+	if skew := now(v.Now).Sub(time.Unix(seconds, 0)); skew > maxSkew || skew < -maxSkew {
+		return fmt.Errorf("%w: mailgun timestamp outside %s", ErrUnauthenticated, maxSkew)
+	}
+	mac := hmac.New(sha256.New, []byte(v.SigningKey))
+	mac.Write([]byte(b.Signature.Timestamp + b.Signature.Token))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(b.Signature.Signature)) {
+		return fmt.Errorf("%w: mailgun signature mismatch", ErrUnauthenticated)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Normalizer handles one provider's callbacks: it authenticates the raw
+// request, splits it into event payloads and maps each onto a
+// NormalizedEvent.
+type Normalizer interface {
+	// Provider is the {provider} path segment the normalizer serves.
+	Provider() string
+	// Verify authenticates the request; normalizers built without a
+	// verifier reject every request.
+	Verify(r *http.Request, body []byte) error
+	Decode(r *http.Request, body []byte) (Callback, error)
+	// Normalize maps one payload. received stands in for the event time
+	// when the payload carries none. It returns errIgnoredEvent for events
+	// with no canonical status.
+	Normalize(payload map[string]any, received time.Time) (NormalizedEvent, error)
+}
+
+// Callback is one decoded provider request: a batch of raw event payloads,
+// or an SNS subscription control message carrying none.
+type Callback struct {
+	Events []map[string]any
+	// SNSType is the SNS message type when the body was an SNS envelope.
+	SNSType string
+	// SubscribeURL is set for SNS subscription confirmations.
+	SubscribeURL string
+	// TopicARN is the SNS topic the envelope names.
+	TopicARN string
+}
+
+// Registry maps provider names to normalizers.
+type Registry struct {
+	mu          sync.RWMutex
+	normalizers map[string]Normalizer
+}
+
+func NewRegistry(normalizers ...Normalizer) *Registry {
+	r := &Registry{normalizers: map[string]Normalizer{}}
+	for _, n := range normalizers {
+		r.Register(n)
+	}
+	return r
+}
+
+// Register adds n, panicking if its provider is already registered.
+func (r *Registry) Register(n Normalizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.normalizers[n.Provider()]; ok {
+		panic(fmt.Sprintf("webhook: normalizer for %q registered twice", n.Provider()))
+	}
+	r.normalizers[n.Provider()] = n
+}
+
+func (r *Registry) Lookup(provider string) (Normalizer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n, ok := r.normalizers[provider]
+	return n, ok
+}
+
+func (r *Registry) Providers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	providers := make([]string, 0, len(r.normalizers))
+	for p := range r.normalizers {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// verify runs v, failing closed when the normalizer was given none.
+func verify(v Verifier, r *http.Request, body []byte) error {
+	if v == nil {
+		return fmt.Errorf("%w: no credentials configured", ErrUnauthenticated)
+	}
+	return v.Verify(r, body)
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Postmark normalizes Postmark webhooks, one record per request. Postmark
+// authenticates with basic auth credentials in the webhook URL.
+type Postmark struct {
+	Verifier Verifier
+}
+
+func (n *Postmark) Provider() string { return "postmark" }
+
+func (n *Postmark) Verify(r *http.Request, body []byte) error {
+	return verify(n.Verifier, r, body)
+}
+
+func (n *Postmark) Decode(_ *http.Request, body []byte) (Callback, error) {
+	return decodeJSON(body)
+}
+
+// postmarkRecords maps RecordType to a status and the field holding the
+// event time.
+var postmarkRecords = map[string][2]string{
+	"Delivery":      {StatusDelivered, "DeliveredAt"},
+	"Bounce":        {StatusBounced, "BouncedAt"},
+	"SpamComplaint": {StatusComplained, "BouncedAt"},
+	"Open":          {StatusOpened, "ReceivedAt"},
+	"Click":         {StatusClicked, "ReceivedAt"},
+}
+
+// postmarkSoftBounces lists bounce types Postmark retries or that may
+// clear up; everything else is a hard bounce.
+var postmarkSoftBounces = map[string]bool{
+	"Transient": true, "SoftBounce": true, "DnsError": true, "AutoResponder": true, "MailboxFull": true,
+}
+
+func (n *Postmark) Normalize(payload map[string]any, received time.Time) (NormalizedEvent, error) {
+	metadata, _ := payload["Metadata"].(map[string]any)
+	messageID := stringField(metadata, "message_id")
+	if messageID == "" {
+		messageID = stringField(payload, "MessageID")
+	}
+	if messageID == "" {
+		return NormalizedEvent{}, errors.New("postmark MessageID missing")
+	}
+	recordType, _ := payload["RecordType"].(string)
+	if recordType == "" {
+		return NormalizedEvent{}, errors.New("postmark RecordType missing")
+	}
+	record, ok := postmarkRecords[recordType]
+	if !ok {
+		return NormalizedEvent{}, errIgnoredEvent
+	}
+
+	event := NormalizedEvent{
+		MessageID: messageID,
+		TenantID:  stringField(metadata, "tenant_id"),
+		Provider:  "postmark",
+		Channel:   "email",
+		Status:    record[0],
+		Recipient: stringField(payload, "Recipient", "Email"),
+		Occurred:  received,
+		Meta:      payload,
+	}
+	if t, ok := timestamp(payload[record[1]]); ok {
+		event.Occurred = t
+	}
+	if recordType == "Bounce" {
+		bounceType, _ := payload["Type"].(string)
+		event.BounceType = "hard"
+		if postmarkSoftBounces[bounceType] {
+			event.BounceType = "soft"
+		}
+		event.BounceSubType = bounceType
+		if code, ok := payload["TypeCode"].(float64); ok {
+			event.ReasonCode = strconv.Itoa(int(code))
+		}
+		event.Reason = stringField(payload, "Description")
+	}
+	return event, nil
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+)
+
+const defaultReprocessBatchSize = 500
+
+// Reprocessor re-runs the current normalizers over archived callbacks and
+// republishes the resulting events. Callbacks are not re-verified: they were
+// authenticated on receipt and provider signatures expire. SNS subscription
+// messages are skipped rather than confirmed again.
+type Reprocessor struct {
+	Archive     Archive
+	Normalizers *Registry
+	// Producer is nil for a dry run, which counts events without publishing.
+	Producer  *kafka.Writer
+	BatchSize int
+	Logger    zerolog.Logger
+}
+
+type ReprocessResult struct {
+	Callbacks int `json:"callbacks"`
+	Published int `json:"published"`
+	Ignored   int `json:"ignored"`
+	Rejected  int `json:"rejected"`
+}
+
+// Run reprocesses callbacks from provider (all providers when empty)
+// received in [from, to).
+func (p *Reprocessor) Run(ctx context.Context, provider string, from, to time.Time) (ReprocessResult, error) {
+	batchSize := p.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultReprocessBatchSize
+	}
+	var (
+		result    ReprocessResult
+		afterTime time.Time
+		afterID   string
+	)
+	for {
+		callbacks, err := p.Archive.List(ctx, provider, from, to, afterTime, afterID, batchSize)
+		if err != nil {
+			return result, err
+		}
+		var msgs []kafka.Message
+		for _, raw := range callbacks {
+			batch, err := p.reprocess(ctx, raw, &result)
+			if err != nil {
+				return result, err
+			}
+			msgs = append(msgs, batch...)
+		}
+		if len(msgs) > 0 && p.Producer != nil {
+			if err := p.Producer.WriteMessages(ctx, msgs...); err != nil {
+				return result, fmt.Errorf("publish reprocessed events: %w", err)
+			}
+		}
+		result.Published += len(msgs)
+		if len(callbacks) < batchSize {
+			return result, nil
+		}
+		last := callbacks[len(callbacks)-1]
+		afterTime, afterID = last.ReceivedAt, last.ID
+	}
+}
+
+func (p *Reprocessor) reprocess(ctx context.Context, raw RawCallback, result *ReprocessResult) ([]kafka.Message, error) {
+	result.Callbacks++
+	normalizer, ok := p.Normalizers.Lookup(raw.Provider)
+	if !ok {
+		p.Logger.Warn().Str("callback_id", raw.ID).Str("provider", raw.Provider).Msg("no normalizer for archived callback")
+		return nil, nil
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/v1/providers/"+raw.Provider+"/events?"+raw.Query, bytes.NewReader(raw.Body))
+	if err != nil {
+		return nil, fmt.Errorf("rebuild request %s: %w", raw.ID, err)
+	}
+	r.Header = raw.Headers.Clone()
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+
+	cb, err := normalizer.Decode(r, raw.Body)
+	if err != nil {
+		p.Logger.Warn().Err(err).Str("callback_id", raw.ID).Msg("archived callback no longer decodes")
+		result.Rejected++
+		return nil, nil
+	}
+	var msgs []kafka.Message
+	for i, payload := range cb.Events {
+		event, err := normalizer.Normalize(payload, raw.ReceivedAt)
+		if errors.Is(err, errIgnoredEvent) {
+			result.Ignored++
+			continue
+		}
+		if err != nil {
+			p.Logger.Warn().Err(err).Str("callback_id", raw.ID).Int("index", i).Msg("archived event rejected")
+			result.Rejected++
+			continue
+		}
+		// The same id as the original publish lets consumers skip events
+		// they already handled.
+		event.EventID = eventKey(normalizer, payload)
+		value, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("marshal event: %w", err)
+		}
+		msgs = append(msgs, kafka.Message{Key: []byte(event.MessageID), Value: value})
+	}
+	return msgs, nil
+}
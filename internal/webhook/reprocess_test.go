@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type memoryArchive struct {
+	callbacks []RawCallback
+}
+
+func (a *memoryArchive) Store(_ context.Context, cb RawCallback) error {
+	a.callbacks = append(a.callbacks, cb)
+	return nil
+}
+
+func (a *memoryArchive) List(_ context.Context, provider string, from, to time.Time, afterTime time.Time, afterID string, limit int) ([]RawCallback, error) {
+	sort.Slice(a.callbacks, func(i, j int) bool {
+		ci, cj := a.callbacks[i], a.callbacks[j]
+		if !ci.ReceivedAt.Equal(cj.ReceivedAt) {
+			return ci.ReceivedAt.Before(cj.ReceivedAt)
+		}
+		return ci.ID < cj.ID
+	})
+	var out []RawCallback
+	for _, cb := range a.callbacks {
+		if provider != "" && cb.Provider != provider || cb.ReceivedAt.Before(from) || !cb.ReceivedAt.Before(to) {
+			continue
+		}
+		if cb.ReceivedAt.Before(afterTime) || cb.ReceivedAt.Equal(afterTime) && cb.ID <= afterID {
+			continue
+		}
+		if len(out) == limit {
+			break
+		}
+		out = append(out, cb)
+	}
+	return out, nil
+}
+
+func TestRawCallbackDropsCredentials(t *testing.T) {
+	r := httptest.NewRequest("POST", "/v1/providers/postmark/events?a=1", nil)
+	r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	r.Header.Set("X-Twilio-Signature", "sig")
+	cb := rawCallback("postmark", r, []byte("{}"), time.Now())
+	if cb.Headers.Get("Authorization") != "" {
+		t.Fatal("Authorization header archived")
+	}
+	if cb.Headers.Get("X-Twilio-Signature") != "sig" || cb.Query != "a=1" {
+		t.Fatalf("archived %v %q", cb.Headers, cb.Query)
+	}
+}
+
+// TestReprocessorDryRun replays the golden samples from an archive and checks
+// the counts against their golden outputs.
+func TestReprocessorDryRun(t *testing.T) {
+	archive := &memoryArchive{}
+	received := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	var want ReprocessResult
+
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*", "*.input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, input := range inputs {
+		name := strings.TrimSuffix(input, ".input")
+		body, err := os.ReadFile(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var query []byte
+		if q, err := os.ReadFile(name + ".query"); err == nil {
+			query = q
+		}
+		archive.callbacks = append(archive.callbacks, RawCallback{
+			ID:         name,
+			Provider:   filepath.Base(filepath.Dir(input)),
+			Headers:    http.Header{},
+			Query:      strings.TrimSpace(string(query)),
+			Body:       bytes.TrimSpace(body),
+			ReceivedAt: received.Add(time.Duration(i%3) * time.Minute),
+		})
+
+		golden, err := os.ReadFile(name + ".golden")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out goldenOutput
+		if err := json.Unmarshal(golden, &out); err != nil {
+			t.Fatal(err)
+		}
+		want.Callbacks++
+		if out.DecodeError != "" {
+			want.Rejected++
+		}
+		for _, res := range out.Results {
+			switch {
+			case res.Event != nil:
+				want.Published++
+			case res.Ignored:
+				want.Ignored++
+			default:
+				want.Rejected++
+			}
+		}
+	}
+	archive.callbacks = append(archive.callbacks, RawCallback{ID: "late", Provider: "sendgrid", Body: []byte(`{}`), ReceivedAt: received.Add(time.Hour)})
+
+	p := &Reprocessor{
+		Archive:     archive,
+		Normalizers: NewRegistry(&SES{}, &SendGrid{}, &Twilio{}, &Mailgun{}, &Postmark{}, &FCM{}, &WhatsApp{}),
+		BatchSize:   2,
+		Logger:      zerolog.Nop(),
+	}
+	got, err := p.Run(context.Background(), "", received, received.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("result = %+v, want %+v", got, want)
+	}
+}
+
+func TestReprocessKeepsEventIDs(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "sendgrid", "batch.input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := RawCallback{ID: "cb1", Provider: "sendgrid", Body: bytes.TrimSpace(body), ReceivedAt: time.Now()}
+	p := &Reprocessor{Normalizers: NewRegistry(&SendGrid{}), Logger: zerolog.Nop()}
+
+	var ids [2][]string
+	for run := range ids {
+		msgs, err := p.reprocess(context.Background(), raw, &ReprocessResult{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range msgs {
+			var event NormalizedEvent
+			if err := json.Unmarshal(m.Value, &event); err != nil {
+				t.Fatal(err)
+			}
+			ids[run] = append(ids[run], event.EventID)
+		}
+	}
+	if len(ids[0]) == 0 || ids[0][0] != "sendgrid:cHJvY2Vzc2VkLTM4NjY0MjI2LXNPRnRpN3pTU3V5X0xfSkRwdGRZY3ctMA" {
+		t.Fatalf("unexpected event ids %v", ids[0])
+	}
+	for i := range ids[0] {
+		if ids[0][i] != ids[1][i] {
+			t.Fatalf("event ids changed between runs: %v vs %v", ids[0], ids[1])
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// SendGrid normalizes SendGrid Event Webhook batches. Events carry the
+// custom_args of the send as top-level fields.
+type SendGrid struct {
+	Verifier Verifier
+}
+
+func (n *SendGrid) Provider() string { return "sendgrid" }
+
+func (n *SendGrid) Verify(r *http.Request, body []byte) error {
+	return verify(n.Verifier, r, body)
+}
+
+func (n *SendGrid) Decode(_ *http.Request, body []byte) (Callback, error) {
+	return decodeJSON(body)
+}
+
+func (n *SendGrid) Normalize(payload map[string]any, received time.Time) (NormalizedEvent, error) {
+	// message_id and tenant_id are the custom_args the email worker sends;
+	// sg_message_id is SendGrid's own id and stays in Meta.
+	messageID := stringField(payload, "message_id")
+	if messageID == "" {
+		return NormalizedEvent{}, errors.New("sendgrid message_id custom arg missing")
+	}
+	status, _ := payload["event"].(string)
+	if status == "" {
+		return NormalizedEvent{}, errors.New("sendgrid event missing")
+	}
+	canonical, err := canonicalStatus(status)
+	if err != nil {
+		return NormalizedEvent{}, err
+	}
+	tenant, _ := payload["tenant_id"].(string)
+	recipient, _ := payload["email"].(string)
+	subType, _ := payload["bounce_classification"].(string)
+	reasonCode, _ := payload["status"].(string)
+	reason, _ := payload["reason"].(string)
+	if reason == "" {
+		reason, _ = payload["response"].(string)
+	}
+	occurred, ok := timestamp(payload["timestamp"])
+	if !ok {
+		occurred = received
+	}
+	return NormalizedEvent{
+		MessageID:     messageID,
+		TenantID:      tenant,
+		Provider:      "sendgrid",
+		Channel:       "email",
+		Status:        canonical,
+		Recipient:     recipient,
+		BounceType:    sendGridBounceType(payload),
+		BounceSubType: subType,
+		ReasonCode:    reasonCode,
+		Reason:        reason,
+		Occurred:      occurred,
+		Meta:          payload,
+	}, nil
+}
+
+// sendGridBounceType distinguishes hard bounces from "blocked" bounces,
+// which SendGrid reports for temporary rejections.
+func sendGridBounceType(payload map[string]any) string {
+	if event, _ := payload["event"].(string); event != "bounce" {
+		return ""
+	}
+	if t, _ := payload["type"].(string); t == "blocked" {
+		return "soft"
+	}
+	return "hard"
+}
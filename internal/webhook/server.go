@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -16,13 +18,43 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/example/notification-service/internal/common"
+	"github.com/example/notification-service/internal/preferences"
+	"github.com/example/notification-service/internal/suppression"
 )
 
 type Server struct {
 	Producer *kafka.Writer
-	Logger   zerolog.Logger
+	// Suppressions is optional; when set bounces and complaints are added to
+	// the tenant's suppression list.
+	Suppressions *suppression.Recorder
+	// Normalizers holds the supported providers.
+	Normalizers *Registry
+	// Dedup is optional; when set, events already published within DedupTTL
+	// (default DefaultDedupTTL) are dropped.
+	Dedup    DedupStore
+	DedupTTL time.Duration
+	// Archive is optional; when set every authenticated callback is stored
+	// raw before it is decoded, for reprocessing.
+	Archive Archive
+	// Inbound holds the inbound message parsers by provider; messages are
+	// published with InboundProducer.
+	Inbound         map[string]InboundParser
+	InboundProducer *kafka.Writer
+	// Preferences is optional; when set, SMS STOP and START keywords opt the
+	// sender out of or back into the tenant's SMS.
+	Preferences preferences.Repository
+	// AllowedTopicARNs are the SNS topics whose subscription confirmations
+	// are followed; confirmations for any other topic are logged and refused.
+	AllowedTopicARNs []string
+	// ConfirmSubscription visits an SNS SubscribeURL; it defaults to an
+	// HTTPS GET restricted to SNS hosts.
+	ConfirmSubscription func(ctx context.Context, subscribeURL string) error
+	Logger              zerolog.Logger
 }
 
+// maxBodyBytes bounds webhook bodies read into memory for verification.
+const maxBodyBytes = 1 << 20
+
 var (
 	eventCounter = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "webhook_events_total",
@@ -33,6 +65,7 @@ var (
 func (s *Server) Router() http.Handler {
 	r := chi.NewRouter()
 	r.Post("/v1/providers/{provider}/events", s.handle)
+	r.Post("/v1/providers/{provider}/inbound", s.inbound)
 	return r
 }
 
@@ -41,100 +74,184 @@ func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
 	defer span.End()
 
 	provider := chi.URLParam(r, "provider")
-	if provider == "" {
-		s.respondErr(ctx, w, http.StatusBadRequest, errors.New("provider path param required"))
+	normalizer, ok := s.Normalizers.Lookup(provider)
+	if !ok {
+		s.respondErr(ctx, w, http.StatusNotFound, fmt.Errorf("unsupported provider %q", provider))
 		return
 	}
+	received := time.Now().UTC()
 
-	var payload map[string]any
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
 		s.respondErr(ctx, w, http.StatusBadRequest, err)
 		return
 	}
+	if err := normalizer.Verify(r, body); err != nil {
+		s.respondErr(ctx, w, http.StatusUnauthorized, err)
+		return
+	}
+	if s.Archive != nil {
+		if err := s.Archive.Store(ctx, rawCallback(provider, r, body, received)); err != nil {
+			s.respondErr(ctx, w, http.StatusInternalServerError, err)
+			return
+		}
+	}
 
-	event, err := s.normalize(provider, payload)
+	cb, err := normalizer.Decode(r, body)
 	if err != nil {
 		s.respondErr(ctx, w, http.StatusBadRequest, err)
 		return
 	}
-	span.SetAttributes(attribute.String("message.id", event.MessageID))
-
-	body, err := json.Marshal(event)
-	if err != nil {
-		s.respondErr(ctx, w, http.StatusInternalServerError, err)
+	if cb.SubscribeURL != "" {
+		if !topicAllowed(s.AllowedTopicARNs, cb.TopicARN) {
+			s.respondErr(ctx, w, http.StatusForbidden, fmt.Errorf("refused SNS subscription to topic %q", cb.TopicARN))
+			return
+		}
+		confirm := s.ConfirmSubscription
+		if confirm == nil {
+			confirm = confirmSubscription
+		}
+		if err := confirm(ctx, cb.SubscribeURL); err != nil {
+			s.respondErr(ctx, w, http.StatusBadGateway, err)
+			return
+		}
+		s.Logger.Info().Str("provider", provider).Str("topic_arn", cb.TopicARN).Msg("confirmed SNS subscription")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if cb.SNSType == "UnsubscribeConfirmation" {
+		s.Logger.Warn().Str("provider", provider).Msg("SNS subscription removed")
+		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	if err := s.Producer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(event.MessageID),
-		Value: body,
-	}); err != nil {
-		s.respondErr(ctx, w, http.StatusInternalServerError, err)
+	// Events are normalized one by one so a malformed event does not make
+	// the provider retry the whole batch; it is reported and skipped.
+	var (
+		msgs       []kafka.Message
+		events     []NormalizedEvent
+		rejected   []rejectedEvent
+		keys       []string
+		duplicates int
+	)
+	batchKeys := map[string]bool{}
+	for i, payload := range cb.Events {
+		key := eventKey(normalizer, payload)
+		if s.Dedup != nil {
+			seen, err := s.Dedup.Seen(ctx, key)
+			if err != nil {
+				s.respondErr(ctx, w, http.StatusInternalServerError, err)
+				return
+			}
+			if seen || batchKeys[key] {
+				duplicates++
+				duplicateEvents.WithLabelValues(provider).Inc()
+				continue
+			}
+			batchKeys[key] = true
+		}
+		event, err := normalizer.Normalize(payload, received)
+		if errors.Is(err, errIgnoredEvent) {
+			eventCounter.WithLabelValues(provider, "ignored").Inc()
+			continue
+		}
+		if err != nil {
+			rejected = append(rejected, rejectedEvent{Index: i, Error: err.Error()})
+			eventCounter.WithLabelValues(provider, "rejected").Inc()
+			continue
+		}
+		event.EventID = key
+		value, err := json.Marshal(event)
+		if err != nil {
+			s.respondErr(ctx, w, http.StatusInternalServerError, err)
+			return
+		}
+		msgs = append(msgs, kafka.Message{Key: []byte(event.MessageID), Value: value})
+		events = append(events, event)
+		keys = append(keys, key)
+	}
+	span.SetAttributes(attribute.Int("webhook.events", len(cb.Events)), attribute.Int("webhook.rejected", len(rejected)))
+
+	if len(msgs) == 0 && len(rejected) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(batchResult{Rejected: rejected})
 		return
 	}
+	if len(msgs) > 0 {
+		if err := s.Producer.WriteMessages(ctx, msgs...); err != nil {
+			s.respondErr(ctx, w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	// Suppressions follow the published events only, so a failed publish
+	// retried by the provider does not count a bounce twice. A failure here
+	// leaves the keys unmarked for the same reason: the retry republishes
+	// with the same event ids, and the recorder skips ids it already counted.
+	if s.Suppressions != nil {
+		for _, event := range events {
+			if err := s.Suppressions.Record(ctx, event.TenantID, event.Recipient, event.EventID, event.Status, event.BounceType); err != nil {
+				s.respondErr(ctx, w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+	}
+	// Keys are only remembered once published, so a retry after a failed
+	// publish goes through.
+	if s.Dedup != nil && len(keys) > 0 {
+		ttl := s.DedupTTL
+		if ttl <= 0 {
+			ttl = DefaultDedupTTL
+		}
+		if err := s.Dedup.Mark(ctx, keys, ttl); err != nil {
+			logger := common.WithContext(ctx, s.Logger)
+			logger.Warn().Err(err).Msg("failed to record published webhook events")
+		}
+	}
 
-	eventCounter.WithLabelValues(provider, "ok").Inc()
+	eventCounter.WithLabelValues(provider, "ok").Add(float64(len(msgs)))
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(batchResult{Accepted: len(msgs), Duplicates: duplicates, Rejected: rejected})
 }
 
-type NormalizedEvent struct {
-	MessageID string                 `json:"message_id"`
-	TenantID  string                 `json:"tenant_id"`
-	Provider  string                 `json:"provider"`
-	Status    string                 `json:"status"`
-	Occurred  time.Time              `json:"occurred_at"`
-	Meta      map[string]interface{} `json:"meta"`
+// batchResult reports which events of a callback were published. Indexes
+// refer to positions in the posted batch.
+type batchResult struct {
+	Accepted   int             `json:"accepted"`
+	Duplicates int             `json:"duplicates,omitempty"`
+	Rejected   []rejectedEvent `json:"rejected,omitempty"`
 }
 
-func (s *Server) normalize(provider string, payload map[string]any) (NormalizedEvent, error) {
-	switch provider {
-	case "ses":
-		return s.normalizeSES(payload)
-	case "sendgrid":
-		return s.normalizeSendGrid(payload)
-	default:
-		return NormalizedEvent{}, errors.New("unsupported provider")
-	}
+type rejectedEvent struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
 }
 
-func (s *Server) normalizeSES(payload map[string]any) (NormalizedEvent, error) {
-	messageID, _ := payload["message_id"].(string)
-	if messageID == "" {
-		return NormalizedEvent{}, errors.New("ses message_id missing")
-	}
-	status, _ := payload["event"].(string)
-	if status == "" {
-		return NormalizedEvent{}, errors.New("ses event missing")
-	}
-	tenant, _ := payload["tenant_id"].(string)
-	return NormalizedEvent{
-		MessageID: messageID,
-		TenantID:  tenant,
-		Provider:  "ses",
-		Status:    status,
-		Occurred:  time.Now().UTC(),
-		Meta:      payload,
-	}, nil
-}
-
-func (s *Server) normalizeSendGrid(payload map[string]any) (NormalizedEvent, error) {
-	messageID, _ := payload["sg_message_id"].(string)
-	if messageID == "" {
-		return NormalizedEvent{}, errors.New("sendgrid sg_message_id missing")
-	}
-	status, _ := payload["event"].(string)
-	if status == "" {
-		return NormalizedEvent{}, errors.New("sendgrid event missing")
-	}
-	tenant, _ := payload["tenant_id"].(string)
-	return NormalizedEvent{
-		MessageID: messageID,
-		TenantID:  tenant,
-		Provider:  "sendgrid",
-		Status:    status,
-		Occurred:  time.Now().UTC(),
-		Meta:      payload,
-	}, nil
+type NormalizedEvent struct {
+	// EventID identifies the provider event: the provider's own event id,
+	// or a hash of the payload. It is the same for provider retries and
+	// reprocessed callbacks, so consumers can drop repeats.
+	EventID   string `json:"event_id,omitempty"`
+	MessageID string `json:"message_id"`
+	TenantID  string `json:"tenant_id"`
+	Provider  string `json:"provider"`
+	// Channel is the channel the message was sent on. Fallback chains reuse
+	// the message id, so consumers tell the steps apart by it.
+	Channel   string `json:"channel"`
+	Status    string `json:"status"`
+	Recipient string `json:"recipient,omitempty"`
+	// BounceType is "hard" or "soft" for bounce events and BounceSubType
+	// the provider's classification, e.g. "NoEmail" or "Invalid Address".
+	BounceType    string `json:"bounce_type,omitempty"`
+	BounceSubType string `json:"bounce_subtype,omitempty"`
+	// ReasonCode is the SMTP status or provider code behind a failure,
+	// complaint or delay, and Reason its diagnostic text.
+	ReasonCode string `json:"reason_code,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	// Occurred is the provider's event time.
+	Occurred time.Time              `json:"occurred_at"`
+	Meta     map[string]interface{} `json:"meta"`
 }
 
 func (s *Server) respondErr(ctx context.Context, w http.ResponseWriter, status int, err error) {
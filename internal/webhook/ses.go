@@ -0,0 +1,180 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// SES normalizes SES events delivered through SNS. Bodies that are not SNS
+// envelopes are accepted as single flat events when no Verifier is set.
+type SES struct {
+	Verifier Verifier
+}
+
+func (n *SES) Provider() string { return "ses" }
+
+func (n *SES) Verify(r *http.Request, body []byte) error {
+	return verify(n.Verifier, r, body)
+}
+
+func (n *SES) Decode(_ *http.Request, body []byte) (Callback, error) {
+	return decodeSNS(body)
+}
+
+func (n *SES) Normalize(payload map[string]any, received time.Time) (NormalizedEvent, error) {
+	messageID := sesField(payload, "message_id")
+	if messageID == "" {
+		return NormalizedEvent{}, errors.New("ses message_id missing")
+	}
+	status, _ := payload["event"].(string)
+	if status == "" {
+		// SES event publishing uses eventType, feedback notifications
+		// notificationType.
+		status, _ = payload["eventType"].(string)
+	}
+	if status == "" {
+		status, _ = payload["notificationType"].(string)
+	}
+	if status == "" {
+		return NormalizedEvent{}, errors.New("ses event missing")
+	}
+	canonical, err := canonicalStatus(status)
+	if err != nil {
+		return NormalizedEvent{}, err
+	}
+	reasonCode, reason := sesReason(payload)
+	return NormalizedEvent{
+		MessageID:     messageID,
+		TenantID:      sesField(payload, "tenant_id"),
+		Provider:      "ses",
+		Channel:       "email",
+		Status:        canonical,
+		Recipient:     sesRecipient(payload),
+		BounceType:    sesBounceType(payload),
+		BounceSubType: sesBounceSubType(payload),
+		ReasonCode:    reasonCode,
+		Reason:        reason,
+		Occurred:      sesOccurred(payload, received),
+		Meta:          payload,
+	}, nil
+}
+
+// sesSections lists the per-event objects of an SES event, each of which
+// may carry the event's own timestamp.
+var sesSections = []string{"delivery", "bounce", "complaint", "open", "click", "deliveryDelay", "reject", "failure", "send"}
+
+// sesOccurred prefers the event's own timestamp over the send time in mail.
+func sesOccurred(payload map[string]any, received time.Time) time.Time {
+	for _, key := range sesSections {
+		section, _ := payload[key].(map[string]any)
+		if t, ok := timestamp(section["timestamp"]); ok {
+			return t
+		}
+	}
+	if t, ok := timestamp(payload["timestamp"]); ok {
+		return t
+	}
+	mail, _ := payload["mail"].(map[string]any)
+	if t, ok := timestamp(mail["timestamp"]); ok {
+		return t
+	}
+	return received
+}
+
+// sesReason returns the SMTP status (or SES classification) and diagnostic
+// text explaining a bounce, complaint, rejection or delay.
+func sesReason(payload map[string]any) (string, string) {
+	if bounce, ok := payload["bounce"].(map[string]any); ok {
+		recipients, _ := bounce["bouncedRecipients"].([]any)
+		if len(recipients) > 0 {
+			first, _ := recipients[0].(map[string]any)
+			code, _ := first["status"].(string)
+			diagnostic, _ := first["diagnosticCode"].(string)
+			return code, diagnostic
+		}
+	}
+	if complaint, ok := payload["complaint"].(map[string]any); ok {
+		feedback, _ := complaint["complaintFeedbackType"].(string)
+		return feedback, ""
+	}
+	if reject, ok := payload["reject"].(map[string]any); ok {
+		reason, _ := reject["reason"].(string)
+		return "", reason
+	}
+	if failure, ok := payload["failure"].(map[string]any); ok {
+		message, _ := failure["errorMessage"].(string)
+		return "", message
+	}
+	if delay, ok := payload["deliveryDelay"].(map[string]any); ok {
+		delayType, _ := delay["delayType"].(string)
+		recipients, _ := delay["delayedRecipients"].([]any)
+		if len(recipients) > 0 {
+			first, _ := recipients[0].(map[string]any)
+			diagnostic, _ := first["diagnosticCode"].(string)
+			return delayType, diagnostic
+		}
+		return delayType, ""
+	}
+	return "", ""
+}
+
+func sesBounceSubType(payload map[string]any) string {
+	bounce, _ := payload["bounce"].(map[string]any)
+	subType, _ := bounce["bounceSubType"].(string)
+	return subType
+}
+
+// sesField reads key from the flat payload, falling back to the message
+// tags the email worker sends, which SES returns in mail.tags of
+// configuration set events. mail.messageId is SES's own id and is not ours.
+func sesField(payload map[string]any, key string) string {
+	if v, _ := payload[key].(string); v != "" {
+		return v
+	}
+	mail, _ := payload["mail"].(map[string]any)
+	tags, _ := mail["tags"].(map[string]any)
+	if values, _ := tags[key].([]any); len(values) > 0 {
+		if v, _ := values[0].(string); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sesRecipient prefers the bounced or complained recipient over the
+// original destination list.
+func sesRecipient(payload map[string]any) string {
+	if email, _ := payload["email"].(string); email != "" {
+		return email
+	}
+	for _, path := range [][2]string{{"bounce", "bouncedRecipients"}, {"complaint", "complainedRecipients"}} {
+		section, _ := payload[path[0]].(map[string]any)
+		recipients, _ := section[path[1]].([]any)
+		if len(recipients) > 0 {
+			first, _ := recipients[0].(map[string]any)
+			if email, _ := first["emailAddress"].(string); email != "" {
+				return email
+			}
+		}
+	}
+	mail, _ := payload["mail"].(map[string]any)
+	destination, _ := mail["destination"].([]any)
+	if len(destination) > 0 {
+		email, _ := destination[0].(string)
+		return email
+	}
+	return ""
+}
+
+func sesBounceType(payload map[string]any) string {
+	bounce, _ := payload["bounce"].(map[string]any)
+	switch bounce["bounceType"] {
+	case "Permanent":
+		return "hard"
+	case "Transient", "Undetermined":
+		return "soft"
+	default:
+		return ""
+	}
+}
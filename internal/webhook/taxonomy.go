@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/example/notification-service/internal/common"
+)
+
+// Canonical event statuses published on provider.events.
+const (
+	StatusQueued     = common.StatusQueued
+	StatusSent       = common.StatusSent
+	StatusDelivered  = common.StatusDelivered
+	StatusOpened     = common.StatusOpened
+	StatusClicked    = common.StatusClicked
+	StatusBounced    = common.StatusBounced
+	StatusFailed     = common.StatusFailed
+	StatusComplained = common.StatusComplained
+	StatusDeferred   = common.StatusDeferred
+)
+
+// errIgnoredEvent marks provider events with no canonical status, such as
+// SendGrid unsubscribes; they are acknowledged but not published.
+var errIgnoredEvent = errors.New("event has no canonical status")
+
+// canonicalStatuses maps lower-cased provider event names to canonical
+// statuses. Canonical names map to themselves so already-normalized
+// payloads pass through.
+var canonicalStatuses = map[string]string{
+	StatusQueued:     StatusQueued,
+	StatusSent:       StatusSent,
+	StatusDelivered:  StatusDelivered,
+	StatusOpened:     StatusOpened,
+	StatusClicked:    StatusClicked,
+	StatusBounced:    StatusBounced,
+	StatusFailed:     StatusFailed,
+	StatusComplained: StatusComplained,
+	StatusDeferred:   StatusDeferred,
+
+	// SES event publishing and feedback notifications.
+	"send":              StatusSent,
+	"delivery":          StatusDelivered,
+	"bounce":            StatusBounced,
+	"complaint":         StatusComplained,
+	"reject":            StatusFailed,
+	"rendering failure": StatusFailed,
+	"deliverydelay":     StatusDeferred,
+	"open":              StatusOpened,
+	"click":             StatusClicked,
+
+	// SendGrid.
+	"processed":  StatusSent,
+	"dropped":    StatusFailed,
+	"spamreport": StatusComplained,
+}
+
+func canonicalStatus(event string) (string, error) {
+	if status, ok := canonicalStatuses[strings.ToLower(event)]; ok {
+		return status, nil
+	}
+	return "", errIgnoredEvent
+}
+
+// stringField returns the first non-empty string among keys.
+func stringField(payload map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if v, _ := payload[key].(string); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// timestamp reads an RFC 3339 string or Unix seconds, keeping fractional
+// seconds to the millisecond.
+func timestamp(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return parsed.UTC(), true
+	case float64:
+		if t <= 0 {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(int64(t * 1000)).UTC(), true
+	}
+	return time.Time{}, false
+}
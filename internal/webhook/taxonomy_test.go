@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalizeCanonicalStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		payload    string
+		status     string
+		bounceType string
+		subType    string
+		reasonCode string
+		occurred   time.Time
+		ignored    bool
+	}{
+		{
+			name:     "ses delivery",
+			provider: "ses",
+			payload:  `{"eventType":"Delivery","mail":{"messageId":"0100018cc2a1b2c3-ses","tags":{"message_id":["m1"],"tenant_id":["t1"]},"timestamp":"2024-01-01T10:00:00Z"},"delivery":{"timestamp":"2024-01-01T10:00:05.123Z"}}`,
+			status:   StatusDelivered,
+			occurred: time.Date(2024, 1, 1, 10, 0, 5, 123000000, time.UTC),
+		},
+		{
+			name:       "ses permanent bounce",
+			provider:   "ses",
+			payload:    `{"notificationType":"Bounce","mail":{"messageId":"0100018cc2a1b2c3-ses","tags":{"message_id":["m1"],"tenant_id":["t1"]}},"bounce":{"bounceType":"Permanent","bounceSubType":"NoEmail","timestamp":"2024-01-01T10:01:00Z","bouncedRecipients":[{"emailAddress":"a@b.com","status":"5.1.1","diagnosticCode":"smtp; 550 5.1.1 user unknown"}]}}`,
+			status:     StatusBounced,
+			bounceType: "hard",
+			subType:    "NoEmail",
+			reasonCode: "5.1.1",
+			occurred:   time.Date(2024, 1, 1, 10, 1, 0, 0, time.UTC),
+		},
+		{
+			name:       "ses complaint",
+			provider:   "ses",
+			payload:    `{"eventType":"Complaint","mail":{"messageId":"0100018cc2a1b2c3-ses","tags":{"message_id":["m1"],"tenant_id":["t1"]}},"complaint":{"complaintFeedbackType":"abuse","timestamp":"2024-01-01T10:02:00Z"}}`,
+			status:     StatusComplained,
+			reasonCode: "abuse",
+			occurred:   time.Date(2024, 1, 1, 10, 2, 0, 0, time.UTC),
+		},
+		{
+			name:       "ses delivery delay",
+			provider:   "ses",
+			payload:    `{"eventType":"DeliveryDelay","mail":{"messageId":"0100018cc2a1b2c3-ses","tags":{"message_id":["m1"],"tenant_id":["t1"]}},"deliveryDelay":{"delayType":"MailboxFull","timestamp":"2024-01-01T10:03:00Z"}}`,
+			status:     StatusDeferred,
+			reasonCode: "MailboxFull",
+			occurred:   time.Date(2024, 1, 1, 10, 3, 0, 0, time.UTC),
+		},
+		{
+			name:     "ses legacy flat event",
+			provider: "ses",
+			payload:  `{"event":"Reject","message_id":"m1","timestamp":"2024-01-01T10:04:00Z"}`,
+			status:   StatusFailed,
+			occurred: time.Date(2024, 1, 1, 10, 4, 0, 0, time.UTC),
+		},
+		{
+			name:       "sendgrid blocked bounce",
+			provider:   "sendgrid",
+			payload:    `{"event":"bounce","type":"blocked","sg_message_id":"14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0","message_id":"m1","tenant_id":"t1","status":"4.7.1","bounce_classification":"Reputation","timestamp":1704103200}`,
+			status:     StatusBounced,
+			bounceType: "soft",
+			subType:    "Reputation",
+			reasonCode: "4.7.1",
+			occurred:   time.Unix(1704103200, 0).UTC(),
+		},
+		{
+			name:     "sendgrid dropped",
+			provider: "sendgrid",
+			payload:  `{"event":"dropped","sg_message_id":"14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0","message_id":"m1","tenant_id":"t1","reason":"Bounced Address","timestamp":1704103200}`,
+			status:   StatusFailed,
+			occurred: time.Unix(1704103200, 0).UTC(),
+		},
+		{
+			name:     "sendgrid processed",
+			provider: "sendgrid",
+			payload:  `{"event":"processed","sg_message_id":"14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0","message_id":"m1","tenant_id":"t1","timestamp":1704103200}`,
+			status:   StatusSent,
+			occurred: time.Unix(1704103200, 0).UTC(),
+		},
+		{
+			name:     "sendgrid spam report",
+			provider: "sendgrid",
+			payload:  `{"event":"spamreport","sg_message_id":"14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0","message_id":"m1","tenant_id":"t1","timestamp":1704103200}`,
+			status:   StatusComplained,
+			occurred: time.Unix(1704103200, 0).UTC(),
+		},
+		{
+			name:     "sendgrid unsubscribe is ignored",
+			provider: "sendgrid",
+			payload:  `{"event":"group_unsubscribe","sg_message_id":"14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0","message_id":"m1","tenant_id":"t1"}`,
+			ignored:  true,
+		},
+	}
+
+	registry := NewRegistry(&SES{}, &SendGrid{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload map[string]any
+			if err := json.Unmarshal([]byte(tt.payload), &payload); err != nil {
+				t.Fatal(err)
+			}
+			normalizer, _ := registry.Lookup(tt.provider)
+			event, err := normalizer.Normalize(payload, time.Now())
+			if tt.ignored {
+				if !errors.Is(err, errIgnoredEvent) {
+					t.Fatalf("expected the event to be ignored, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Status != tt.status || event.BounceType != tt.bounceType || event.BounceSubType != tt.subType || event.ReasonCode != tt.reasonCode {
+				t.Fatalf("unexpected event %+v", event)
+			}
+			if !event.Occurred.Equal(tt.occurred) {
+				t.Fatalf("occurred %v, want %v", event.Occurred, tt.occurred)
+			}
+		})
+	}
+}
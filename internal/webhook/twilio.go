@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Twilio normalizes SMS and WhatsApp status callbacks. The callback URL the
+// SMS worker registers carries message_id and tenant_id query parameters,
+// which Decode merges over the posted form fields.
+type Twilio struct {
+	Verifier Verifier
+}
+
+func (n *Twilio) Provider() string { return "twilio" }
+
+func (n *Twilio) Verify(r *http.Request, body []byte) error {
+	return verify(n.Verifier, r, body)
+}
+
+func (n *Twilio) Decode(r *http.Request, body []byte) (Callback, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return Callback{}, fmt.Errorf("decode twilio form: %w", err)
+	}
+	payload := make(map[string]any, len(form))
+	for key, values := range form {
+		payload[key] = values[0]
+	}
+	for key, values := range r.URL.Query() {
+		payload[key] = values[0]
+	}
+	return Callback{Events: []map[string]any{payload}}, nil
+}
+
+// twilioStatuses maps MessageStatus values; canceled messages were never
+// sent and are ignored.
+var twilioStatuses = map[string]string{
+	"accepted":    StatusQueued,
+	"scheduled":   StatusQueued,
+	"queued":      StatusQueued,
+	"sending":     StatusSent,
+	"sent":        StatusSent,
+	"delivered":   StatusDelivered,
+	"read":        StatusOpened,
+	"undelivered": StatusFailed,
+	"failed":      StatusFailed,
+}
+
+func (n *Twilio) Normalize(payload map[string]any, received time.Time) (NormalizedEvent, error) {
+	messageID := stringField(payload, "message_id", "MessageSid", "SmsSid")
+	if messageID == "" {
+		return NormalizedEvent{}, errors.New("twilio MessageSid missing")
+	}
+	raw := stringField(payload, "MessageStatus", "SmsStatus")
+	if raw == "" {
+		return NormalizedEvent{}, errors.New("twilio MessageStatus missing")
+	}
+	status, ok := twilioStatuses[strings.ToLower(raw)]
+	if !ok {
+		return NormalizedEvent{}, errIgnoredEvent
+	}
+	tenant, _ := payload["tenant_id"].(string)
+	recipient, _ := payload["To"].(string)
+	reasonCode, _ := payload["ErrorCode"].(string)
+	reason, _ := payload["ErrorMessage"].(string)
+	return NormalizedEvent{
+		MessageID:  messageID,
+		TenantID:   tenant,
+		Provider:   "twilio",
+		Channel:    twilioChannel(recipient),
+		Status:     status,
+		Recipient:  recipient,
+		ReasonCode: reasonCode,
+		Reason:     reason,
+		Occurred:   received,
+		Meta:       payload,
+	}, nil
+}
+
+// twilioChannel tells WhatsApp messages sent through Twilio, addressed as
+// "whatsapp:+...", from SMS.
+func twilioChannel(to string) string {
+	if strings.HasPrefix(to, "whatsapp:") {
+		return "whatsapp"
+	}
+	return "sms"
+}
+
+// TwilioVerifier checks X-Twilio-Signature: a base64 HMAC-SHA1, keyed by
+// the account auth token, of the full callback URL followed by the sorted
+// form fields. BaseURL is the public scheme and host Twilio calls, since
+// proxies rewrite the request's own.
+type TwilioVerifier struct {
+	AuthToken string
+	BaseURL   string
+}
+
+func (v *TwilioVerifier) Verify(r *http.Request, body []byte) error {
+	signature := r.Header.Get("X-Twilio-Signature")
+	if signature == "" {
+		return fmt.Errorf("%w: missing twilio signature", ErrUnauthenticated)
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return fmt.Errorf("%w: invalid twilio form", ErrUnauthenticated)
+	}
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(strings.TrimSuffix(v.BaseURL, "/"))
+	b.WriteString(r.URL.RequestURI())
+	for _, key := range keys {
+		for _, value := range form[key] {
+			b.WriteString(key)
+			b.WriteString(value)
+		}
+	}
+	mac := hmac.New(sha1.New, []byte(v.AuthToken))
+	mac.Write([]byte(b.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("%w: twilio signature mismatch", ErrUnauthenticated)
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+package webhook
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnauthenticated marks a webhook whose signature or timestamp does not
+// check out.
+var ErrUnauthenticated = errors.New("webhook authentication failed")
+
+const (
+	defaultSendGridMaxSkew = 5 * time.Minute
+	// SNS signs the original publish time and retries for up to an hour.
+	defaultSNSMaxAge = time.Hour
+)
+
+// Verifier authenticates a provider callback from its headers and raw body.
+type Verifier interface {
+	Verify(r *http.Request, body []byte) error
+}
+
+// Unverified accepts every request. It exists for WEBHOOK_ALLOW_UNVERIFIED
+// only, e.g. local development against provider sandboxes.
+type Unverified struct{}
+
+func (Unverified) Verify(*http.Request, []byte) error { return nil }
+
+// SendGridVerifier checks SendGrid's signed Event Webhook: an ECDSA
+// signature over the timestamp header followed by the raw body.
+type SendGridVerifier struct {
+	PublicKey *ecdsa.PublicKey
+	MaxSkew   time.Duration
+	Now       func() time.Time
+}
+
+// ParseSendGridPublicKey decodes the base64 DER key shown in SendGrid's
+// signed webhook settings.
+func ParseSendGridPublicKey(encoded string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decode sendgrid public key: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse sendgrid public key: %w", err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("sendgrid public key is not an ECDSA key")
+	}
+	return ecKey, nil
+}
+
+func (v *SendGridVerifier) Verify(r *http.Request, body []byte) error {
+	signature := r.Header.Get("X-Twilio-Email-Event-Webhook-Signature")
+	timestamp := r.Header.Get("X-Twilio-Email-Event-Webhook-Timestamp")
+	if signature == "" || timestamp == "" {
+		return fmt.Errorf("%w: missing sendgrid signature headers", ErrUnauthenticated)
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid sendgrid timestamp", ErrUnauthenticated)
+	}
+	maxSkew := v.MaxSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultSendGridMaxSkew
+	}
+	if skew := now(v.Now).Sub(time.Unix(seconds, 0)); skew > maxSkew || skew < -maxSkew {
+		return fmt.Errorf("%w: sendgrid timestamp outside %s", ErrUnauthenticated, maxSkew)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: invalid sendgrid signature encoding", ErrUnauthenticated)
+	}
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(v.PublicKey, digest[:], sig) {
+		return fmt.Errorf("%w: sendgrid signature mismatch", ErrUnauthenticated)
+	}
+	return nil
+}
+
+// HMACVerifier checks a hex HMAC-SHA256 of the raw body sent in Header,
+// optionally prefixed "sha256=" as Meta's X-Hub-Signature-256 is.
+type HMACVerifier struct {
+	Header string
+	Secret []byte
+}
+
+func (v *HMACVerifier) Verify(r *http.Request, body []byte) error {
+	signature := strings.TrimPrefix(r.Header.Get(v.Header), "sha256=")
+	if signature == "" {
+		return fmt.Errorf("%w: missing %s", ErrUnauthenticated, v.Header)
+	}
+	mac := hmac.New(sha256.New, v.Secret)
+	mac.Write(body)
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(signature)) {
+		return fmt.Errorf("%w: %s mismatch", ErrUnauthenticated, v.Header)
+	}
+	return nil
+}
+
+// BasicAuthVerifier checks credentials embedded in the webhook URL.
+type BasicAuthVerifier struct {
+	Username string
+	Password string
+}
+
+func (v *BasicAuthVerifier) Verify(r *http.Request, _ []byte) error {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return fmt.Errorf("%w: missing basic auth", ErrUnauthenticated)
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(v.Username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(v.Password)) == 1
+	if !userOK || !passwordOK {
+		return fmt.Errorf("%w: invalid basic auth", ErrUnauthenticated)
+	}
+	return nil
+}
+
+// snsCertHost matches the hosts SNS serves signing certificates from.
+var snsCertHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// snsEnvelope is the JSON body SNS posts for notifications and
+// subscription confirmations.
+type snsEnvelope struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// stringToSign builds the canonical text SNS signs for e.Type.
+func (e snsEnvelope) stringToSign() string {
+	var fields [][2]string
+	switch e.Type {
+	case "Notification":
+		fields = [][2]string{{"Message", e.Message}, {"MessageId", e.MessageID}}
+		if e.Subject != "" {
+			fields = append(fields, [2]string{"Subject", e.Subject})
+		}
+		fields = append(fields, [][2]string{{"Timestamp", e.Timestamp}, {"TopicArn", e.TopicArn}, {"Type", e.Type}}...)
+	default:
+		fields = [][2]string{
+			{"Message", e.Message},
+			{"MessageId", e.MessageID},
+			{"SubscribeURL", e.SubscribeURL},
+			{"Timestamp", e.Timestamp},
+			{"Token", e.Token},
+			{"TopicArn", e.TopicArn},
+			{"Type", e.Type},
+		}
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(f[0])
+		b.WriteByte('\n')
+		b.WriteString(f[1])
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// SNSVerifier checks the signature SNS puts on every message it delivers.
+// Signing certificates are fetched once per URL and cached; tests and
+// air-gapped deployments can supply Fetch to serve them locally.
+//
+// A valid signature only proves the message came from some SNS topic, so
+// messages are also required to name one of AllowedTopicARNs; with none
+// configured every message is rejected.
+type SNSVerifier struct {
+	AllowedTopicARNs []string
+
+	Fetch  func(ctx context.Context, certURL string) (*x509.Certificate, error)
+	MaxAge time.Duration
+	Now    func() time.Time
+
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+func (v *SNSVerifier) Verify(r *http.Request, body []byte) error {
+	var e snsEnvelope
+	if err := json.Unmarshal(body, &e); err != nil {
+		return fmt.Errorf("%w: body is not an SNS message", ErrUnauthenticated)
+	}
+	if e.Signature == "" || e.SigningCertURL == "" {
+		return fmt.Errorf("%w: unsigned SNS message", ErrUnauthenticated)
+	}
+	if !topicAllowed(v.AllowedTopicARNs, e.TopicArn) {
+		return fmt.Errorf("%w: SNS topic %q is not allowed", ErrUnauthenticated, e.TopicArn)
+	}
+	sent, err := time.Parse(time.RFC3339, e.Timestamp)
+	if err != nil {
+		return fmt.Errorf("%w: invalid SNS timestamp", ErrUnauthenticated)
+	}
+	maxAge := v.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultSNSMaxAge
+	}
+	if age := now(v.Now).Sub(sent); age > maxAge || age < -defaultSendGridMaxSkew {
+		return fmt.Errorf("%w: SNS message timestamp outside %s", ErrUnauthenticated, maxAge)
+	}
+
+	var (
+		hash   crypto.Hash
+		digest []byte
+	)
+	switch e.SignatureVersion {
+	case "1":
+		sum := sha1.Sum([]byte(e.stringToSign()))
+		hash, digest = crypto.SHA1, sum[:]
+	case "2":
+		sum := sha256.Sum256([]byte(e.stringToSign()))
+		hash, digest = crypto.SHA256, sum[:]
+	default:
+		return fmt.Errorf("%w: unsupported SNS signature version %q", ErrUnauthenticated, e.SignatureVersion)
+	}
+	sig, err := base64.StdEncoding.DecodeString(e.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: invalid SNS signature encoding", ErrUnauthenticated)
+	}
+	cert, err := v.certificate(r.Context(), e.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: SNS certificate has no RSA key", ErrUnauthenticated)
+	}
+	if err := rsa.VerifyPKCS1v15(key, hash, digest, sig); err != nil {
+		return fmt.Errorf("%w: SNS signature mismatch", ErrUnauthenticated)
+	}
+	return nil
+}
+
+func topicAllowed(allowed []string, topicARN string) bool {
+	for _, arn := range allowed {
+		if topicARN != "" && arn == topicARN {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *SNSVerifier) certificate(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || !snsCertHost.MatchString(u.Hostname()) {
+		return nil, fmt.Errorf("%w: untrusted SNS certificate URL %q", ErrUnauthenticated, certURL)
+	}
+
+	v.mu.Lock()
+	cert, ok := v.certs[certURL]
+	v.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	fetch := v.Fetch
+	if fetch == nil {
+		fetch = fetchCertificate
+	}
+	cert, err = fetch(ctx, certURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch SNS certificate: %w", err)
+	}
+	v.mu.Lock()
+	if v.certs == nil {
+		v.certs = map[string]*x509.Certificate{}
+	}
+	v.certs[certURL] = cert
+	v.mu.Unlock()
+	return cert, nil
+}
+
+func fetchCertificate(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func now(clock func() time.Time) time.Time {
+	if clock != nil {
+		return clock()
+	}
+	return time.Now()
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSendGridVerifier(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := ParseSendGridPublicKey(base64.StdEncoding.EncodeToString(der))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Unix(1700000000, 0)
+	v := &SendGridVerifier{PublicKey: public, Now: func() time.Time { return clock }}
+	body := []byte(`[{"event":"delivered","message_id":"m1","tenant_id":"t1"}]`)
+
+	sign := func(timestamp string, body []byte) string {
+		digest := sha256.Sum256(append([]byte(timestamp), body...))
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+	fresh := strconv.FormatInt(clock.Unix(), 10)
+	stale := strconv.FormatInt(clock.Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		body      []byte
+		ok        bool
+	}{
+		{"valid", fresh, sign(fresh, body), body, true},
+		{"tampered body", fresh, sign(fresh, body), []byte(`[{"event":"bounce"}]`), false},
+		{"replayed", stale, sign(stale, body), body, false},
+		{"timestamp swapped", stale, sign(fresh, body), body, false},
+		{"missing signature", fresh, "", body, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/providers/sendgrid/events", nil)
+			r.Header.Set("X-Twilio-Email-Event-Webhook-Timestamp", tt.timestamp)
+			if tt.signature != "" {
+				r.Header.Set("X-Twilio-Email-Event-Webhook-Signature", tt.signature)
+			}
+			err := v.Verify(r, tt.body)
+			if (err == nil) != tt.ok {
+				t.Fatalf("Verify err=%v, want ok=%v", err, tt.ok)
+			}
+			if err != nil && !errors.Is(err, ErrUnauthenticated) {
+				t.Fatalf("expected ErrUnauthenticated, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSNSVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetches := 0
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	v := &SNSVerifier{
+		AllowedTopicARNs: []string{"arn:aws:sns:us-east-1:123456789012:ses-events"},
+		Fetch: func(context.Context, string) (*x509.Certificate, error) {
+			fetches++
+			return cert, nil
+		},
+		Now: func() time.Time { return clock },
+	}
+
+	signed := func(e snsEnvelope) []byte {
+		e.SignatureVersion = "2"
+		digest := sha256.Sum256([]byte(e.stringToSign()))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Signature = base64.StdEncoding.EncodeToString(sig)
+		body, _ := json.Marshal(e)
+		return body
+	}
+	notification := snsEnvelope{
+		Type:           "Notification",
+		MessageID:      "n1",
+		TopicArn:       "arn:aws:sns:us-east-1:123456789012:ses-events",
+		Message:        `{"eventType":"Delivery"}`,
+		Timestamp:      clock.Add(-time.Minute).Format(time.RFC3339),
+		SigningCertURL: "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-abc.pem",
+	}
+	confirmation := notification
+	confirmation.Type = "SubscriptionConfirmation"
+	confirmation.Token = "tok"
+	confirmation.SubscribeURL = "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription"
+	stale := notification
+	stale.Timestamp = clock.Add(-2 * time.Hour).Format(time.RFC3339)
+	foreignCert := notification
+	foreignTopic := notification
+	foreignTopic.TopicArn = "arn:aws:sns:us-east-1:999999999999:ses-events"
+	foreignCert.SigningCertURL = "https://attacker.example.com/cert.pem"
+
+	tampered := signed(notification)
+	var e snsEnvelope
+	_ = json.Unmarshal(tampered, &e)
+	e.Message = `{"eventType":"Bounce"}`
+	tampered, _ = json.Marshal(e)
+
+	tests := []struct {
+		name string
+		body []byte
+		ok   bool
+	}{
+		{"notification", signed(notification), true},
+		{"subscription confirmation", signed(confirmation), true},
+		{"tampered message", tampered, false},
+		{"stale", signed(stale), false},
+		{"foreign certificate host", signed(foreignCert), false},
+		{"foreign topic", signed(foreignTopic), false},
+		{"not sns", []byte(`{"event":"delivered"}`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Verify(httptest.NewRequest("POST", "/v1/providers/ses/events", nil), tt.body)
+			if (err == nil) != tt.ok {
+				t.Fatalf("Verify err=%v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+	if fetches != 1 {
+		t.Fatalf("expected the certificate to be fetched once, got %d", fetches)
+	}
+}
+
+func TestVerifyWithoutCredentials(t *testing.T) {
+	r := httptest.NewRequest("POST", "/v1/providers/sendgrid/events", nil)
+	for _, n := range []Normalizer{&SendGrid{}, &Twilio{}, &Mailgun{}, &Postmark{}, &FCM{}, &WhatsApp{}, &SES{}} {
+		if err := n.Verify(r, []byte(`{}`)); !errors.Is(err, ErrUnauthenticated) {
+			t.Fatalf("%s: Verify err=%v, want ErrUnauthenticated", n.Provider(), err)
+		}
+	}
+	if err := (&SendGrid{Verifier: Unverified{}}).Verify(r, []byte(`{}`)); err != nil {
+		t.Fatalf("Unverified rejected the request: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WhatsApp normalizes WhatsApp Cloud API webhooks: every status in every
+// entry becomes one event. Sends set biz_opaque_callback_data to a JSON
+// object with message_id and tenant_id.
+type WhatsApp struct {
+	Verifier Verifier
+}
+
+func (n *WhatsApp) Provider() string { return "whatsapp" }
+
+func (n *WhatsApp) Verify(r *http.Request, body []byte) error {
+	return verify(n.Verifier, r, body)
+}
+
+type whatsAppBody struct {
+	Entry []struct {
+		Changes []struct {
+			Value struct {
+				Statuses []map[string]any `json:"statuses"`
+			} `json:"value"`
+		} `json:"changes"`
+	} `json:"entry"`
+}
+
+func (n *WhatsApp) Decode(_ *http.Request, body []byte) (Callback, error) {
+	var b whatsAppBody
+	if err := json.Unmarshal(body, &b); err != nil {
+		return Callback{}, fmt.Errorf("decode whatsapp webhook: %w", err)
+	}
+	var cb Callback
+	for _, entry := range b.Entry {
+		for _, change := range entry.Changes {
+			cb.Events = append(cb.Events, change.Value.Statuses...)
+		}
+	}
+	return cb, nil
+}
+
+var whatsAppStatuses = map[string]string{
+	"sent":      StatusSent,
+	"delivered": StatusDelivered,
+	"read":      StatusOpened,
+	"failed":    StatusFailed,
+}
+
+func (n *WhatsApp) Normalize(payload map[string]any, received time.Time) (NormalizedEvent, error) {
+	var callbackData map[string]any
+	if raw, _ := payload["biz_opaque_callback_data"].(string); raw != "" {
+		_ = json.Unmarshal([]byte(raw), &callbackData)
+	}
+	messageID := stringField(callbackData, "message_id")
+	if messageID == "" {
+		messageID = stringField(payload, "id")
+	}
+	if messageID == "" {
+		return NormalizedEvent{}, errors.New("whatsapp status id missing")
+	}
+	raw := stringField(payload, "status")
+	if raw == "" {
+		return NormalizedEvent{}, errors.New("whatsapp status missing")
+	}
+	status, ok := whatsAppStatuses[raw]
+	if !ok {
+		return NormalizedEvent{}, errIgnoredEvent
+	}
+
+	event := NormalizedEvent{
+		MessageID: messageID,
+		TenantID:  stringField(callbackData, "tenant_id"),
+		Provider:  "whatsapp",
+		Channel:   "whatsapp",
+		Status:    status,
+		Recipient: stringField(payload, "recipient_id"),
+		Occurred:  received,
+		Meta:      payload,
+	}
+	if seconds, err := strconv.ParseInt(stringField(payload, "timestamp"), 10, 64); err == nil {
+		event.Occurred = time.Unix(seconds, 0).UTC()
+	}
+	if errs, _ := payload["errors"].([]any); len(errs) > 0 {
+		first, _ := errs[0].(map[string]any)
+		if code, ok := first["code"].(float64); ok {
+			event.ReasonCode = strconv.Itoa(int(code))
+		}
+		event.Reason = stringField(first, "title", "message")
+	}
+	return event, nil
+}